@@ -0,0 +1,38 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// LevelDB returns the RMS level of PCM in dBFS, -100 for silence
+func LevelDB(pcm []byte) float64 {
+	return toDB(rms(Samples(pcm)))
+}
+
+// Levels returns the RMS level in dBFS of each full window of PCM
+func Levels(pcm []byte, window time.Duration) []float64 {
+	samples := Samples(pcm)
+	size := int(window.Seconds() * SampleRate)
+	if size < 1 {
+		return nil
+	}
+	levels := make([]float64, 0, len(samples)/size)
+	for start := 0; start+size <= len(samples); start += size {
+		levels = append(levels, toDB(rms(samples[start:start+size])))
+	}
+	return levels
+}
+
+// rms is the root mean square of samples, from 0 to 1
+func rms(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		f := float64(s) / 32768
+		sum += f * f
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
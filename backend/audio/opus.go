@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+// opusMaxFrame is the longest Opus packet, 120ms, in samples per channel at
+// SampleRate
+const opusMaxFrame = SampleRate * 120 / 1000
+
+// OpusReader decodes an Ogg/Opus stream, as browsers record it, to 16 kHz
+// 16-bit PCM as it is read. Mono and stereo streams are supported; streams
+// of more channels need ffmpeg.
+type OpusReader struct {
+	ogg      *oggreader.OggReader
+	decoder  opus.Decoder
+	channels int
+	skip     int // samples per channel still to drop for the encoder delay
+	pending  []byte
+	frame    []int16
+	out      []byte
+}
+
+// NewOpusReader reads the header of an Ogg/Opus stream and returns a reader
+// of its audio as 16 kHz PCM with the given number of interleaved channels
+func NewOpusReader(r io.Reader, channels int) (*OpusReader, error) {
+	ogg, header, err := oggreader.NewWith(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not Ogg/Opus: %v", ErrUnsupported, err)
+	}
+	if header.ChannelMap > 1 || header.Channels > 2 {
+		return nil, fmt.Errorf("%w: Opus with %d channels", ErrUnsupported, header.Channels)
+	}
+	decoder, err := opus.NewDecoderWithOutput(SampleRate, channels)
+	if err != nil {
+		return nil, fmt.Errorf("opus: %w", err)
+	}
+
+	return &OpusReader{
+		ogg:      ogg,
+		decoder:  decoder,
+		channels: channels,
+		// Pre-skip is counted at 48 kHz
+		skip:  int(header.PreSkip) * SampleRate / 48000,
+		frame: make([]int16, opusMaxFrame*channels),
+	}, nil
+}
+
+func (o *OpusReader) Read(p []byte) (int, error) {
+	for len(o.pending) == 0 {
+		if err := o.decodePacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, o.pending)
+	o.pending = o.pending[n:]
+	return n, nil
+}
+
+// decodePacket decodes the next audio packet. A recording cut off mid-page,
+// as when a browser tab closes, simply ends.
+func (o *OpusReader) decodePacket() error {
+	packet, _, err := o.ogg.ParseNextPacket()
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return io.EOF
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(packet, []byte("OpusTags")) || len(packet) == 0 {
+		return nil
+	}
+
+	samples, err := o.decoder.DecodeToInt16(packet, o.frame)
+	if err != nil {
+		return fmt.Errorf("opus: %w", err)
+	}
+	start := 0
+	if o.skip > 0 {
+		start = o.skip
+		if start > samples {
+			start = samples
+		}
+		o.skip -= start
+	}
+
+	o.out = o.out[:0]
+	for _, v := range o.frame[start*o.channels : samples*o.channels] {
+		o.out = binary.LittleEndian.AppendUint16(o.out, uint16(v))
+	}
+	o.pending = o.out
+	return nil
+}
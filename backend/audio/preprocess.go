@@ -0,0 +1,348 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+)
+
+// Audio quality warnings, as returned to clients
+const (
+	WarningTooQuiet = "input too quiet"
+	WarningClipping = "clipping detected"
+	WarningNoisy    = "background noise is high"
+)
+
+// PreprocessConfig tunes the Preprocessor
+type PreprocessConfig struct {
+	// TargetDB is the RMS level, in dBFS, speech is normalized to
+	TargetDB float64
+	// PeakDB is the highest level, in dBFS, normalization may raise a peak to
+	PeakDB float64
+	// MaxGainDB limits amplification, so a distant microphone is not
+	// boosted into loud hiss
+	MaxGainDB float64
+	// GateDB is how far above the noise floor a frame must be to pass the
+	// noise gate untouched
+	GateDB float64
+	// GateReductionDB is how much quieter the gate makes frames below it
+	GateReductionDB float64
+	// GateHold keeps the gate open after speech so word tails are not cut
+	GateHold time.Duration
+	// QuietDB is the speech level, in dBFS, below which the input is too
+	// quiet to transcribe reliably even when amplified
+	QuietDB float64
+	// MinSNRDB is how far speech should be above the noise floor
+	MinSNRDB float64
+	// ClipRatio is the share of samples at full scale that means the input
+	// clipped
+	ClipRatio float64
+	// Adapt is how long a stream takes to settle on new levels
+	Adapt time.Duration
+}
+
+// DefaultPreprocessConfig suits speech from a laptop microphone
+func DefaultPreprocessConfig() PreprocessConfig {
+	return PreprocessConfig{
+		TargetDB:        -20,
+		PeakDB:          -1,
+		MaxGainDB:       30,
+		GateDB:          6,
+		GateReductionDB: 20,
+		GateHold:        200 * time.Millisecond,
+		QuietDB:         -45,
+		MinSNRDB:        15,
+		ClipRatio:       0.001,
+		Adapt:           5 * time.Second,
+	}
+}
+
+const (
+	// preprocessFrame is the span levels are measured and gated over
+	preprocessFrame = SampleRate / 50 // 20ms
+	// dcPole sets the DC blocker's cutoff, about 13 Hz
+	dcPole = 0.995
+	// clipLevel is the magnitude counted as full scale
+	clipLevel = 32440
+	// speechAboveFloorDB is how far above the noise floor a frame counts as
+	// speech when measuring the speech level
+	speechAboveFloorDB = 6
+	// floorPercentile is the share of frames taken to be below the noise
+	// floor
+	floorPercentile = 0.1
+	// settleSeconds of audio are measured before levels are judged
+	settleSeconds = 2
+)
+
+// Preprocessor conditions 16 kHz mono PCM before transcription: it removes
+// DC offset, normalizes speech to a steady level without letting peaks
+// clip, and turns down the background between words with a noise gate
+// driven by the estimated noise floor. It also notices input that is too
+// quiet, noisy or clipped.
+//
+// A preprocessor keeps its state between calls, so a stream can be passed
+// through block by block; its levels then adapt over Adapt. A recording
+// passed in one call is measured as a whole. A nil Preprocessor passes
+// audio through untouched.
+type Preprocessor struct {
+	cfg PreprocessConfig
+
+	prevIn, prevOut float64 // DC blocker state
+	measured        bool    // whether the levels below have been estimated
+	floorDB         float64
+	speechDB        float64
+	speechSeconds   float64 // speech measured so far, up to Adapt
+	gain            float64 // linear gain at the end of the last block
+	gate            float64 // linear gate gain at the end of the last frame
+	hold            int     // frames the gate stays open for
+	frameSum        float64 // energy of the frame in progress
+	frameLen        int     // samples in the frame in progress
+	carry           []byte  // odd byte left over from the last block
+
+	samples, clipped int64
+	warnings         []string
+}
+
+func NewPreprocessor(cfg PreprocessConfig) *Preprocessor {
+	return &Preprocessor{cfg: cfg, gain: 1, gate: 1}
+}
+
+// Process returns the conditioned audio
+func (p *Preprocessor) Process(pcm []byte) []byte {
+	if p == nil {
+		return pcm
+	}
+	if len(p.carry) > 0 {
+		pcm = append(p.carry, pcm...)
+		p.carry = nil
+	}
+	if len(pcm)%BytesPerSample != 0 {
+		p.carry = []byte{pcm[len(pcm)-1]}
+		pcm = pcm[:len(pcm)-1]
+	}
+	raw := Samples(pcm)
+	if len(raw) == 0 {
+		return pcm
+	}
+
+	first := p.samples == 0
+	x := p.removeDC(raw)
+	frames := p.frames(x)
+	p.measure(frames)
+
+	out := make([]byte, len(pcm))
+	gain := p.blockGain(x)
+	if first || gain < p.gain {
+		// Start at the right level, and turn down at once so a sudden loud
+		// block cannot clip
+		p.gain = gain
+	}
+	start := 0
+	for _, f := range frames {
+		target := p.gateTarget(f)
+		for i := start; i < f.end; i++ {
+			// Ramp the gain up across the block and the gate across the
+			// frame, so changes do not click
+			g := p.gain + (gain-p.gain)*float64(i+1)/float64(len(x))
+			gate := p.gate + (target-p.gate)*float64(i-start+1)/float64(f.end-start)
+			binary.LittleEndian.PutUint16(out[i*BytesPerSample:], uint16(clamp16(float32(x[i]*g*gate))))
+		}
+		p.gate = target
+		start = f.end
+	}
+	p.gain = gain
+
+	p.judge()
+	return out
+}
+
+// removeDC filters out DC offset and rumble below about 13 Hz, counting
+// clipped samples on the way. The filter starts from the first block's
+// mean, so a large offset does not click at the start.
+func (p *Preprocessor) removeDC(raw []int16) []float64 {
+	if p.samples == 0 {
+		var sum float64
+		for _, s := range raw {
+			sum += float64(s)
+		}
+		p.prevIn = sum / float64(len(raw))
+	}
+
+	x := make([]float64, len(raw))
+	for i, s := range raw {
+		if s >= clipLevel || s <= -clipLevel {
+			p.clipped++
+		}
+		in := float64(s)
+		p.prevOut = in - p.prevIn + dcPole*p.prevOut
+		p.prevIn = in
+		x[i] = p.prevOut
+	}
+	p.samples += int64(len(raw))
+	return x
+}
+
+// frame is the part of a block that falls in one frame. Frames are counted
+// from the start of the audio, so levels do not depend on how a stream is
+// split into blocks; the last frame of a block may go on in the next.
+type frame struct {
+	end      int     // index in the block just after the frame
+	level    float64 // RMS level in dBFS of the frame so far
+	length   int     // samples in the frame so far
+	complete bool
+}
+
+func (p *Preprocessor) frames(x []float64) []frame {
+	frames := make([]frame, 0, len(x)/preprocessFrame+2)
+	for i, v := range x {
+		p.frameSum += v * v
+		p.frameLen++
+		complete := p.frameLen == preprocessFrame
+		if complete || i == len(x)-1 {
+			frames = append(frames, frame{
+				end:      i + 1,
+				level:    toDB(math.Sqrt(p.frameSum/float64(p.frameLen)) / 32768),
+				length:   p.frameLen,
+				complete: complete,
+			})
+		}
+		if complete {
+			p.frameSum, p.frameLen = 0, 0
+		}
+	}
+	return frames
+}
+
+// gateTarget returns the gate gain for a frame. The hold only counts down
+// on whole frames, and a frame too short to judge leaves the gate as it is.
+func (p *Preprocessor) gateTarget(f frame) float64 {
+	open := f.level >= p.floorDB+p.cfg.GateDB
+	if !f.complete {
+		if f.length < preprocessFrame/2 {
+			return p.gate
+		}
+		open = open || p.hold > 0
+	} else if open {
+		p.hold = int(p.cfg.GateHold.Seconds() * SampleRate / preprocessFrame)
+	} else if p.hold > 0 {
+		p.hold--
+		open = true
+	}
+	if open {
+		return 1
+	}
+	return dbToGain(-p.cfg.GateReductionDB)
+}
+
+// measure updates the noise floor and speech level from the frames a block
+// completed. The floor follows a quieter block at once and a louder one over
+// Adapt, as speech raises a block's quietest frames but never lowers them;
+// the speech level is the average power of frames well above the floor.
+func (p *Preprocessor) measure(frames []frame) {
+	var levels []float64
+	for _, f := range frames {
+		if f.complete {
+			levels = append(levels, f.level)
+		}
+	}
+	if len(levels) == 0 {
+		return
+	}
+	sorted := append([]float64(nil), levels...)
+	sort.Float64s(sorted)
+	floor := sorted[int(float64(len(sorted)-1)*floorPercentile)]
+
+	weight := 1.0
+	if p.measured {
+		seconds := float64(len(levels)*preprocessFrame) / SampleRate
+		weight = seconds / (seconds + p.cfg.Adapt.Seconds())
+	}
+	if !p.measured || floor < p.floorDB {
+		p.floorDB = floor
+	} else {
+		p.floorDB += weight * (floor - p.floorDB)
+	}
+	p.measured = true
+
+	var power float64
+	n := 0
+	for _, level := range levels {
+		if level >= p.floorDB+speechAboveFloorDB {
+			power += math.Pow(10, level/10)
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	// Average over all the speech heard until there is Adapt of it, so the
+	// first few frames do not set the level on their own
+	seconds := float64(n*preprocessFrame) / SampleRate
+	p.speechSeconds = math.Min(p.speechSeconds+seconds, p.cfg.Adapt.Seconds())
+	p.speechDB += seconds / p.speechSeconds * (10*math.Log10(power/float64(n)) - p.speechDB)
+}
+
+// blockGain returns the gain that brings speech to the target level, within
+// MaxGainDB, lowered so the block's loudest sample stays under PeakDB.
+// Until speech is heard the level is left alone.
+func (p *Preprocessor) blockGain(x []float64) float64 {
+	gainDB := 0.0
+	if p.speechSeconds > 0 {
+		gainDB = math.Min(p.cfg.TargetDB-p.speechDB, p.cfg.MaxGainDB)
+	}
+
+	var peak float64
+	for _, v := range x {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	if peak > 0 {
+		gainDB = math.Min(gainDB, p.cfg.PeakDB-toDB(peak/32768))
+	}
+	return dbToGain(gainDB)
+}
+
+// judge raises each warning the first time the audio so far calls for it
+func (p *Preprocessor) judge() {
+	if p.clipped > 0 && float64(p.clipped) >= p.cfg.ClipRatio*float64(p.samples) {
+		p.warn(WarningClipping)
+	}
+	if float64(p.samples)/SampleRate < settleSeconds {
+		return
+	}
+	if p.speechSeconds == 0 {
+		// Sound throughout, with no speech standing out of it
+		if p.floorDB > p.cfg.QuietDB {
+			p.warn(WarningNoisy)
+		}
+		return
+	}
+	if p.speechDB < p.cfg.QuietDB {
+		p.warn(WarningTooQuiet)
+	}
+	if p.speechDB-p.floorDB < p.cfg.MinSNRDB {
+		p.warn(WarningNoisy)
+	}
+}
+
+func (p *Preprocessor) warn(warning string) {
+	for _, w := range p.warnings {
+		if w == warning {
+			return
+		}
+	}
+	p.warnings = append(p.warnings, warning)
+}
+
+// Warnings returns the quality warnings raised so far, in the order they
+// were raised
+func (p *Preprocessor) Warnings() []string {
+	if p == nil {
+		return nil
+	}
+	return append([]string(nil), p.warnings...)
+}
+
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// noise is white noise of the given peak amplitude as PCM, the same on every
+// call
+func noise(seconds, amplitude float64) []byte {
+	rng := rand.New(rand.NewSource(1))
+	n := int(seconds * SampleRate)
+	pcm := make([]byte, 0, n*BytesPerSample)
+	for i := 0; i < n; i++ {
+		v := amplitude * (2*rng.Float64() - 1)
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(v)))
+	}
+	return pcm
+}
+
+// mix adds PCM of the same length sample by sample
+func mix(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	x, y := Samples(a), Samples(b)
+	for i := range x {
+		binary.LittleEndian.PutUint16(out[i*BytesPerSample:], uint16(clamp16(float32(x[i])+float32(y[i]))))
+	}
+	return out
+}
+
+// offset adds a constant to every sample
+func offset(pcm []byte, dc int16) []byte {
+	out := make([]byte, len(pcm))
+	for i, s := range Samples(pcm) {
+		binary.LittleEndian.PutUint16(out[i*BytesPerSample:], uint16(s+dc))
+	}
+	return out
+}
+
+// speech is two tones of the given amplitude between pauses, 4 seconds in
+// all: pauses at 0-0.5, 1.5-2.5 and 3.5-4 seconds
+func speech(amplitude float64) []byte {
+	return concat(silence(0.5), tone(1, amplitude), silence(1), tone(1, amplitude), silence(0.5))
+}
+
+func span(pcm []byte, start, end float64) []byte {
+	return pcm[Offset(start):Offset(end)]
+}
+
+func TestPreprocessorNormalizesLevel(t *testing.T) {
+	cfg := DefaultPreprocessConfig()
+
+	tests := []struct {
+		name      string
+		amplitude float64
+		capped    bool // too quiet to reach the target within MaxGainDB
+	}{
+		{"quiet raised", 1000, false},
+		{"loud lowered", 15000, false},
+		{"at the target", 4634, false},
+		{"gain capped", 30, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := speech(tt.amplitude)
+			out := NewPreprocessor(cfg).Process(pcm)
+			for _, s := range [][2]float64{{0.7, 1.3}, {2.7, 3.3}} {
+				want := cfg.TargetDB
+				if tt.capped {
+					want = LevelDB(span(pcm, s[0], s[1])) + cfg.MaxGainDB
+				}
+				if got := LevelDB(span(out, s[0], s[1])); math.Abs(got-want) > 1 {
+					t.Errorf("level %.1f-%.1fs = %.1f dBFS, want %.1f", s[0], s[1], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPreprocessorKeepsPeaksUnderLimit(t *testing.T) {
+	pcm := speech(1000)
+	click := tone(0.01, 30000)
+	copy(pcm[Offset(1):], click)
+
+	cfg := DefaultPreprocessConfig()
+	limit := 32768 * dbToGain(cfg.PeakDB)
+	out := NewPreprocessor(cfg).Process(pcm)
+	for i, s := range Samples(out) {
+		if math.Abs(float64(s)) > limit+1 {
+			t.Fatalf("sample %d = %d, over the %.0f peak limit", i, s, limit)
+		}
+	}
+}
+
+func TestPreprocessorRemovesDCOffset(t *testing.T) {
+	out := NewPreprocessor(DefaultPreprocessConfig()).Process(offset(speech(3000), 4000))
+	// Leave a moment for the filter to settle
+	var sum float64
+	samples := Samples(span(out, 0.5, 4))
+	for _, s := range samples {
+		sum += float64(s)
+	}
+	if mean := sum / float64(len(samples)); math.Abs(mean) > 50 {
+		t.Fatalf("mean after DC removal = %.0f, want about 0", mean)
+	}
+}
+
+func TestPreprocessorNoiseGate(t *testing.T) {
+	pcm := mix(speech(3000), noise(4, 150))
+	cfg := DefaultPreprocessConfig()
+	out := NewPreprocessor(cfg).Process(pcm)
+
+	// Speech is only scaled by the gain; the pause, past the gate's hold, is
+	// turned down by the gate as well
+	gain := LevelDB(span(out, 0.7, 1.3)) - LevelDB(span(pcm, 0.7, 1.3))
+	pause := LevelDB(span(out, 1.8, 2.3)) - LevelDB(span(pcm, 1.8, 2.3))
+	if got := pause - gain; math.Abs(got+cfg.GateReductionDB) > 1.5 {
+		t.Fatalf("pause turned down %.1f dB more than speech, want %.0f", -got, cfg.GateReductionDB)
+	}
+}
+
+func TestPreprocessorWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		pcm  []byte
+		want []string
+	}{
+		{"clean speech", mix(speech(3000), noise(4, 30)), nil},
+		{"too quiet", speech(30), []string{WarningTooQuiet}},
+		{"clipped", speech(32767), []string{WarningClipping}},
+		{"speech in noise", mix(speech(3000), noise(4, 3000)), []string{WarningNoisy}},
+		{"noise throughout", noise(4, 3000), []string{WarningNoisy}},
+		{"too short to judge", tone(1, 30), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPreprocessor(DefaultPreprocessConfig())
+			p.Process(tt.pcm)
+			if got := p.Warnings(); !slices.Equal(got, tt.want) {
+				t.Fatalf("Warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessorStreaming(t *testing.T) {
+	pcm := mix(speech(3000), noise(4, 30))
+	p := NewPreprocessor(DefaultPreprocessConfig())
+
+	// Blocks of odd byte lengths carry the split sample to the next block
+	var out []byte
+	for rest := pcm; len(rest) > 0; {
+		n := min(3333, len(rest))
+		out = append(out, p.Process(rest[:n])...)
+		rest = rest[n:]
+	}
+	if len(out) != len(pcm) {
+		t.Fatalf("streamed %d bytes out for %d in", len(out), len(pcm))
+	}
+	if got := LevelDB(span(out, 2.7, 3.3)); math.Abs(got+20) > 2 {
+		t.Errorf("speech level = %.1f dBFS once settled, want about -20", got)
+	}
+	if w := p.Warnings(); len(w) != 0 {
+		t.Errorf("Warnings = %q, want none", w)
+	}
+}
+
+func TestNilPreprocessor(t *testing.T) {
+	var p *Preprocessor
+	pcm := tone(0.5, 3000)
+	if out := p.Process(pcm); &out[0] != &pcm[0] {
+		t.Fatal("nil Preprocessor changed the audio")
+	}
+	if p.Warnings() != nil {
+		t.Fatal("nil Preprocessor has warnings")
+	}
+}
@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// resampleZeroCrossings is the half-width of the interpolation kernel,
+	// in zero crossings of its sinc
+	resampleZeroCrossings = 8
+	// resampleTableSteps is how finely the kernel is tabulated per input
+	// sample
+	resampleTableSteps = 256
+	// resampleRolloff keeps the low-pass just below the output's Nyquist
+	// frequency, so downsampling does not alias
+	resampleRolloff = 0.95
+)
+
+// Resampler converts interleaved samples to 16-bit PCM at another sample
+// rate and channel count as they stream through. Channels are mixed first:
+// down to mono by averaging, or up from mono by copying. Rates are converted
+// by windowed-sinc interpolation with a low-pass below the lower of the two
+// Nyquist frequencies.
+type Resampler struct {
+	inRate, outRate         int
+	inChannels, outChannels int
+
+	step   float64     // input samples per output sample
+	half   float64     // kernel half-width in input samples
+	kernel []float32   // kernel by distance, resampleTableSteps per sample
+	hist   [][]float32 // per output channel, input samples from base on
+	base   int64       // input index of hist[c][0]
+	next   int64       // index of the next output sample
+}
+
+// NewResampler returns a resampler from inRate with inChannels to outRate
+// with outChannels
+func NewResampler(inRate, inChannels, outRate, outChannels int) *Resampler {
+	r := &Resampler{
+		inRate:      inRate,
+		outRate:     outRate,
+		inChannels:  inChannels,
+		outChannels: outChannels,
+		step:        float64(inRate) / float64(outRate),
+		hist:        make([][]float32, outChannels),
+	}
+	if inRate == outRate {
+		return r
+	}
+
+	cutoff := 1.0
+	if outRate < inRate {
+		cutoff = float64(outRate) / float64(inRate)
+	}
+	cutoff *= resampleRolloff
+	r.half = resampleZeroCrossings / cutoff
+
+	size := int(math.Ceil(r.half*resampleTableSteps)) + 2
+	r.kernel = make([]float32, size)
+	for i := range r.kernel {
+		d := float64(i) / resampleTableSteps
+		if d > r.half {
+			break
+		}
+		x := math.Pi * cutoff * d
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(x) / x
+		}
+		// Blackman window over the kernel's width
+		w := 0.42 + 0.5*math.Cos(math.Pi*d/r.half) + 0.08*math.Cos(2*math.Pi*d/r.half)
+		r.kernel[i] = float32(cutoff * sinc * w)
+	}
+	return r
+}
+
+// Write takes whole frames of interleaved samples, scaled as 16-bit values,
+// and returns the 16-bit little-endian PCM that can be produced so far
+func (r *Resampler) Write(samples []float32) []byte {
+	frames := len(samples) / r.inChannels
+	for f := 0; f < frames; f++ {
+		frame := samples[f*r.inChannels : (f+1)*r.inChannels]
+		switch {
+		case r.outChannels == r.inChannels:
+			for c, v := range frame {
+				r.hist[c] = append(r.hist[c], v)
+			}
+		case r.outChannels == 1:
+			var sum float32
+			for _, v := range frame {
+				sum += v
+			}
+			r.hist[0] = append(r.hist[0], sum/float32(len(frame)))
+		case r.inChannels == 1:
+			for c := range r.hist {
+				r.hist[c] = append(r.hist[c], frame[0])
+			}
+		default:
+			// Keep the leading channels, front left and right
+			for c := range r.hist {
+				r.hist[c] = append(r.hist[c], frame[c])
+			}
+		}
+	}
+	return r.drain(false)
+}
+
+// Flush returns the PCM still held back for the kernel's look-ahead, at the
+// end of the input
+func (r *Resampler) Flush() []byte {
+	return r.drain(true)
+}
+
+func (r *Resampler) drain(final bool) []byte {
+	end := r.base + int64(len(r.hist[0]))
+	var out []byte
+	for {
+		t := float64(r.next) * r.step
+		if t >= float64(end) || (!final && t+r.half >= float64(end)) {
+			break
+		}
+		for c := range r.hist {
+			out = binary.LittleEndian.AppendUint16(out, uint16(clamp16(r.sample(c, t))))
+		}
+		r.next++
+	}
+
+	// Drop input the kernel will not reach again
+	if keep := int64(math.Floor(float64(r.next)*r.step-r.half)) - r.base; keep > 0 {
+		for c := range r.hist {
+			r.hist[c] = append(r.hist[c][:0], r.hist[c][keep:]...)
+		}
+		r.base += keep
+	}
+	return out
+}
+
+// sample interpolates channel c at input position t
+func (r *Resampler) sample(c int, t float64) float32 {
+	hist := r.hist[c]
+	if r.kernel == nil {
+		return hist[int64(t)-r.base]
+	}
+
+	lo := int64(math.Ceil(t - r.half))
+	hi := int64(math.Floor(t + r.half))
+	var sum float32
+	for k := lo; k <= hi; k++ {
+		i := k - r.base
+		if i < 0 || i >= int64(len(hist)) {
+			continue
+		}
+		pos := math.Abs(t-float64(k)) * resampleTableSteps
+		j := int(pos)
+		frac := float32(pos - float64(j))
+		sum += hist[i] * (r.kernel[j] + frac*(r.kernel[j+1]-r.kernel[j]))
+	}
+	return sum
+}
+
+func clamp16(v float32) int16 {
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(math.Round(float64(v)))
+}
+
+// PCMReader resamples raw 16-bit little-endian PCM to 16 kHz as it is read
+type PCMReader struct {
+	r         io.Reader
+	resampler *Resampler
+	frame     int
+	block     []byte
+	carry     int
+	samples   []float32
+	pending   []byte
+	done      bool
+}
+
+// NewPCMReader returns a reader of r, raw PCM at sampleRate with inChannels
+// interleaved, as 16 kHz PCM with outChannels
+func NewPCMReader(r io.Reader, sampleRate, inChannels, outChannels int) *PCMReader {
+	return &PCMReader{
+		r:         r,
+		resampler: NewResampler(sampleRate, inChannels, SampleRate, outChannels),
+		frame:     inChannels * BytesPerSample,
+		block:     make([]byte, wavBlockFrames*inChannels*BytesPerSample),
+	}
+}
+
+func (p *PCMReader) Read(b []byte) (int, error) {
+	for len(p.pending) == 0 {
+		if p.done {
+			return 0, io.EOF
+		}
+		if err := p.decodeBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+// decodeBlock converts whatever whole frames have arrived, keeping a frame
+// split across reads for the next block
+func (p *PCMReader) decodeBlock() error {
+	n, err := p.r.Read(p.block[p.carry:])
+	if err != nil && err != io.EOF {
+		return err
+	}
+	n += p.carry
+	whole := n - n%p.frame
+	p.samples = p.samples[:0]
+	for i := 0; i < whole; i += BytesPerSample {
+		p.samples = append(p.samples, float32(int16(binary.LittleEndian.Uint16(p.block[i:]))))
+	}
+	p.carry = copy(p.block, p.block[whole:n])
+
+	p.pending = p.resampler.Write(p.samples)
+	if err == io.EOF {
+		p.pending = append(p.pending, p.resampler.Flush()...)
+		p.done = true
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+// sine is a sine of the given frequency and peak at rate, as samples scaled
+// as 16-bit values
+func sine(freq, amplitude float64, rate int, seconds float64) []float32 {
+	samples := make([]float32, int(seconds*float64(rate)))
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// resample runs samples through a resampler in blocks of block samples
+func resample(r *Resampler, samples []float32, block int) []byte {
+	var pcm []byte
+	for len(samples) > 0 {
+		n := min(block, len(samples))
+		pcm = append(pcm, r.Write(samples[:n])...)
+		samples = samples[n:]
+	}
+	return append(pcm, r.Flush()...)
+}
+
+func TestResamplerRates(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  int
+		freq  float64
+		level float64 // dB relative to the input
+	}{
+		{"8 kHz up", 8000, 1000, 0},
+		{"44.1 kHz down", 44100, 1000, 0},
+		{"48 kHz down", 48000, 3000, 0},
+		{"above the output's Nyquist is filtered", 48000, 10000, -60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := resample(NewResampler(tt.rate, 1, SampleRate, 1), sine(tt.freq, 10000, tt.rate, 1), 1000)
+			if n := len(pcm) / BytesPerSample; n < SampleRate-1 || n > SampleRate+1 {
+				t.Fatalf("resampled to %d samples, want %d", n, SampleRate)
+			}
+			want := toDB(10000/math.Sqrt2/32768) + tt.level
+			got := LevelDB(pcm[Offset(0.1):Offset(0.9)])
+			if tt.level < 0 {
+				if got > want {
+					t.Errorf("level = %.1f dBFS, want below %.1f", got, want)
+				}
+			} else if math.Abs(got-want) > 0.2 {
+				t.Errorf("level = %.2f dBFS, want %.2f", got, want)
+			}
+		})
+	}
+}
+
+func TestResamplerBlockSizes(t *testing.T) {
+	samples := sine(440, 8000, 44100, 0.5)
+	whole := resample(NewResampler(44100, 1, SampleRate, 1), samples, len(samples))
+	for _, block := range []int{1, 7, 441, 4096} {
+		if got := resample(NewResampler(44100, 1, SampleRate, 1), samples, block); !bytes.Equal(got, whole) {
+			t.Errorf("writing in blocks of %d changed the output", block)
+		}
+	}
+}
+
+func TestResamplerChannels(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          []float32
+		inChannels  int
+		outChannels int
+		want        []int16
+	}{
+		{"mono", []float32{1, -2, 3.4}, 1, 1, []int16{1, -2, 3}},
+		{"mono to stereo", []float32{1, -2}, 1, 2, []int16{1, 1, -2, -2}},
+		{"stereo to mono", []float32{100, 200, -50, 50}, 2, 1, []int16{150, 0}},
+		{"surround to stereo", []float32{1, 2, 3, 4, 5, 6}, 6, 2, []int16{1, 2}},
+		{"partial frame dropped", []float32{1, 2, 3}, 2, 2, []int16{1, 2}},
+		{"clipped", []float32{40000, -40000}, 1, 1, []int16{math.MaxInt16, math.MinInt16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResampler(SampleRate, tt.inChannels, SampleRate, tt.outChannels)
+			got := Samples(append(r.Write(tt.in), r.Flush()...))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPCMReader(t *testing.T) {
+	var raw []byte
+	for _, v := range sine(440, 8000, 48000, 1) {
+		raw = binary.LittleEndian.AppendUint16(raw, uint16(int16(v)))
+	}
+
+	// Frames split across reads are carried over
+	pcm, err := io.ReadAll(NewPCMReader(iotest.OneByteReader(bytes.NewReader(raw)), 48000, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(pcm) / BytesPerSample; n < SampleRate-1 || n > SampleRate+1 {
+		t.Fatalf("read %d samples, want %d", n, SampleRate)
+	}
+	want := toDB(8000 / math.Sqrt2 / 32768)
+	if got := LevelDB(pcm[Offset(0.1):Offset(0.9)]); math.Abs(got-want) > 0.2 {
+		t.Errorf("level = %.2f dBFS, want %.2f", got, want)
+	}
+
+	passthrough, err := io.ReadAll(NewPCMReader(bytes.NewReader(raw[:3200]), SampleRate, 1, 1))
+	if err != nil || !bytes.Equal(passthrough, raw[:3200]) {
+		t.Fatalf("16 kHz mono was not passed through unchanged: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package audio
+
+// Chunk is a piece of a recording and where it starts, in seconds
+type Chunk struct {
+	PCM   []byte
+	Start float64
+}
+
+// Trim cuts leading and trailing silence, returning the speech span and its
+// start. It returns nil when the segments hold no speech.
+func Trim(pcm []byte, segments []Segment) *Chunk {
+	first, last := -1, -1
+	for i, s := range segments {
+		if s.Speech {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+
+	start := segments[first].Start
+	return &Chunk{PCM: slice(pcm, start, segments[last].End), Start: start}
+}
+
+// Split cuts a recording into speech chunks of at most maxSeconds, cutting
+// at pauses so words are not broken. Pauses within a chunk are kept and
+// pauses between chunks are dropped. Speech that runs longer than
+// maxSeconds without a pause is cut hard.
+func Split(pcm []byte, segments []Segment, maxSeconds float64) []Chunk {
+	chunks := []Chunk{}
+	var cur *Segment // span of the chunk being built
+
+	flush := func() {
+		if cur != nil {
+			chunks = append(chunks, Chunk{PCM: slice(pcm, cur.Start, cur.End), Start: cur.Start})
+			cur = nil
+		}
+	}
+
+	for _, s := range segments {
+		if !s.Speech {
+			continue
+		}
+		if cur != nil && s.End-cur.Start > maxSeconds {
+			flush()
+		}
+		if cur == nil {
+			seg := s
+			cur = &seg
+		} else {
+			// Keep the pause inside the chunk; it is short enough not to
+			// have been split on
+			cur.End = s.End
+		}
+
+		for maxSeconds > 0 && cur.End-cur.Start > maxSeconds {
+			end := cur.Start + maxSeconds
+			chunks = append(chunks, Chunk{PCM: slice(pcm, cur.Start, end), Start: cur.Start})
+			cur.Start = end
+		}
+	}
+	flush()
+	return chunks
+}
+
+// LastPause returns the middle of the last silence segment, if that is at
+// least minSeconds in, or 0. Streaming callers cut there so the
+// audio after the pause waits for the rest of the sentence.
+func LastPause(segments []Segment, minSeconds float64) float64 {
+	for i := len(segments) - 1; i >= 0; i-- {
+		s := segments[i]
+		if s.Speech {
+			continue
+		}
+		mid := (s.Start + s.End) / 2
+		if mid >= minSeconds {
+			return mid
+		}
+		break
+	}
+	return 0
+}
+
+func slice(pcm []byte, start, end float64) []byte {
+	from, to := Offset(start), Offset(end)
+	if to > len(pcm) {
+		to = len(pcm)
+	}
+	if from > to {
+		from = to
+	}
+	return pcm[from:to]
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// A two-track recording has one speaker per channel. Each channel then
+// carries its own speaker loudly and the other, if at all, only as bleed, so
+// while anyone is talking one channel is well above the other.
+const (
+	// separationDB is how much louder one channel must be for a frame to
+	// count as belonging to it
+	separationDB = 12
+	// activeDB is the energy, in dBFS, above which a frame is talk rather
+	// than room noise
+	activeDB = -45
+)
+
+// Deinterleave splits 16-bit stereo PCM into its left and right channels
+func Deinterleave(pcm []byte) (left, right []byte) {
+	frames := len(pcm) / (2 * BytesPerSample)
+	left = make([]byte, frames*BytesPerSample)
+	right = make([]byte, frames*BytesPerSample)
+	for i := 0; i < frames; i++ {
+		copy(left[i*2:], pcm[i*4:i*4+2])
+		copy(right[i*2:], pcm[i*4+2:i*4+4])
+	}
+	return left, right
+}
+
+// Mix averages two channels into one
+func Mix(left, right []byte) []byte {
+	l, r := Samples(left), Samples(right)
+	n := len(l)
+	if len(r) < n {
+		n = len(r)
+	}
+	mixed := make([]byte, n*BytesPerSample)
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint16(mixed[i*2:], uint16((int32(l[i])+int32(r[i]))/2))
+	}
+	return mixed
+}
+
+// Separated reports whether two channels hold different speakers: in at
+// least half of the frames where either channel is active, one of them
+// dominates. A mono recording played on both channels never is.
+func (v *VAD) Separated(left, right []byte) bool {
+	lf, rf := v.Frames(left), v.Frames(right)
+	n := len(lf)
+	if len(rf) < n {
+		n = len(rf)
+	}
+
+	active, dominated := 0, 0
+	for i := 0; i < n; i++ {
+		l, r := lf[i].EnergyDB, rf[i].EnergyDB
+		if math.Max(l, r) < activeDB {
+			continue
+		}
+		active++
+		if math.Abs(l-r) >= separationDB {
+			dominated++
+		}
+	}
+	return active > 0 && dominated*2 >= active
+}
@@ -0,0 +1,285 @@
+// Package audio analyses 16 kHz mono 16-bit little-endian PCM, the format
+// every transcription provider receives.
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	SampleRate     = 16000
+	BytesPerSample = 2
+)
+
+// Segment is a span of audio classified as speech or silence. Start and End
+// are seconds from the start of the audio.
+type Segment struct {
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Speech bool    `json:"speech"`
+}
+
+// Duration returns the length of the segment
+func (s Segment) Duration() float64 {
+	return s.End - s.Start
+}
+
+// VADConfig tunes voice activity detection
+type VADConfig struct {
+	// Frame is the analysis window
+	Frame time.Duration
+	// ThresholdDB is how far above the estimated noise floor a frame's
+	// energy must be to count as speech
+	ThresholdDB float64
+	// FloorDB is the lowest energy, in dBFS, ever counted as speech, so
+	// near-digital silence with a very low noise floor is not mistaken for
+	// speech
+	FloorDB float64
+	// MinSpeech drops speech bursts shorter than this, such as clicks
+	MinSpeech time.Duration
+	// MinSilence bridges pauses shorter than this, so words within a
+	// sentence stay in one segment
+	MinSilence time.Duration
+	// Padding extends each speech segment on both sides so word onsets and
+	// tails are not clipped
+	Padding time.Duration
+}
+
+// DefaultVADConfig suits conversational speech from a laptop microphone
+func DefaultVADConfig() VADConfig {
+	return VADConfig{
+		Frame:       20 * time.Millisecond,
+		ThresholdDB: 10,
+		FloorDB:     -50,
+		MinSpeech:   80 * time.Millisecond,
+		MinSilence:  300 * time.Millisecond,
+		Padding:     100 * time.Millisecond,
+	}
+}
+
+// VAD detects speech from frame energy and zero-crossing rate. Voiced
+// speech is loud relative to the noise floor; unvoiced consonants such as
+// "s" and "f" are quieter but cross zero often, so frames just under the
+// energy threshold still count when their zero-crossing rate is in the
+// fricative range.
+type VAD struct {
+	cfg VADConfig
+}
+
+func NewVAD(cfg VADConfig) *VAD {
+	return &VAD{cfg: cfg}
+}
+
+// zero-crossing rates, as crossings per sample, typical of fricatives;
+// higher rates are mostly hiss
+const (
+	fricativeZCRMin = 0.25
+	fricativeZCRMax = 0.6
+	// fricativeMarginDB is how far below the threshold a fricative frame
+	// may fall
+	fricativeMarginDB = 6
+)
+
+// Frame is the measurements of one analysis window
+type Frame struct {
+	EnergyDB float64
+	ZCR      float64
+}
+
+// Samples decodes PCM bytes into samples
+func Samples(pcm []byte) []int16 {
+	samples := make([]int16, len(pcm)/BytesPerSample)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(pcm[i*2:]))
+	}
+	return samples
+}
+
+// Seconds returns the duration of PCM in seconds
+func Seconds(pcm []byte) float64 {
+	return float64(len(pcm)/BytesPerSample) / SampleRate
+}
+
+// Offset returns the byte offset of a time in PCM, aligned to a sample
+func Offset(seconds float64) int {
+	if seconds <= 0 {
+		return 0
+	}
+	return int(seconds*SampleRate) * BytesPerSample
+}
+
+// Frames measures energy and zero-crossing rate for each full frame
+func (v *VAD) Frames(pcm []byte) []Frame {
+	samples := Samples(pcm)
+	size := v.frameSamples()
+	frames := make([]Frame, 0, len(samples)/size)
+	for start := 0; start+size <= len(samples); start += size {
+		frames = append(frames, measure(samples[start:start+size]))
+	}
+	return frames
+}
+
+func measure(samples []int16) Frame {
+	var sum float64
+	crossings := 0
+	for i, s := range samples {
+		f := float64(s) / 32768
+		sum += f * f
+		if i > 0 && (s >= 0) != (samples[i-1] >= 0) {
+			crossings++
+		}
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	return Frame{
+		EnergyDB: toDB(rms),
+		ZCR:      float64(crossings) / float64(len(samples)),
+	}
+}
+
+// toDB converts a linear amplitude to dBFS, clamped at -100
+func toDB(amplitude float64) float64 {
+	if amplitude < 1e-5 {
+		return -100
+	}
+	return 20 * math.Log10(amplitude)
+}
+
+// Segments classifies the audio into alternating speech and silence
+// segments covering all of it
+func (v *VAD) Segments(pcm []byte) []Segment {
+	frames := v.Frames(pcm)
+	total := Seconds(pcm)
+	if len(frames) == 0 {
+		if total == 0 {
+			return []Segment{}
+		}
+		return []Segment{{Start: 0, End: total}}
+	}
+
+	speech := v.classify(frames)
+	v.smooth(speech)
+
+	frameSec := v.cfg.Frame.Seconds()
+	pad := v.cfg.Padding.Seconds()
+
+	// Collect speech spans, padded and merged where padding overlaps
+	var spans []Segment
+	forEachRun(speech, true, func(i, j int) {
+		start := math.Max(0, float64(i)*frameSec-pad)
+		end := math.Min(total, float64(j)*frameSec+pad)
+		if n := len(spans); n > 0 && start <= spans[n-1].End {
+			spans[n-1].End = end
+		} else {
+			spans = append(spans, Segment{Start: start, End: end, Speech: true})
+		}
+	})
+
+	// Fill the gaps with silence
+	segments := []Segment{}
+	at := 0.0
+	for _, s := range spans {
+		if s.Start > at {
+			segments = append(segments, Segment{Start: at, End: s.Start})
+		}
+		segments = append(segments, s)
+		at = s.End
+	}
+	if at < total {
+		segments = append(segments, Segment{Start: at, End: total})
+	}
+	return segments
+}
+
+// HasSpeech reports whether any speech was detected
+func (v *VAD) HasSpeech(pcm []byte) bool {
+	for _, s := range v.Segments(pcm) {
+		if s.Speech {
+			return true
+		}
+	}
+	return false
+}
+
+// maxThresholdDB caps the adaptive threshold, so a clip that is speech from
+// end to end, and so has no quiet frames to estimate noise from, still
+// counts as speech
+const maxThresholdDB = -30
+
+// classify marks frames as speech against a threshold relative to the
+// clip's noise floor, taken as its 10th percentile frame energy
+func (v *VAD) classify(frames []Frame) []bool {
+	energies := make([]float64, len(frames))
+	for i, f := range frames {
+		energies[i] = f.EnergyDB
+	}
+	sort.Float64s(energies)
+	noise := energies[len(energies)/10]
+
+	threshold := math.Max(math.Min(noise+v.cfg.ThresholdDB, maxThresholdDB), v.cfg.FloorDB)
+	speech := make([]bool, len(frames))
+	for i, f := range frames {
+		switch {
+		case f.EnergyDB >= threshold:
+			speech[i] = true
+		case f.EnergyDB >= threshold-fricativeMarginDB && f.EnergyDB >= v.cfg.FloorDB &&
+			f.ZCR >= fricativeZCRMin && f.ZCR <= fricativeZCRMax:
+			speech[i] = true
+		}
+	}
+	return speech
+}
+
+// smooth bridges short pauses and then drops short bursts, in place
+func (v *VAD) smooth(speech []bool) {
+	minSilence := v.framesFor(v.cfg.MinSilence)
+	forEachRun(speech, false, func(i, j int) {
+		// Leading and trailing silence is real, not a pause
+		if i > 0 && j < len(speech) && j-i < minSilence {
+			setRun(speech, i, j, true)
+		}
+	})
+
+	minSpeech := v.framesFor(v.cfg.MinSpeech)
+	forEachRun(speech, true, func(i, j int) {
+		if j-i < minSpeech {
+			setRun(speech, i, j, false)
+		}
+	})
+}
+
+// forEachRun calls fn with the bounds [i, j) of each run of value
+func forEachRun(flags []bool, value bool, fn func(i, j int)) {
+	for i := 0; i < len(flags); {
+		if flags[i] != value {
+			i++
+			continue
+		}
+		j := i
+		for j < len(flags) && flags[j] == value {
+			j++
+		}
+		fn(i, j)
+		i = j
+	}
+}
+
+func setRun(flags []bool, i, j int, value bool) {
+	for k := i; k < j; k++ {
+		flags[k] = value
+	}
+}
+
+func (v *VAD) frameSamples() int {
+	n := int(v.cfg.Frame.Seconds() * SampleRate)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (v *VAD) framesFor(d time.Duration) int {
+	return int(d / v.cfg.Frame)
+}
@@ -0,0 +1,167 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// tone is a 220 Hz sine of the given peak amplitude as PCM
+func tone(seconds, amplitude float64) []byte {
+	n := int(seconds * SampleRate)
+	pcm := make([]byte, 0, n*BytesPerSample)
+	for i := 0; i < n; i++ {
+		v := amplitude * math.Sin(2*math.Pi*220*float64(i)/SampleRate)
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(v)))
+	}
+	return pcm
+}
+
+// silence is digital silence as PCM
+func silence(seconds float64) []byte {
+	return make([]byte, int(seconds*SampleRate)*BytesPerSample)
+}
+
+func concat(parts ...[]byte) []byte {
+	var pcm []byte
+	for _, p := range parts {
+		pcm = append(pcm, p...)
+	}
+	return pcm
+}
+
+func sameSegments(got, want []Segment) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Speech != want[i].Speech ||
+			math.Abs(got[i].Start-want[i].Start) > 1e-6 || math.Abs(got[i].End-want[i].End) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVADSegments(t *testing.T) {
+	vad := NewVAD(DefaultVADConfig())
+
+	tests := []struct {
+		name string
+		pcm  []byte
+		want []Segment
+	}{
+		{"empty", nil, []Segment{}},
+		{"silence", silence(2), []Segment{{0, 2, false}}},
+		{
+			"speech padded by 100ms",
+			concat(silence(1), tone(1, 3000), silence(1)),
+			[]Segment{{0, 0.9, false}, {0.9, 2.1, true}, {2.1, 3, false}},
+		},
+		{
+			"short pause bridged",
+			concat(silence(1), tone(0.5, 3000), silence(0.2), tone(0.5, 3000), silence(1)),
+			[]Segment{{0, 0.9, false}, {0.9, 2.3, true}, {2.3, 3.2, false}},
+		},
+		{
+			"long pause splits",
+			concat(silence(1), tone(0.5, 3000), silence(0.6), tone(0.5, 3000), silence(1)),
+			[]Segment{{0, 0.9, false}, {0.9, 1.6, true}, {1.6, 2.0, false}, {2.0, 2.7, true}, {2.7, 3.6, false}},
+		},
+		{"click dropped", concat(silence(1), tone(0.04, 20000), silence(1)), []Segment{{0, 2.04, false}}},
+		{"speech throughout", tone(2, 3000), []Segment{{0, 2, true}}},
+		{"quieter than the floor", concat(silence(1), tone(1, 50), silence(1)), []Segment{{0, 3, false}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := vad.Segments(tt.pcm)
+			if !sameSegments(got, tt.want) {
+				t.Fatalf("Segments = %v, want %v", got, tt.want)
+			}
+			speech := false
+			for _, s := range tt.want {
+				speech = speech || s.Speech
+			}
+			if vad.HasSpeech(tt.pcm) != speech {
+				t.Errorf("HasSpeech = %v, want %v", !speech, speech)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	pcm := silence(10)
+
+	tests := []struct {
+		name     string
+		segments []Segment
+		max      float64
+		want     []Segment // chunk spans
+	}{
+		{"no speech", []Segment{{0, 10, false}}, 30, nil},
+		{
+			"pause kept inside a chunk",
+			[]Segment{{0, 1, false}, {1, 2, true}, {2, 3, false}, {3, 4, true}, {4, 10, false}},
+			30,
+			[]Segment{{1, 4, true}},
+		},
+		{
+			"split at the pause",
+			[]Segment{{0, 1, false}, {1, 2, true}, {2, 3, false}, {3, 4, true}, {4, 10, false}},
+			2.5,
+			[]Segment{{1, 2, true}, {3, 4, true}},
+		},
+		{
+			"long speech cut hard",
+			[]Segment{{0, 5, true}, {5, 10, false}},
+			2,
+			[]Segment{{0, 2, true}, {2, 4, true}, {4, 5, true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Split(pcm, tt.segments, tt.max)
+			got := make([]Segment, len(chunks))
+			for i, c := range chunks {
+				got[i] = Segment{Start: c.Start, End: c.Start + Seconds(c.PCM), Speech: true}
+			}
+			if !sameSegments(got, tt.want) {
+				t.Fatalf("chunks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	pcm := silence(5)
+	chunk := Trim(pcm, []Segment{{0, 1, false}, {1, 2, true}, {2, 3, false}, {3, 4, true}, {4, 5, false}})
+	if chunk == nil || chunk.Start != 1 || math.Abs(Seconds(chunk.PCM)-3) > 1e-6 {
+		t.Fatalf("Trim = %+v", chunk)
+	}
+	if Trim(pcm, []Segment{{0, 5, false}}) != nil {
+		t.Fatal("Trim of silence returned a chunk")
+	}
+}
+
+func TestLastPause(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		min      float64
+		want     float64
+	}{
+		{"pause at the end", []Segment{{0, 3, true}, {3, 4, false}}, 1, 3.5},
+		{"pause before trailing speech", []Segment{{0, 2, true}, {2, 3, false}, {3, 4, true}}, 1, 2.5},
+		{"only the last pause counts", []Segment{{0, 1, false}, {1, 3, true}, {3, 3.4, false}, {3.4, 4, true}}, 1, 3.2},
+		{"pause too early", []Segment{{0, 0.4, false}, {0.4, 4, true}}, 1, 0},
+		{"no pause", []Segment{{0, 4, true}}, 1, 0},
+		{"no segments", nil, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LastPause(tt.segments, tt.min); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("LastPause = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
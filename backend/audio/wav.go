@@ -0,0 +1,207 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrUnsupported is returned by the native decoders for audio they cannot
+// read, such as compressed WAV or Ogg Vorbis, which need ffmpeg
+var ErrUnsupported = errors.New("unsupported audio encoding")
+
+// WAV sample formats, as in the fmt chunk
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavBlockFrames is how many frames are decoded at a time
+const wavBlockFrames = 4096
+
+// Limits on what a WAV header may declare, so a forged header cannot make
+// the decoder allocate huge buffers
+const (
+	wavMinRate     = 8000
+	wavMaxRate     = 384000
+	wavMaxChannels = 32
+	// wavMaxFmtSize is well above the 40 bytes of WAVE_FORMAT_EXTENSIBLE
+	wavMaxFmtSize = 64
+)
+
+// wavFormat is what a WAV fmt chunk says about its samples
+type wavFormat struct {
+	format     uint16
+	channels   int
+	sampleRate int
+	blockAlign int
+	bits       int
+}
+
+// WAVReader decodes a WAV stream to 16 kHz 16-bit PCM as it is read.
+// Integer PCM of 8 to 32 bits and 32- or 64-bit float are supported, at
+// 8 to 384 kHz with up to 32 channels.
+type WAVReader struct {
+	r         io.Reader
+	format    wavFormat
+	resampler *Resampler
+	pending   []byte
+	block     []byte
+	samples   []float32
+	done      bool
+}
+
+// NewWAVReader reads the header of a WAV stream and returns a reader of its
+// audio as 16 kHz PCM with the given number of interleaved channels
+func NewWAVReader(r io.Reader, channels int) (*WAVReader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("wav: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a RIFF WAVE file", ErrUnsupported)
+	}
+
+	var format *wavFormat
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("wav: no data chunk: %w", err)
+		}
+		id, size := string(header[0:4]), int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size > wavMaxFmtSize {
+				return nil, fmt.Errorf("wav: fmt chunk of %d bytes", size)
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+			f, err := parseWAVFormat(body[:size])
+			if err != nil {
+				return nil, err
+			}
+			format = f
+		case "data":
+			if format == nil {
+				return nil, fmt.Errorf("wav: data before fmt chunk")
+			}
+			// Writers that stream often leave the size at 0 or the
+			// maximum; read those to the end of the input instead
+			if size > 0 && size < math.MaxUint32 {
+				r = io.LimitReader(r, size)
+			}
+			return &WAVReader{
+				r:         r,
+				format:    *format,
+				resampler: NewResampler(format.sampleRate, format.channels, SampleRate, channels),
+				block:     make([]byte, wavBlockFrames*format.blockAlign),
+			}, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+		}
+	}
+}
+
+func parseWAVFormat(body []byte) (*wavFormat, error) {
+	if len(body) < 16 {
+		return nil, fmt.Errorf("wav: fmt chunk too short")
+	}
+	f := &wavFormat{
+		format:     binary.LittleEndian.Uint16(body[0:2]),
+		channels:   int(binary.LittleEndian.Uint16(body[2:4])),
+		sampleRate: int(binary.LittleEndian.Uint32(body[4:8])),
+		blockAlign: int(binary.LittleEndian.Uint16(body[12:14])),
+		bits:       int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	if f.format == wavFormatExtensible && len(body) >= 26 {
+		// The real format leads the sub-format GUID
+		f.format = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch {
+	case f.format == wavFormatPCM && (f.bits == 8 || f.bits == 16 || f.bits == 24 || f.bits == 32):
+	case f.format == wavFormatFloat && (f.bits == 32 || f.bits == 64):
+	default:
+		return nil, fmt.Errorf("%w: WAV format %d with %d-bit samples", ErrUnsupported, f.format, f.bits)
+	}
+	if f.channels < 1 || f.channels > wavMaxChannels || f.sampleRate < wavMinRate || f.sampleRate > wavMaxRate ||
+		f.blockAlign != f.channels*f.bits/8 {
+		return nil, fmt.Errorf("wav: invalid format (%d channels, %d Hz, block %d)", f.channels, f.sampleRate, f.blockAlign)
+	}
+	return f, nil
+}
+
+// SampleRate returns the rate the audio was recorded at
+func (w *WAVReader) SampleRate() int {
+	return w.format.sampleRate
+}
+
+// Channels returns the channel count the audio was recorded with
+func (w *WAVReader) Channels() int {
+	return w.format.channels
+}
+
+func (w *WAVReader) Read(p []byte) (int, error) {
+	for len(w.pending) == 0 {
+		if w.done {
+			return 0, io.EOF
+		}
+		if err := w.decodeBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, w.pending)
+	w.pending = w.pending[n:]
+	return n, nil
+}
+
+// decodeBlock converts the next block of frames, dropping a frame cut short
+// at the end of the input
+func (w *WAVReader) decodeBlock() error {
+	n, err := io.ReadFull(w.r, w.block)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	frames := n / w.format.blockAlign
+	w.samples = w.samples[:0]
+	size := w.format.bits / 8
+	for i := 0; i < frames*w.format.channels; i++ {
+		w.samples = append(w.samples, w.format.sample(w.block[i*size:(i+1)*size]))
+	}
+
+	w.pending = w.resampler.Write(w.samples)
+	if err != nil {
+		w.pending = append(w.pending, w.resampler.Flush()...)
+		w.done = true
+	}
+	return nil
+}
+
+// sample converts one sample to the scale of a 16-bit value
+func (f *wavFormat) sample(b []byte) float32 {
+	if f.format == wavFormatFloat {
+		if f.bits == 64 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)) * 32768)
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)) * 32768
+	}
+	switch f.bits {
+	case 8:
+		return float32(int(b[0])-128) * 256
+	case 16:
+		return float32(int16(binary.LittleEndian.Uint16(b)))
+	case 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 256
+	default:
+		return float32(int32(binary.LittleEndian.Uint32(b))) / 65536
+	}
+}
@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// wavHeader is a RIFF header and fmt chunk, with dataSize as the data chunk's
+// declared size
+func wavHeader(format, channels, rate, bits int, dataSize uint32) []byte {
+	var b []byte
+	b = append(b, "RIFF"...)
+	b = binary.LittleEndian.AppendUint32(b, 36+dataSize)
+	b = append(b, "WAVE"...)
+	b = append(b, "fmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, uint16(format))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate*channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(bits))
+	b = append(b, "data"...)
+	return binary.LittleEndian.AppendUint32(b, dataSize)
+}
+
+// wavTone is a WAV file of a 220 Hz sine at half of full scale on every
+// channel
+func wavTone(format, channels, rate, bits int, seconds float64) []byte {
+	var data []byte
+	for i := 0; i < int(seconds*float64(rate)); i++ {
+		v := 0.5 * math.Sin(2*math.Pi*220*float64(i)/float64(rate))
+		for c := 0; c < channels; c++ {
+			switch {
+			case format == wavFormatFloat && bits == 64:
+				data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+			case format == wavFormatFloat:
+				data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(v)))
+			case bits == 8:
+				data = append(data, byte(math.Round(v*127)+128))
+			case bits == 16:
+				data = binary.LittleEndian.AppendUint16(data, uint16(int16(math.Round(v*32767))))
+			case bits == 24:
+				s := uint32(int32(math.Round(v * 8388607)))
+				data = append(data, byte(s), byte(s>>8), byte(s>>16))
+			default:
+				data = binary.LittleEndian.AppendUint32(data, uint32(int32(math.Round(v*2147483647))))
+			}
+		}
+	}
+	return append(wavHeader(format, channels, rate, bits, uint32(len(data))), data...)
+}
+
+func decodeWAV(t *testing.T, wav []byte, channels int) []byte {
+	t.Helper()
+	r, err := NewWAVReader(bytes.NewReader(wav), channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pcm
+}
+
+func TestWAVReaderFormats(t *testing.T) {
+	want := LevelDB(tone(1, 0.5*32767))
+
+	tests := []struct {
+		name     string
+		format   int
+		channels int
+		rate     int
+		bits     int
+	}{
+		{"16-bit 16 kHz", wavFormatPCM, 1, 16000, 16},
+		{"8-bit 8 kHz", wavFormatPCM, 1, 8000, 8},
+		{"24-bit 48 kHz", wavFormatPCM, 1, 48000, 24},
+		{"32-bit 96 kHz", wavFormatPCM, 1, 96000, 32},
+		{"16-bit 44.1 kHz stereo", wavFormatPCM, 2, 44100, 16},
+		{"float 22.05 kHz", wavFormatFloat, 1, 22050, 32},
+		{"double 48 kHz 6 channels", wavFormatFloat, 6, 48000, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wav := wavTone(tt.format, tt.channels, tt.rate, tt.bits, 1)
+			r, err := NewWAVReader(bytes.NewReader(wav), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.SampleRate() != tt.rate || r.Channels() != tt.channels {
+				t.Errorf("format = %d Hz %d channels, want %d Hz %d channels", r.SampleRate(), r.Channels(), tt.rate, tt.channels)
+			}
+			pcm, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(pcm) / BytesPerSample; n < SampleRate-1 || n > SampleRate+1 {
+				t.Fatalf("decoded %d samples, want %d", n, SampleRate)
+			}
+			// Leave out the edges, where the resampler's kernel runs off the input
+			middle := pcm[Offset(0.1):Offset(0.9)]
+			if got := LevelDB(middle); math.Abs(got-want) > 0.3 {
+				t.Errorf("level = %.2f dBFS, want %.2f", got, want)
+			}
+		})
+	}
+}
+
+func TestWAVReaderPassthrough(t *testing.T) {
+	pcm := tone(0.5, 3000)
+	wav := append(wavHeader(wavFormatPCM, 1, SampleRate, 16, uint32(len(pcm))), pcm...)
+	if got := decodeWAV(t, wav, 1); !bytes.Equal(got, pcm) {
+		t.Fatal("16 kHz mono PCM was not passed through unchanged")
+	}
+
+	// Mono is copied to both channels
+	stereo := Samples(decodeWAV(t, wav, 2))
+	mono := Samples(pcm)
+	if len(stereo) != 2*len(mono) {
+		t.Fatalf("got %d stereo samples, want %d", len(stereo), 2*len(mono))
+	}
+	for i, v := range mono {
+		if stereo[2*i] != v || stereo[2*i+1] != v {
+			t.Fatalf("frame %d = %d/%d, want %d", i, stereo[2*i], stereo[2*i+1], v)
+		}
+	}
+}
+
+func TestWAVReaderChunks(t *testing.T) {
+	pcm := tone(0.25, 3000)
+	header := wavHeader(wavFormatPCM, 1, SampleRate, 16, uint32(len(pcm)))
+
+	// An odd-sized chunk before the data is skipped along with its padding
+	list := append([]byte("LIST"), 3, 0, 0, 0, 'a', 'b', 'c', 0)
+	withList := append(append(append([]byte{}, header[:36]...), list...), header[36:]...)
+	if got := decodeWAV(t, append(withList, pcm...), 1); !bytes.Equal(got, pcm) {
+		t.Error("audio after an odd-sized chunk decoded wrongly")
+	}
+
+	// Data past the declared size is ignored
+	if got := decodeWAV(t, append(append(header, pcm...), 1, 2, 3, 4), 1); !bytes.Equal(got, pcm) {
+		t.Error("trailing bytes after the data chunk were decoded")
+	}
+
+	// Streamed recordings leave the size unset; the data runs to the end
+	for _, size := range []uint32{0, 0xFFFFFFFF} {
+		wav := append(wavHeader(wavFormatPCM, 1, SampleRate, 16, size), pcm...)
+		if got := decodeWAV(t, wav, 1); !bytes.Equal(got, pcm) {
+			t.Errorf("data of size %#x decoded %d bytes, want %d", size, len(got), len(pcm))
+		}
+	}
+}
+
+func TestWAVReaderRejects(t *testing.T) {
+	valid := wavHeader(wavFormatPCM, 1, SampleRate, 16, 0)
+	hugeFmt := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(hugeFmt[16:20], 0xFFFFFFF0)
+	noFmt := append(append([]byte{}, valid[:12]...), valid[36:]...)
+
+	tests := []struct {
+		name        string
+		wav         []byte
+		unsupported bool
+	}{
+		{"empty", nil, false},
+		{"not RIFF", append([]byte("OggS"), valid[4:]...), true},
+		{"ADPCM", wavHeader(2, 1, SampleRate, 4, 0), true},
+		{"12-bit PCM", wavHeader(wavFormatPCM, 1, SampleRate, 12, 0), true},
+		{"16-bit float", wavHeader(wavFormatFloat, 1, SampleRate, 16, 0), true},
+		{"no channels", wavHeader(wavFormatPCM, 0, SampleRate, 16, 0), false},
+		{"too many channels", wavHeader(wavFormatPCM, 33, SampleRate, 16, 0), false},
+		{"rate too low", wavHeader(wavFormatPCM, 1, 4000, 16, 0), false},
+		{"rate too high", wavHeader(wavFormatPCM, 1, 400000, 16, 0), false},
+		{"rate overflows", wavHeader(wavFormatPCM, 1, math.MaxUint32, 16, 0), false},
+		{"huge fmt chunk", hugeFmt, false},
+		{"data before fmt", noFmt, false},
+		{"no data chunk", valid[:36], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWAVReader(bytes.NewReader(tt.wav), 1)
+			if err == nil {
+				t.Fatal("invalid WAV accepted")
+			}
+			if errors.Is(err, ErrUnsupported) != tt.unsupported {
+				t.Fatalf("error %q, want ErrUnsupported %v", err, tt.unsupported)
+			}
+		})
+	}
+}
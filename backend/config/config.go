@@ -1,20 +1,60 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	AppName          string
-	Debug            bool
-	AnthropicAPIKey  string
-	AWSAccessKeyID   string
-	AWSSecretKey     string
-	AWSRegion        string
-	Port             string
+	AppName            string
+	Debug              bool
+	AnthropicAPIKey    string
+	AWSAccessKeyID     string
+	AWSSecretKey       string
+	AWSRegion          string
+	Port               string
+	OCRCommand         string
+	OCRLanguage        string
+	PDFRasterizer      string
+	SkillsTaxonomyFile string
+	RedactPII          bool
+	RedactNames        bool
+	RedactEmployers    bool
+	StorageDriver      string
+	DataDir            string
+	SessionIdleTTL     time.Duration
+	MemoryIdleTTL      time.Duration
+	MaxLiveSessions    int
+	MaxSessionMemory   int
+	MaxArchived        int
+	JanitorInterval    time.Duration
+	EncryptionKeyFile  string
+	EncryptionPass     string
+	EncryptionOldPass  string
+	EncryptAtRest      bool
+	Transcribers       []string
+	TranscribeTimeout  time.Duration
+	DeepgramAPIKey     string
+	DeepgramModel      string
+	WhisperCommand     string
+	WhisperModel       string
+	WhisperServerURL   string
+	FFmpegCommand      string
+	FakeTranscript     string
+	AudioPreprocess    bool
+	VADEnabled         bool
+	TranscribeMaxChunk time.Duration
+	DetectLanguages    []string
+	CorrectTranscripts bool
+	CorrectionsFile    string
+	RecordingMaxBytes  int64
+	RecordingWorkers   int
 }
 
 var (
@@ -28,13 +68,49 @@ func GetConfig() *Config {
 		godotenv.Load()
 
 		instance = &Config{
-			AppName:          "NEXUS AI",
-			Debug:            os.Getenv("DEBUG") == "true",
-			AnthropicAPIKey:  os.Getenv("ANTHROPIC_API_KEY"),
-			AWSAccessKeyID:   os.Getenv("AWS_ACCESS_KEY_ID"),
-			AWSSecretKey:     os.Getenv("AWS_SECRET_ACCESS_KEY"),
-			AWSRegion:        getEnvOrDefault("AWS_REGION", "us-east-1"),
-			Port:             getEnvOrDefault("PORT", "8000"),
+			AppName:            "NEXUS AI",
+			Debug:              os.Getenv("DEBUG") == "true",
+			AnthropicAPIKey:    os.Getenv("ANTHROPIC_API_KEY"),
+			AWSAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			AWSSecretKey:       os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			AWSRegion:          getEnvOrDefault("AWS_REGION", "us-east-1"),
+			Port:               getEnvOrDefault("PORT", "8000"),
+			OCRCommand:         getEnvOrDefault("OCR_COMMAND", "tesseract"),
+			OCRLanguage:        getEnvOrDefault("OCR_LANGUAGE", "eng"),
+			PDFRasterizer:      getEnvOrDefault("PDF_RASTERIZER", "pdftoppm"),
+			SkillsTaxonomyFile: os.Getenv("SKILLS_TAXONOMY_FILE"),
+			RedactPII:          os.Getenv("PII_REDACTION") != "false",
+			RedactNames:        os.Getenv("REDACT_NAMES") == "true",
+			RedactEmployers:    os.Getenv("REDACT_EMPLOYERS") == "true",
+			StorageDriver:      getEnvOrDefault("STORAGE_DRIVER", "sqlite"),
+			DataDir:            getEnvOrDefault("DATA_DIR", "./data"),
+			SessionIdleTTL:     getDurationOrDefault("SESSION_IDLE_TTL", 2*time.Hour),
+			MemoryIdleTTL:      getDurationOrDefault("MEMORY_IDLE_TTL", 30*time.Minute),
+			MaxLiveSessions:    getIntOrDefault("MAX_LIVE_SESSIONS", 1000),
+			MaxSessionMemory:   getIntOrDefault("MAX_SESSION_MEMORY", 1000),
+			MaxArchived:        getIntOrDefault("MAX_ARCHIVED_SESSIONS", 5000),
+			JanitorInterval:    getDurationOrDefault("JANITOR_INTERVAL", time.Minute),
+			EncryptionKeyFile:  os.Getenv("ENCRYPTION_KEY_FILE"),
+			EncryptionPass:     os.Getenv("ENCRYPTION_PASSPHRASE"),
+			EncryptionOldPass:  os.Getenv("ENCRYPTION_OLD_PASSPHRASE"),
+			EncryptAtRest:      os.Getenv("ENCRYPT_AT_REST") != "false",
+			Transcribers:       getListOrDefault("TRANSCRIBE_PROVIDERS", []string{"aws", "deepgram", "whisper-server", "whisper-cli"}),
+			TranscribeTimeout:  getDurationOrDefault("TRANSCRIBE_TIMEOUT", 60*time.Second),
+			DeepgramAPIKey:     os.Getenv("DEEPGRAM_API_KEY"),
+			DeepgramModel:      getEnvOrDefault("DEEPGRAM_MODEL", "nova-2"),
+			WhisperCommand:     getEnvOrDefault("WHISPER_COMMAND", "whisper-cli"),
+			WhisperModel:       os.Getenv("WHISPER_MODEL"),
+			WhisperServerURL:   os.Getenv("WHISPER_SERVER_URL"),
+			FFmpegCommand:      getEnvOrDefault("FFMPEG_COMMAND", "ffmpeg"),
+			FakeTranscript:     getEnvOrDefault("FAKE_TRANSCRIPT", "This is a practice transcript."),
+			AudioPreprocess:    os.Getenv("AUDIO_PREPROCESS") != "false",
+			VADEnabled:         os.Getenv("VAD_ENABLED") != "false",
+			TranscribeMaxChunk: getDurationOrDefault("TRANSCRIBE_MAX_CHUNK", 30*time.Second),
+			DetectLanguages:    getListOrDefault("TRANSCRIBE_DETECT_LANGUAGES", nil),
+			CorrectTranscripts: os.Getenv("TRANSCRIPT_CORRECTION") != "false",
+			CorrectionsFile:    os.Getenv("TRANSCRIPT_CORRECTIONS_FILE"),
+			RecordingMaxBytes:  int64(getIntOrDefault("RECORDING_MAX_MB", 1024)) << 20,
+			RecordingWorkers:   getIntOrDefault("RECORDING_WORKERS", 2),
 		}
 	})
 	return instance
@@ -47,3 +123,46 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getDurationOrDefault reads a duration such as "90s" or "2h". Invalid values
+// fall back to the default with a warning.
+func getDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("[CONFIG] Invalid %s=%q, using %s\n", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getListOrDefault reads a comma-separated list, trimming and lowercasing
+// each entry
+func getListOrDefault(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getIntOrDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		fmt.Printf("[CONFIG] Invalid %s=%q, using %d\n", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
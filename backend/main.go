@@ -13,6 +13,8 @@ import (
 
 	"nexus-ai/config"
 	"nexus-ai/routes"
+	"nexus-ai/services"
+	"nexus-ai/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,39 @@ func main() {
 	// Load configuration
 	cfg := config.GetConfig()
 
+	// Open storage, encrypting records on disk unless turned off
+	opts := storage.Options{
+		SessionTTL:  cfg.SessionIdleTTL,
+		MemoryTTL:   cfg.MemoryIdleTTL,
+		MaxSessions: cfg.MaxLiveSessions,
+		MaxMemory:   cfg.MaxSessionMemory,
+		MaxArchived: cfg.MaxArchived,
+	}
+	if cfg.EncryptAtRest {
+		// The memory driver only spools recordings it removes on close, so a
+		// key that dies with the process is enough
+		var keys *storage.Keyring
+		var err error
+		if cfg.StorageDriver == storage.DriverMemory {
+			keys, err = storage.NewEphemeralKeyring()
+		} else {
+			keys, err = storage.LoadKeys(cfg.EncryptionKeyFile, cfg.EncryptionPass, cfg.EncryptionOldPass, cfg.DataDir)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load encryption keys: %v", err)
+		}
+		opts.Keys = keys
+	}
+	store, err := storage.Open(cfg.StorageDriver, cfg.DataDir, opts)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+	store.StartJanitor(cfg.JanitorInterval)
+	routes.SetStore(store)
+	routes.StartRecordingWorkers(cfg.RecordingWorkers)
+	services.DetectFFmpeg()
+
 	// Set Gin mode
 	if !cfg.Debug {
 		gin.SetMode(gin.ReleaseMode)
@@ -35,7 +70,7 @@ func main() {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag", "Location", "Upload-Offset", "Upload-Length", "X-Profile-Variant"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -75,27 +110,67 @@ func main() {
 			"version":     "2.0.0",
 			"endpoints": gin.H{
 				"profile": gin.H{
-					"POST /profile/upload-resume": "Upload and parse resume",
-					"POST /profile/manual":        "Create manual profile",
-					"GET /profile":                "List all profiles",
-					"GET /profile/:id":            "Get profile by ID",
-					"PUT /profile/:id":            "Update profile",
-					"DELETE /profile/:id":         "Delete profile",
+					"POST /profile/upload-resume":                  "Upload and parse resume (or LinkedIn export .zip)",
+					"POST /profile/manual":                         "Create manual profile",
+					"GET /profile":                                 "List all profiles",
+					"GET /profile/:id":                             "Get profile by ID",
+					"PUT /profile/:id":                             "Update profile",
+					"PATCH /profile/:id":                           "Partially update profile (merge-patch+json or json-patch+json, If-Match)",
+					"DELETE /profile/:id":                          "Delete profile",
+					"GET /profile/:id/export":                      "Export profile (format=json|markdown|text|pdf, optional job_description)",
+					"POST /profile/:id/export":                     "Export profile tailored to a job description",
+					"GET /profile/:id/variants":                    "List tailored profile variants",
+					"GET /profile/:id/timeline":                    "Career timeline: dated roles, years per skill, gaps and overlaps",
+					"GET /profile/:id/versions":                    "List profile versions",
+					"GET /profile/:id/versions/:version":           "Get one profile version",
+					"POST /profile/:id/versions/:version/rollback": "Restore a profile version (saved as a new version)",
+					"GET /profile/:id/diff":                        "Field-level diff between versions (from, to)",
+					"POST /profile/merge":                          "Merge several profiles into one (profile_ids, target_profile_id)",
+					"GET /profile/merges/:merge_id":                "Get a merge waiting on conflict resolution",
+					"POST /profile/merges/:merge_id/resolve":       "Resolve merge conflicts (resolutions, finalize)",
+				},
+				"jobs": gin.H{
+					"POST /jobs":        "Create job description from pasted text",
+					"POST /jobs/upload": "Upload job description (PDF, HTML, TXT)",
+					"GET /jobs":         "List job descriptions",
+					"GET /jobs/:id":     "Get parsed job description",
+					"DELETE /jobs/:id":  "Delete job description",
+				},
+				"analysis": gin.H{
+					"POST /analysis/fit": "Resume-to-job fit analysis and gap report",
 				},
 				"interview": gin.H{
-					"POST /interview/session/start":     "Start interview session",
-					"POST /interview/session/:id/end":   "End interview session",
-					"POST /interview/assist":            "Get interview assistance",
-					"POST /interview/coding-assist":     "Get coding assistance",
-					"POST /interview/feedback":          "Get response feedback",
-					"POST /interview/translate":         "Translate text",
+					"POST /interview/session/start":         "Start interview session (profile_id, jd_id, profile_mode=live|snapshot)",
+					"GET /interview/session/:id":            "Get session with its resolved profile and job description",
+					"POST /interview/session/:id/end":       "End interview session",
+					"GET /interview/session/:id/transcript": "Session transcript (offset, limit)",
+					"POST /interview/assist":                "Get interview assistance",
+					"POST /interview/coding-assist":         "Get coding assistance",
+					"POST /interview/feedback":              "Get response feedback",
+					"POST /interview/feedback/audio":        "Feedback on a recorded answer with delivery metrics (file, question, target_seconds)",
+					"POST /interview/translate":             "Translate text",
+					"POST /interview/diarize":               "Speaker-tagged transcript of a recorded interview (file, mode, interviewers, session_id)",
 				},
 				"live": gin.H{
 					"POST /live/stream-answer":    "Stream AI answer (SSE)",
 					"POST /live/transcribe-chunk": "Transcribe audio chunk",
+					"GET /live/ws":                "WebSocket audio stream with partial and final transcripts",
 					"GET /live/memory-status":     "Get memory status",
 					"POST /live/clear-memory":     "Clear session memory",
 					"GET /live/health":            "Health check",
+					"GET /live/metrics":           "Live state metrics (sessions, Q&A memory, expiries, evictions)",
+				},
+				"recordings": gin.H{
+					"POST /recordings":              "Upload a long recording (multipart file) or open a resumable upload (JSON size, filename)",
+					"HEAD /recordings/:id":          "Upload-Offset to resume an upload from",
+					"PATCH /recordings/:id":         "Append to a resumable upload (Upload-Offset header, raw body)",
+					"POST /recordings/:id/complete": "Queue an upload of unknown size, or retry a failed recording",
+					"GET /recordings/:id":           "Status, progress and timestamped transcript segments",
+					"GET /recordings/:id/export":    "Download the transcript (format=txt|srt|vtt)",
+					"DELETE /recordings/:id":        "Cancel and delete a recording with its audio",
+				},
+				"privacy": gin.H{
+					"GET /privacy/redactions": "Audit log of PII redacted before LLM calls",
 				},
 			},
 		})
@@ -104,8 +179,12 @@ func main() {
 	// Register route groups
 	api := r.Group("")
 	routes.RegisterProfileRoutes(api)
+	routes.RegisterJobDescriptionRoutes(api)
+	routes.RegisterAnalysisRoutes(api)
 	routes.RegisterInterviewRoutes(api)
 	routes.RegisterLiveInterviewRoutes(api)
+	routes.RegisterRecordingRoutes(api)
+	routes.RegisterPrivacyRoutes(api)
 
 	// Start server
 	port := cfg.Port
@@ -117,4 +196,3 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
-
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"nexus-ai/audio"
 )
 
 // InterviewType enum
@@ -41,6 +43,9 @@ type Experience struct {
 	Company      string   `json:"company,omitempty"`
 	Title        string   `json:"title,omitempty"`
 	Duration     string   `json:"duration,omitempty"`
+	StartDate    string   `json:"start_date,omitempty"` // YYYY-MM, parsed from Duration
+	EndDate      string   `json:"end_date,omitempty"`   // YYYY-MM, empty when current
+	IsCurrent    bool     `json:"is_current,omitempty"`
 	Description  string   `json:"description,omitempty"`
 	Achievements []string `json:"achievements,omitempty"`
 }
@@ -51,6 +56,8 @@ type Education struct {
 	Degree      string `json:"degree,omitempty"`
 	Field       string `json:"field,omitempty"`
 	Year        string `json:"year,omitempty"`
+	StartDate   string `json:"start_date,omitempty"` // YYYY-MM, parsed from Year
+	EndDate     string `json:"end_date,omitempty"`   // YYYY-MM
 }
 
 // Project represents a project
@@ -60,12 +67,22 @@ type Project struct {
 	Technologies []string `json:"technologies,omitempty"`
 }
 
+// Skill is a normalized skill with derived detail
+type Skill struct {
+	Name              string  `json:"name"`
+	Category          string  `json:"category"` // language, framework, cloud, database, tool, practice, other
+	Proficiency       string  `json:"proficiency,omitempty"`
+	ProficiencySource string  `json:"proficiency_source,omitempty"` // "user" or "derived"
+	Years             float64 `json:"years,omitempty"`
+}
+
 // UserProfile represents a user's profile
 type UserProfile struct {
 	Name          string       `json:"name"`
 	Email         string       `json:"email,omitempty"`
 	Phone         string       `json:"phone,omitempty"`
 	Skills        []string     `json:"skills"`
+	SkillDetails  []Skill      `json:"skill_details,omitempty"`
 	Experience    []Experience `json:"experience"`
 	Education     []Education  `json:"education"`
 	Projects      []Project    `json:"projects"`
@@ -74,25 +91,282 @@ type UserProfile struct {
 	RawResumeText string       `json:"raw_resume_text,omitempty"`
 }
 
+// TimelineEntry is one dated role or education entry
+type TimelineEntry struct {
+	Kind      string `json:"kind"` // "experience" or "education"
+	Index     int    `json:"index"`
+	Label     string `json:"label"`
+	Start     string `json:"start"`         // YYYY-MM
+	End       string `json:"end,omitempty"` // YYYY-MM, empty when current
+	IsCurrent bool   `json:"is_current,omitempty"`
+	Months    int    `json:"months"`
+}
+
+// TimelinePeriod is a span of months, used for employment gaps
+type TimelinePeriod struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Months int    `json:"months"`
+}
+
+// TimelineOverlap is a span where two roles ran at the same time
+type TimelineOverlap struct {
+	Start   string   `json:"start"`
+	End     string   `json:"end"`
+	Months  int      `json:"months"`
+	Entries []string `json:"entries"`
+}
+
+// ProfileTimeline is the computed career timeline of a profile
+type ProfileTimeline struct {
+	Entries    []TimelineEntry    `json:"entries"`
+	TotalYears float64            `json:"total_years"`
+	SkillYears map[string]float64 `json:"skill_years"`
+	Gaps       []TimelinePeriod   `json:"gaps"`
+	Overlaps   []TimelineOverlap  `json:"overlaps"`
+	Unparsed   []string           `json:"unparsed,omitempty"`
+}
+
+// ProfileVariant is a named, tailored copy of a profile
+type ProfileVariant struct {
+	Name           string      `json:"name"`
+	JobDescription string      `json:"job_description,omitempty"`
+	Profile        UserProfile `json:"profile"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Sources of a profile version
+const (
+	ProfileSourceUpload   = "upload"
+	ProfileSourceManual   = "manual"
+	ProfileSourceUpdate   = "update"
+	ProfileSourcePatch    = "patch"
+	ProfileSourceMerge    = "merge"
+	ProfileSourceRollback = "rollback"
+)
+
+// ProfileVersion is an immutable snapshot of a profile, recorded on every
+// change
+type ProfileVersion struct {
+	ProfileID string       `json:"profile_id"`
+	Version   int          `json:"version"`
+	Source    string       `json:"source"`
+	Note      string       `json:"note,omitempty"`
+	Profile   *UserProfile `json:"profile"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// ProfileFieldChange is one field-level difference between two profile
+// versions. Path uses JSON names, e.g. "experience[0].title" or "skills".
+type ProfileFieldChange struct {
+	Path string `json:"path"`
+	Op   string `json:"op"` // added, removed, changed
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// ProfileDiff lists the changes from one profile version to another
+type ProfileDiff struct {
+	ProfileID string               `json:"profile_id"`
+	From      int                  `json:"from"`
+	To        int                  `json:"to"`
+	Changes   []ProfileFieldChange `json:"changes"`
+}
+
+// ProfileMergeRequest combines several stored profiles into one. Sources are
+// listed in priority order: where they disagree the first one is the default.
+type ProfileMergeRequest struct {
+	ProfileIDs      []string `json:"profile_ids" binding:"required,min=2"`
+	TargetProfileID string   `json:"target_profile_id,omitempty"` // save into this profile instead of a new one
+}
+
+// MergeOption is one source's value for a conflicting field
+type MergeOption struct {
+	ProfileID string `json:"profile_id"`
+	Value     string `json:"value"`
+}
+
+// MergeConflict is a field on which merged sources disagree
+type MergeConflict struct {
+	ID       string        `json:"id"`
+	Path     string        `json:"path"` // e.g. "email" or "experience[1].title"
+	Options  []MergeOption `json:"options"`
+	Resolved bool          `json:"resolved"`
+	Value    string        `json:"value"` // current value in the draft
+}
+
+// PendingMerge is a merged draft waiting for its conflicts to be resolved
+type PendingMerge struct {
+	ID              string          `json:"merge_id"`
+	SourceIDs       []string        `json:"source_ids"`
+	TargetProfileID string          `json:"target_profile_id,omitempty"`
+	TargetVersion   int             `json:"target_version,omitempty"`
+	Profile         *UserProfile    `json:"profile"`
+	Conflicts       []MergeConflict `json:"conflicts"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// MergeResolution picks a value for one conflict: an option index, or a
+// custom value
+type MergeResolution struct {
+	ConflictID string  `json:"conflict_id" binding:"required"`
+	Option     *int    `json:"option,omitempty"`
+	Value      *string `json:"value,omitempty"`
+}
+
+// ResolveMergeRequest resolves conflicts of a pending merge. With Finalize,
+// unresolved conflicts keep their default and the profile is saved.
+type ResolveMergeRequest struct {
+	Resolutions []MergeResolution `json:"resolutions"`
+	Finalize    bool              `json:"finalize"`
+}
+
+// ExportRequest for rendering a profile
+type ExportRequest struct {
+	Format         string `json:"format" form:"format"`
+	JobDescription string `json:"job_description" form:"job_description"`
+	JobDescID      string `json:"jd_id" form:"jd_id"`
+	VariantName    string `json:"variant_name" form:"variant_name"`
+	Variant        string `json:"variant" form:"variant"`
+}
+
+// JobDescription is a parsed job posting
+type JobDescription struct {
+	ID               string    `json:"id"`
+	Role             string    `json:"role"`
+	Company          string    `json:"company,omitempty"`
+	Seniority        string    `json:"seniority,omitempty"`
+	RequiredSkills   []string  `json:"required_skills"`
+	NiceToHaveSkills []string  `json:"nice_to_have_skills"`
+	Responsibilities []string  `json:"responsibilities"`
+	Source           string    `json:"source"` // "text", "pdf" or "html"
+	SourceName       string    `json:"source_name,omitempty"`
+	RawText          string    `json:"raw_text,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// JobDescriptionRequest for creating a JD from pasted text
+type JobDescriptionRequest struct {
+	Text    string `json:"text" binding:"required"`
+	Role    string `json:"role,omitempty"`
+	Company string `json:"company,omitempty"`
+}
+
+// FitAnalysisRequest for resume-to-job fit analysis
+type FitAnalysisRequest struct {
+	ProfileID      string `json:"profile_id" binding:"required"`
+	JobDescID      string `json:"jd_id,omitempty"`
+	JobDescription string `json:"job_description,omitempty"`
+}
+
+// FitEvidence is a piece of the profile that supports a requirement
+type FitEvidence struct {
+	Kind         string   `json:"kind"` // "skill", "experience", "project" or "achievement"
+	Source       string   `json:"source,omitempty"`
+	Text         string   `json:"text"`
+	MatchedTerms []string `json:"matched_terms"`
+}
+
+// RequirementMatch is one row of the fit evidence matrix
+type RequirementMatch struct {
+	Requirement  string        `json:"requirement"`
+	Category     string        `json:"category"` // "required", "nice_to_have" or "responsibility"
+	Status       string        `json:"status"`   // "met", "partial" or "missing"
+	Coverage     float64       `json:"coverage"`
+	Evidence     []FitEvidence `json:"evidence"`
+	MissingTerms []string      `json:"missing_terms,omitempty"`
+}
+
+// FitGap is something to prepare for before the interview
+type FitGap struct {
+	Requirement string  `json:"requirement"`
+	Category    string  `json:"category"`
+	Coverage    float64 `json:"coverage"`
+	Suggestion  string  `json:"suggestion"`
+}
+
+// FitReport for resume-to-job fit analysis
+type FitReport struct {
+	ProfileID              string             `json:"profile_id"`
+	JobDescID              string             `json:"jd_id,omitempty"`
+	Role                   string             `json:"role,omitempty"`
+	Company                string             `json:"company,omitempty"`
+	OverallScore           float64            `json:"overall_score"`
+	RequiredCoverage       float64            `json:"required_coverage"`
+	NiceToHaveCoverage     float64            `json:"nice_to_have_coverage"`
+	ResponsibilityCoverage float64            `json:"responsibility_coverage"`
+	Requirements           []RequirementMatch `json:"requirements"`
+	MissingKeywords        []string           `json:"missing_keywords"`
+	Gaps                   []FitGap           `json:"gaps"`
+}
+
 // InterviewMessage represents a message in interview
 type InterviewMessage struct {
-	Role      string    `json:"role"` // "interviewer" or "candidate"
+	Seq       int       `json:"seq"`  // position in the transcript, from 1
+	Role      string    `json:"role"` // "interviewer", "candidate" or "assistant"
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp,omitempty"`
 	Language  string    `json:"language,omitempty"`
+	Source    string    `json:"source,omitempty"` // endpoint that recorded it
+	Model     string    `json:"model,omitempty"`
+	LatencyMs int64     `json:"latency_ms,omitempty"`
+}
+
+// Roles of an interview message. Assistant messages are the answers and
+// feedback NEXUS generated, as opposed to what the candidate actually said.
+const (
+	MessageRoleInterviewer = "interviewer"
+	MessageRoleCandidate   = "candidate"
+	MessageRoleAssistant   = "assistant"
+)
+
+// Transcript is one page of a session's messages
+type Transcript struct {
+	SessionID string             `json:"session_id"`
+	Total     int                `json:"total"`
+	Offset    int                `json:"offset"`
+	Limit     int                `json:"limit"`
+	Messages  []InterviewMessage `json:"messages"`
 }
 
 // InterviewSession represents an interview session
 type InterviewSession struct {
 	SessionID     string             `json:"session_id"`
+	ProfileID     string             `json:"profile_id,omitempty"`
+	ProfileMode   ProfileMode        `json:"profile_mode,omitempty"`
 	UserProfile   *UserProfile       `json:"user_profile,omitempty"`
 	InterviewType InterviewType      `json:"interview_type"`
 	Language      Language           `json:"language"`
+	JobDescID     string             `json:"jd_id,omitempty"`
 	Messages      []InterviewMessage `json:"messages"`
 	StartedAt     time.Time          `json:"started_at"`
+	LastActivity  time.Time          `json:"last_activity,omitempty"`
+	EndedAt       *time.Time         `json:"ended_at,omitempty"`
 	IsActive      bool               `json:"is_active"`
 }
 
+// ProfileMode controls how a session sees edits to its linked profile
+type ProfileMode string
+
+const (
+	// ProfileModeLive reads the stored profile on every request, so edits
+	// made mid-session take effect immediately
+	ProfileModeLive ProfileMode = "live"
+	// ProfileModeSnapshot freezes the profile as it was when the session
+	// started
+	ProfileModeSnapshot ProfileMode = "snapshot"
+)
+
+// StartSessionRequest for starting an interview session. Fields may be sent
+// as query parameters or as a JSON body.
+type StartSessionRequest struct {
+	ProfileID     string        `json:"profile_id" form:"profile_id"`
+	JobDescID     string        `json:"jd_id" form:"jd_id"`
+	InterviewType InterviewType `json:"interview_type" form:"interview_type"`
+	Language      Language      `json:"language" form:"language"`
+	ProfileMode   ProfileMode   `json:"profile_mode" form:"profile_mode"`
+}
+
 // AssistanceRequest for interview assistance
 type AssistanceRequest struct {
 	SessionID       string        `json:"session_id"`
@@ -126,13 +400,42 @@ type ToneAnalysis struct {
 	Professionalism int `json:"professionalism"`
 }
 
-// FeedbackResponse for response feedback
+// FeedbackResponse for response feedback. A spoken answer also gets its
+// transcript and delivery metrics.
 type FeedbackResponse struct {
-	OverallScore     float64      `json:"overall_score"`
-	ToneAnalysis     ToneAnalysis `json:"tone_analysis"`
-	Strengths        []string     `json:"strengths"`
-	Improvements     []string     `json:"improvements"`
-	DetailedFeedback string       `json:"detailed_feedback"`
+	OverallScore     float64          `json:"overall_score"`
+	ToneAnalysis     ToneAnalysis     `json:"tone_analysis"`
+	Strengths        []string         `json:"strengths"`
+	Improvements     []string         `json:"improvements"`
+	DetailedFeedback string           `json:"detailed_feedback"`
+	Transcript       string           `json:"transcript,omitempty"`
+	Delivery         *DeliveryMetrics `json:"delivery,omitempty"`
+	Warnings         []string         `json:"warnings,omitempty"`
+}
+
+// DeliveryMetrics measure how a spoken answer was delivered. They are
+// computed from the transcript and the audio, not judged by the LLM, so the
+// same recording always gets the same numbers. Times are in seconds.
+type DeliveryMetrics struct {
+	Duration          float64        `json:"duration"` // first word to last
+	SpeakingTime      float64        `json:"speaking_time"`
+	TargetDuration    float64        `json:"target_duration"`
+	DurationRatio     float64        `json:"duration_ratio"`   // duration / target
+	DurationVerdict   string         `json:"duration_verdict"` // short, on_target or long
+	WordCount         int            `json:"word_count"`
+	WordsPerMinute    float64        `json:"words_per_minute"`
+	PaceVerdict       string         `json:"pace_verdict"` // slow, good or fast
+	FillerCount       int            `json:"filler_count"`
+	FillersPerMinute  float64        `json:"fillers_per_minute"`
+	Fillers           map[string]int `json:"fillers"`
+	PauseCount        int            `json:"pause_count"`
+	LongPauseCount    int            `json:"long_pause_count"`
+	AveragePause      float64        `json:"average_pause"`
+	LongestPause      float64        `json:"longest_pause"`
+	AverageLevelDB    float64        `json:"average_level_db"`
+	LevelVariationDB  float64        `json:"level_variation_db"`
+	VolumeConsistency int            `json:"volume_consistency"` // 0-100
+	Observations      []string       `json:"observations"`
 }
 
 // CodingAssistanceRequest for coding help
@@ -159,6 +462,7 @@ type InterviewContext struct {
 	Role           string `json:"role,omitempty"`
 	Company        string `json:"company,omitempty"`
 	JobDescription string `json:"job_description,omitempty"`
+	JobDescID      string `json:"jd_id,omitempty"`
 	Model          string `json:"model,omitempty"`
 }
 
@@ -183,8 +487,121 @@ type QAPair struct {
 
 // TranscribeResponse for audio transcription
 type TranscribeResponse struct {
-	Success bool   `json:"success"`
-	Text    string `json:"text"`
+	Success  bool            `json:"success"`
+	Text     string          `json:"text"`
+	Provider string          `json:"provider,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Segments []audio.Segment `json:"segments,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"` // audio quality, such as "clipping detected"
+	// Language is the language transcribed in, as requested or taken from
+	// the session; DetectedLanguage is set when it was identified instead
+	Language         Language `json:"language,omitempty"`
+	DetectedLanguage Language `json:"detected_language,omitempty"`
+}
+
+// SpeakerSegment is a stretch of a recording spoken by one speaker. Speaker
+// is the provider's label, such as spk_0, or channel_0 for the left track
+// of a two-track recording. Start and End are seconds from the start of the
+// recording.
+type SpeakerSegment struct {
+	Speaker string  `json:"speaker"`
+	Role    string  `json:"role,omitempty"`
+	Text    string  `json:"text"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// DiarizeResponse is a speaker-tagged transcript of a recorded interview.
+// Method is speaker_labels when the provider told the speakers apart, or
+// channels when each speaker had their own track.
+type DiarizeResponse struct {
+	Success      bool             `json:"success"`
+	Provider     string           `json:"provider,omitempty"`
+	Method       string           `json:"method,omitempty"`
+	Duration     float64          `json:"duration"`
+	Interviewers []string         `json:"interviewers"`
+	Segments     []SpeakerSegment `json:"segments"`
+	Recorded     int              `json:"recorded,omitempty"` // messages added to the session transcript
+	Warnings     []string         `json:"warnings,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// Recording statuses. An upload stays uploading until all of it has been
+// received, then waits queued for a worker, which transcribes it.
+const (
+	RecordingUploading  = "uploading"
+	RecordingQueued     = "queued"
+	RecordingProcessing = "processing"
+	RecordingDone       = "done"
+	RecordingFailed     = "failed"
+)
+
+// RecordingRequest starts a resumable upload of a long recording. Size is
+// the recording's length in bytes; when given, the upload completes by
+// itself once that much has arrived.
+type RecordingRequest struct {
+	Filename   string `json:"filename"`
+	Size       int64  `json:"size"`
+	SessionID  string `json:"session_id"`
+	Language   string `json:"language"`
+	Vocabulary string `json:"vocabulary"`
+}
+
+// TranscriptSegment is a timed stretch of transcript. Start and End are
+// seconds from the start of the recording.
+type TranscriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Recording is a long recording uploaded for transcription in the
+// background. Size is the upload's declared length in bytes, if known, and
+// Received how much has arrived. Progress is the share of the audio
+// transcribed so far, from 0 to 1.
+type Recording struct {
+	ID               string              `json:"recording_id"`
+	Status           string              `json:"status"`
+	Filename         string              `json:"filename,omitempty"`
+	Size             int64               `json:"size,omitempty"`
+	Received         int64               `json:"received"`
+	SessionID        string              `json:"session_id,omitempty"`
+	Language         Language            `json:"language,omitempty"`
+	Vocabulary       []string            `json:"vocabulary,omitempty"`
+	Progress         float64             `json:"progress"`
+	Duration         float64             `json:"duration"` // seconds transcribed so far
+	Provider         string              `json:"provider,omitempty"`
+	DetectedLanguage Language            `json:"detected_language,omitempty"`
+	Text             string              `json:"text"`
+	Segments         []TranscriptSegment `json:"segments"`
+	Warnings         []string            `json:"warnings,omitempty"`
+	Error            string              `json:"error,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// Live transcript event types sent over /live/ws
+const (
+	TranscriptEventReady   = "ready"
+	TranscriptEventPartial = "partial"
+	TranscriptEventFinal   = "final"
+	TranscriptEventError   = "error"
+	TranscriptEventWarning = "warning"
+	TranscriptEventDone    = "done"
+)
+
+// TranscriptEvent is one message pushed to a live audio WebSocket client.
+// Partial text may still change; final text for the same span will not.
+// Start and End are seconds from the start of the stream.
+type TranscriptEvent struct {
+	Type     string   `json:"type"`
+	Text     string   `json:"text,omitempty"`
+	Start    float64  `json:"start,omitempty"`
+	End      float64  `json:"end,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+	Mode     string   `json:"mode,omitempty"`
+	Language Language `json:"language,omitempty"`
+	Message  string   `json:"message,omitempty"`
 }
 
 // TranslateRequest for translation
@@ -193,3 +610,18 @@ type TranslateRequest struct {
 	TargetLanguage string `json:"target_language" binding:"required"`
 }
 
+// RedactionEntry is one value removed before an LLM call. The value itself
+// is never stored, only a short fingerprint of it.
+type RedactionEntry struct {
+	Placeholder string `json:"placeholder"`
+	Kind        string `json:"kind"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// RedactionAudit records what was redacted from one outbound LLM call
+type RedactionAudit struct {
+	Time    time.Time        `json:"time"`
+	Purpose string           `json:"purpose"`
+	Counts  map[string]int   `json:"counts"`
+	Entries []RedactionEntry `json:"entries"`
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAnalysisRoutes registers all analysis routes
+func RegisterAnalysisRoutes(r *gin.RouterGroup) {
+	analysis := r.Group("/analysis")
+	{
+		analysis.POST("/fit", analyzeFit)
+	}
+}
+
+// analyzeFit compares a stored profile against a job description and
+// returns the evidence matrix, missing ATS keywords and gaps to prepare for
+func analyzeFit(c *gin.Context) {
+	var req models.FitAnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	profile, err := store.Profiles.Get(req.ProfileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	var jd *models.JobDescription
+	switch {
+	case req.JobDescID != "":
+		jd = lookupJobDescription(req.JobDescID)
+		if jd == nil {
+			c.JSON(http.StatusNotFound, gin.H{"detail": "Job description not found"})
+			return
+		}
+	case strings.TrimSpace(req.JobDescription) != "":
+		parsed, err := services.NewJobDescriptionParser().Parse(req.JobDescription)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
+		jd = parsed
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "jd_id or job_description required"})
+		return
+	}
+
+	report := services.AnalyzeFit(profile, jd)
+	report.ProfileID = req.ProfileID
+	report.JobDescID = jd.ID
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diarizeRecording transcribes a recorded mock interview into speaker turns
+// tagged as interviewer or candidate.
+//
+// Multipart form fields:
+//   - file: the recording, as WAV or Ogg/Opus, or in any format ffmpeg
+//     reads when it is installed
+//   - mode: auto (the default), stereo for a two-track recording with one
+//     speaker per channel, or mono to use the provider's speaker labels
+//   - interviewers: comma-separated speaker labels to treat as the
+//     interviewer, such as channel_0 or spk_1, instead of guessing
+//   - language: spoken language, or auto to identify it; defaults to the
+//     session's language
+//   - vocabulary: comma-separated technical terms to expect
+//   - session_id: append the turns to this session's transcript, whose
+//     profile and job description also add to the vocabulary
+func diarizeRecording(c *gin.Context) {
+	if !parseUploadForm(c) {
+		return
+	}
+	mode := c.DefaultPostForm("mode", services.DiarizeAuto)
+	if mode != services.DiarizeAuto && mode != services.DiarizeStereo && mode != services.DiarizeMono {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "mode must be auto, stereo or mono"})
+		return
+	}
+
+	sessionID := c.PostForm("session_id")
+	var sc *sessionContext
+	if sessionID != "" {
+		if sc = resolveSession(sessionID); sc == nil {
+			c.JSON(http.StatusNotFound, gin.H{"detail": "Session not found"})
+			return
+		}
+	}
+
+	chain, _, err := transcriberFor(c.PostForm("language"), sessionID, c.PostForm("vocabulary"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	var interviewers []string
+	for _, label := range strings.Split(c.PostForm("interviewers"), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			interviewers = append(interviewers, label)
+		}
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "No recording uploaded"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	fmt.Printf("[DIARIZE] Received recording: %d bytes, mode=%s\n", len(content), mode)
+
+	result, err := chain.Diarize(c.Request.Context(), content, mode)
+	if err != nil {
+		fmt.Printf("[DIARIZE] Error: %v\n", err)
+		status := http.StatusOK
+		if errors.Is(err, services.ErrNotSeparated) || errors.Is(err, services.ErrNoSpeakerLabels) {
+			status = http.StatusUnprocessableEntity
+		}
+		if s := transcribeErrorStatus(err); s != http.StatusOK {
+			status = s
+		}
+		c.JSON(status, models.DiarizeResponse{Success: false, Segments: []models.SpeakerSegment{}, Error: err.Error()})
+		return
+	}
+
+	interviewers = services.AssignRoles(result.Segments, interviewers)
+
+	response := models.DiarizeResponse{
+		Success:      len(result.Segments) > 0,
+		Provider:     result.Provider,
+		Method:       result.Method,
+		Duration:     result.Duration,
+		Interviewers: interviewers,
+		Segments:     result.Segments,
+		Warnings:     result.Warnings,
+	}
+
+	if sc != nil && len(result.Segments) > 0 {
+		// The recording is taken to have ended on upload
+		began := time.Now().Add(-time.Duration(result.Duration * float64(time.Second)))
+		messages := make([]models.InterviewMessage, 0, len(result.Segments))
+		for _, s := range result.Segments {
+			messages = append(messages, models.InterviewMessage{
+				Role:      s.Role,
+				Content:   s.Text,
+				Timestamp: began.Add(time.Duration(s.Start * float64(time.Second))),
+				Language:  string(sc.Language),
+				Source:    sourceDiarize,
+			})
+		}
+		recordMessages(sessionID, messages...)
+		response.Recorded = len(messages)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,60 +1,135 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
+	"nexus-ai/audio"
 	"nexus-ai/models"
 	"nexus-ai/services"
+	"nexus-ai/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-var (
-	sessions     = make(map[string]*models.InterviewSession)
-	sessionsLock sync.RWMutex
-)
-
 // RegisterInterviewRoutes registers all interview-related routes
 func RegisterInterviewRoutes(r *gin.RouterGroup) {
 	interview := r.Group("/interview")
 	{
 		interview.POST("/session/start", startSession)
+		interview.GET("/session/:session_id", getSession)
 		interview.POST("/session/:session_id/end", endSession)
+		interview.GET("/session/:session_id/transcript", getTranscript)
 		interview.POST("/assist", getInterviewAssistance)
 		interview.POST("/coding-assist", getCodingAssistance)
 		interview.POST("/feedback", getResponseFeedback)
+		interview.POST("/feedback/audio", getSpokenFeedback)
 		interview.POST("/translate", translateResponse)
+		interview.POST("/diarize", diarizeRecording)
 	}
 }
 
-// startSession starts a new interview session
+// startSession starts a new interview session, optionally linked to a stored
+// profile and job description. Parameters come from the query string or a
+// JSON body.
 func startSession(c *gin.Context) {
-	interviewType := c.DefaultQuery("interview_type", "mixed")
-	language := c.DefaultQuery("language", "en")
+	var req models.StartSessionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
+	}
+
+	if req.InterviewType == "" {
+		req.InterviewType = models.InterviewTypeMixed
+	}
+	if req.Language == "" {
+		req.Language = models.LanguageEnglish
+	}
+	switch req.ProfileMode {
+	case "":
+		req.ProfileMode = models.ProfileModeLive
+	case models.ProfileModeLive, models.ProfileModeSnapshot:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "profile_mode must be live or snapshot"})
+		return
+	}
+
+	if req.JobDescID != "" && lookupJobDescription(req.JobDescID) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Job description not found"})
+		return
+	}
+
+	var snapshot *models.UserProfile
+	if req.ProfileID != "" {
+		// The store hands out copies, so this is already a snapshot
+		snapshot = lookupProfile(req.ProfileID)
+		if snapshot == nil {
+			c.JSON(http.StatusNotFound, gin.H{"detail": "Profile not found"})
+			return
+		}
+	}
 
 	sessionID := uuid.New().String()
 
 	session := &models.InterviewSession{
 		SessionID:     sessionID,
-		InterviewType: models.InterviewType(interviewType),
-		Language:      models.Language(language),
+		ProfileID:     req.ProfileID,
+		UserProfile:   snapshot,
+		InterviewType: req.InterviewType,
+		Language:      req.Language,
+		JobDescID:     req.JobDescID,
 		StartedAt:     time.Now(),
 		IsActive:      true,
 		Messages:      []models.InterviewMessage{},
 	}
+	if req.ProfileID != "" {
+		session.ProfileMode = req.ProfileMode
+	}
 
-	sessionsLock.Lock()
-	sessions[sessionID] = session
-	sessionsLock.Unlock()
+	if err := store.Sessions.Save(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"session_id":     sessionID,
 		"message":        "Interview session started",
-		"interview_type": interviewType,
-		"language":       language,
+		"interview_type": req.InterviewType,
+		"language":       req.Language,
+		"profile_id":     req.ProfileID,
+		"profile_mode":   session.ProfileMode,
+		"jd_id":          req.JobDescID,
+	})
+}
+
+// getSession returns a session with the profile it currently resolves to
+func getSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	session, err := store.Sessions.Get(sessionID)
+	if err != nil {
+		storeError(c, err, "Session not found")
+		return
+	}
+
+	// Resolve from the session already loaded, which a concurrent end or
+	// expiry cannot take away
+	sc := newSessionContext(session)
+	c.JSON(http.StatusOK, gin.H{
+		"session":         session,
+		"profile":         sc.Profile,
+		"job_description": sc.JobDesc,
 	})
 }
 
@@ -62,19 +137,25 @@ func startSession(c *gin.Context) {
 func endSession(c *gin.Context) {
 	sessionID := c.Param("session_id")
 
-	sessionsLock.Lock()
-	session, exists := sessions[sessionID]
-	if exists {
-		session.IsActive = false
+	session, err := store.Sessions.Get(sessionID)
+	if err != nil {
+		storeError(c, err, "Session not found")
+		return
 	}
-	sessionsLock.Unlock()
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"detail": "Session not found"})
-		return
+	// Sessions idle past SESSION_IDLE_TTL may already have been ended by the
+	// janitor; keep their original end time
+	if session.EndedAt == nil {
+		now := time.Now()
+		session.IsActive = false
+		session.EndedAt = &now
+		if err := store.Sessions.Save(session); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+			return
+		}
 	}
 
-	duration := time.Since(session.StartedAt)
+	duration := session.EndedAt.Sub(session.StartedAt)
 
 	c.JSON(http.StatusOK, gin.H{
 		"session_id": sessionID,
@@ -91,6 +172,19 @@ func getInterviewAssistance(c *gin.Context) {
 		return
 	}
 
+	// Get user profile and JD from the session if available
+	var profile *models.UserProfile
+	var jd *models.JobDescription
+	if sc := resolveSession(req.SessionID); sc != nil {
+		profile, jd = sc.Profile, sc.JobDesc
+		if req.InterviewType == "" {
+			req.InterviewType = sc.InterviewType
+		}
+		if req.Language == "" {
+			req.Language = string(sc.Language)
+		}
+	}
+
 	// Set defaults
 	if req.InterviewType == "" {
 		req.InterviewType = models.InterviewTypeMixed
@@ -102,19 +196,20 @@ func getInterviewAssistance(c *gin.Context) {
 		req.AssistanceLevel = "medium"
 	}
 
-	// Get user profile from session if available
 	userProfile := make(map[string]any)
-	sessionsLock.RLock()
-	if session, exists := sessions[req.SessionID]; exists && session.UserProfile != nil {
-		// Convert profile to map
-		userProfile["name"] = session.UserProfile.Name
-		userProfile["skills"] = session.UserProfile.Skills
-		userProfile["experience"] = session.UserProfile.Experience
+	if profile != nil {
+		// Convert profile to map, keeping only the skills relevant to this question
+		userProfile["name"] = profile.Name
+		userProfile["skills"] = services.SelectRelevantSkills(profile.Skills, req.Question, jd, 15)
+		userProfile["experience"] = profile.Experience
+		if summary := services.SeniorityContext(profile, req.Question, jd); summary != "" {
+			userProfile["experience_summary"] = summary
+		}
 	}
-	sessionsLock.RUnlock()
 
 	// Generate response
 	claude := services.NewClaudeService()
+	start := time.Now()
 	response, err := claude.GenerateInterviewResponse(
 		req.Question,
 		userProfile,
@@ -122,6 +217,7 @@ func getInterviewAssistance(c *gin.Context) {
 		req.Context,
 		req.AssistanceLevel,
 		req.Language,
+		jd,
 	)
 
 	if err != nil {
@@ -129,6 +225,25 @@ func getInterviewAssistance(c *gin.Context) {
 		return
 	}
 
+	recordMessages(req.SessionID,
+		models.InterviewMessage{
+			Role:      models.MessageRoleInterviewer,
+			Content:   req.Question,
+			Timestamp: start,
+			Language:  req.Language,
+			Source:    sourceAssist,
+		},
+		models.InterviewMessage{
+			Role:      models.MessageRoleAssistant,
+			Content:   response.SuggestedAnswer,
+			Timestamp: time.Now(),
+			Language:  req.Language,
+			Source:    sourceAssist,
+			Model:     claude.Model(),
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+	)
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -146,6 +261,7 @@ func getCodingAssistance(c *gin.Context) {
 	}
 
 	claude := services.NewClaudeService()
+	start := time.Now()
 	response, err := claude.GenerateCodingAssistance(
 		req.ProblemDescription,
 		req.Language,
@@ -158,6 +274,27 @@ func getCodingAssistance(c *gin.Context) {
 		return
 	}
 
+	answer := response.Approach
+	if response.CodeSnippet != "" {
+		answer += "\n\n" + response.CodeSnippet
+	}
+	recordMessages(req.SessionID,
+		models.InterviewMessage{
+			Role:      models.MessageRoleInterviewer,
+			Content:   req.ProblemDescription,
+			Timestamp: start,
+			Source:    sourceCodingAssist,
+		},
+		models.InterviewMessage{
+			Role:      models.MessageRoleAssistant,
+			Content:   answer,
+			Timestamp: time.Now(),
+			Source:    sourceCodingAssist,
+			Model:     claude.Model(),
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+	)
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -169,15 +306,22 @@ func getResponseFeedback(c *gin.Context) {
 		return
 	}
 
+	if req.InterviewType == "" {
+		if sc := resolveSession(req.SessionID); sc != nil {
+			req.InterviewType = sc.InterviewType
+		}
+	}
 	if req.InterviewType == "" {
 		req.InterviewType = models.InterviewTypeMixed
 	}
 
 	claude := services.NewClaudeService()
+	start := time.Now()
 	response, err := claude.AnalyzeResponseFeedback(
 		req.Question,
 		req.UserResponse,
 		string(req.InterviewType),
+		nil,
 	)
 
 	if err != nil {
@@ -185,9 +329,138 @@ func getResponseFeedback(c *gin.Context) {
 		return
 	}
 
+	recordFeedback(req.SessionID, req.UserResponse, response, claude.Model(), start)
 	c.JSON(http.StatusOK, response)
 }
 
+// getSpokenFeedback transcribes a recorded practice answer and returns the
+// feedback on it with delivery metrics measured from the recording: pace,
+// filler words, pauses, length against a target and volume consistency.
+//
+// Multipart form fields:
+//   - file: the recorded answer
+//   - question: the question answered (required)
+//   - interview_type: defaults to the session's
+//   - target_seconds: intended length of the answer; defaults to the usual
+//     length for the interview type
+//   - language, vocabulary: as for /live/transcribe-chunk
+//   - session_id: session whose transcript records the answer and feedback
+func getSpokenFeedback(c *gin.Context) {
+	if !parseUploadForm(c) {
+		return
+	}
+	question := c.PostForm("question")
+	if question == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "question is required"})
+		return
+	}
+	sessionID := c.PostForm("session_id")
+	interviewType := models.InterviewType(c.PostForm("interview_type"))
+	if interviewType == "" {
+		if sc := resolveSession(sessionID); sc != nil {
+			interviewType = sc.InterviewType
+		}
+	}
+	if interviewType == "" {
+		interviewType = models.InterviewTypeMixed
+	}
+
+	target := services.AnswerTarget(interviewType)
+	if v := c.PostForm("target_seconds"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": "target_seconds must be a positive number of seconds"})
+			return
+		}
+		target = time.Duration(seconds) * time.Second
+	}
+
+	chain, _, err := transcriberFor(c.PostForm("language"), sessionID, c.PostForm("vocabulary"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	chain.WithFillerWords()
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "No recording uploaded"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	start := time.Now()
+	pcm, err := services.DecodeToPCM(c.Request.Context(), content)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrUnsupportedAudio) {
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{"detail": "Could not decode the recording: " + err.Error()})
+		return
+	}
+	transcription, err := chain.TranscribeSpeech(c.Request.Context(), pcm)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrNoTranscriber) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"detail": "Transcription failed: " + err.Error()})
+		return
+	}
+	if transcription.Text == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": "No speech found in the recording"})
+		return
+	}
+	fmt.Printf("[FEEDBACK] Spoken answer: %.1fs, %d chars via %s\n", audio.Seconds(pcm), len(transcription.Text), transcription.Provider)
+
+	delivery := services.AnalyzeDelivery(pcm, transcription.Text, target)
+
+	claude := services.NewClaudeService()
+	response, err := claude.AnalyzeResponseFeedback(question, transcription.Text, string(interviewType), delivery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+	response.Transcript = transcription.Text
+	response.Delivery = delivery
+	response.Warnings = transcription.Warnings
+
+	recordFeedback(sessionID, transcription.Text, response, claude.Model(), start)
+	c.JSON(http.StatusOK, response)
+}
+
+// recordFeedback adds the candidate's own answer, followed by the feedback
+// on it, to the session transcript
+func recordFeedback(sessionID, answer string, response *models.FeedbackResponse, model string, start time.Time) {
+	recordMessages(sessionID,
+		models.InterviewMessage{
+			Role:      models.MessageRoleCandidate,
+			Content:   answer,
+			Timestamp: start,
+			Source:    sourceFeedback,
+		},
+		models.InterviewMessage{
+			Role:      models.MessageRoleAssistant,
+			Content:   response.DetailedFeedback,
+			Timestamp: time.Now(),
+			Source:    sourceFeedback,
+			Model:     model,
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+	)
+}
+
 // translateResponse translates text
 func translateResponse(c *gin.Context) {
 	var req models.TranslateRequest
@@ -211,3 +484,109 @@ func translateResponse(c *gin.Context) {
 	})
 }
 
+// Sources recorded on transcript messages
+const (
+	sourceAssist       = "/interview/assist"
+	sourceCodingAssist = "/interview/coding-assist"
+	sourceFeedback     = "/interview/feedback"
+	sourceStreamAnswer = "/live/stream-answer"
+	sourceDiarize      = "/interview/diarize"
+)
+
+// Transcript page sizes
+const (
+	defaultTranscriptLimit = 50
+	maxTranscriptLimit     = 200
+)
+
+// getTranscript returns a page of a session's recorded messages, oldest
+// first. Use offset and limit to page through long sessions.
+func getTranscript(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "Invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTranscriptLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "Invalid limit"})
+		return
+	}
+	if limit > maxTranscriptLimit {
+		limit = maxTranscriptLimit
+	}
+
+	messages, total, err := store.Sessions.Messages(sessionID, offset, limit)
+	if err != nil {
+		storeError(c, err, "Session not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Transcript{
+		SessionID: sessionID,
+		Total:     total,
+		Offset:    offset,
+		Limit:     limit,
+		Messages:  messages,
+	})
+}
+
+// recordMessages appends messages to a session's transcript. Requests that
+// are not tied to a known session are not recorded, and a failure to record
+// never fails the request itself.
+func recordMessages(sessionID string, messages ...models.InterviewMessage) {
+	if sessionID == "" {
+		return
+	}
+	err := store.Sessions.AppendMessages(sessionID, messages...)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		fmt.Printf("[SESSION] Failed to record messages for %s: %v\n", sessionID, err)
+	}
+}
+
+// sessionContext is the profile, JD and settings a request inherits from
+// its interview session
+type sessionContext struct {
+	Profile       *models.UserProfile
+	JobDesc       *models.JobDescription
+	InterviewType models.InterviewType
+	Language      models.Language
+}
+
+// resolveSession looks up a session and resolves its linked profile and JD.
+// In live mode the stored profile is read fresh so mid-session edits apply;
+// in snapshot mode, or if the profile has since been deleted, the copy taken
+// at session start is used. Returns nil when there is no such session.
+func resolveSession(sessionID string) *sessionContext {
+	if sessionID == "" {
+		return nil
+	}
+
+	session, err := store.Sessions.GetMeta(sessionID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			fmt.Printf("[SESSION] Failed to load session %s: %v\n", sessionID, err)
+		}
+		return nil
+	}
+	return newSessionContext(session)
+}
+
+// newSessionContext resolves a loaded session's linked profile and JD
+func newSessionContext(session *models.InterviewSession) *sessionContext {
+	sc := &sessionContext{
+		Profile:       session.UserProfile,
+		InterviewType: session.InterviewType,
+		Language:      session.Language,
+	}
+	if session.ProfileMode == models.ProfileModeLive {
+		if profile := lookupProfile(session.ProfileID); profile != nil {
+			sc.Profile = profile
+		}
+	}
+	sc.JobDesc = lookupJobDescription(session.JobDescID)
+
+	return sc
+}
@@ -0,0 +1,203 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+	"nexus-ai/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RegisterJobDescriptionRoutes registers all job description routes
+func RegisterJobDescriptionRoutes(r *gin.RouterGroup) {
+	jobs := r.Group("/jobs")
+	{
+		jobs.POST("", createJobDescription)
+		jobs.POST("/upload", uploadJobDescription)
+		jobs.GET("", listJobDescriptions)
+		jobs.GET("/:jd_id", getJobDescription)
+		jobs.DELETE("/:jd_id", deleteJobDescription)
+	}
+}
+
+// createJobDescription parses and stores a pasted job description
+func createJobDescription(c *gin.Context) {
+	var req models.JobDescriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	jd, err := services.NewJobDescriptionParser().Parse(req.Text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	if req.Role != "" {
+		jd.Role = req.Role
+	}
+	if req.Company != "" {
+		jd.Company = req.Company
+	}
+	jd.Source = "text"
+
+	if err := storeJobDescription(jd); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Job description saved",
+		"jd_id":           jd.ID,
+		"job_description": jd,
+	})
+}
+
+// uploadJobDescription handles a PDF, HTML (saved web page) or text upload
+func uploadJobDescription(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "No file uploaded"})
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	allowedTypes := map[string]bool{".pdf": true, ".html": true, ".htm": true, ".txt": true, ".md": true}
+	if !allowedTypes[ext] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"detail": "Unsupported file type. Allowed: .pdf, .html, .htm, .txt, .md",
+		})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": "Failed to open file"})
+		return
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": "Failed to read file"})
+		return
+	}
+
+	parser := services.NewJobDescriptionParser()
+
+	text, source, err := parser.ExtractText(content, file.Filename)
+	if err != nil {
+		c.JSON(extractErrorStatus(err), gin.H{"detail": err.Error()})
+		return
+	}
+
+	jd, err := parser.Parse(text)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": err.Error()})
+		return
+	}
+	jd.Source = source
+	jd.SourceName = file.Filename
+
+	if err := storeJobDescription(jd); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Job description uploaded and parsed successfully",
+		"jd_id":           jd.ID,
+		"job_description": jd,
+	})
+}
+
+// listJobDescriptions lists all stored job descriptions
+func listJobDescriptions(c *gin.Context) {
+	jds, err := store.JobDescriptions.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	list := make([]gin.H, 0, len(jds))
+	for _, jd := range jds {
+		list = append(list, gin.H{
+			"jd_id":     jd.ID,
+			"role":      jd.Role,
+			"company":   jd.Company,
+			"seniority": jd.Seniority,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job_descriptions": list})
+}
+
+// getJobDescription retrieves a stored job description
+func getJobDescription(c *gin.Context) {
+	jd := lookupJobDescription(c.Param("jd_id"))
+	if jd == nil {
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Job description not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jd)
+}
+
+// deleteJobDescription deletes a job description
+func deleteJobDescription(c *gin.Context) {
+	jdID := c.Param("jd_id")
+
+	if err := store.JobDescriptions.Delete(jdID); err != nil {
+		storeError(c, err, "Job description not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job description deleted successfully"})
+}
+
+func storeJobDescription(jd *models.JobDescription) error {
+	jd.ID = uuid.New().String()
+	jd.CreatedAt = time.Now()
+
+	return store.JobDescriptions.Save(jd)
+}
+
+func lookupJobDescription(jdID string) *models.JobDescription {
+	if jdID == "" {
+		return nil
+	}
+
+	jd, err := store.JobDescriptions.Get(jdID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			fmt.Printf("[JD] Failed to load job description %s: %v\n", jdID, err)
+		}
+		return nil
+	}
+	return jd
+}
+
+// resolveJobDescription returns the structured JD for a live interview
+// context: a stored JD when jd_id is given, otherwise a heuristic parse of
+// any raw job_description text
+func resolveJobDescription(ctx *models.InterviewContext) *models.JobDescription {
+	if ctx == nil {
+		return nil
+	}
+	if jd := lookupJobDescription(ctx.JobDescID); jd != nil {
+		return jd
+	}
+	if strings.TrimSpace(ctx.JobDescription) != "" {
+		return services.ParseJobDescriptionHeuristic(ctx.JobDescription)
+	}
+	return nil
+}
@@ -2,11 +2,12 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
-	"sync"
+	"time"
 
 	"nexus-ai/models"
 	"nexus-ai/services"
@@ -14,10 +15,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	memory     = make(map[string]*models.SessionMemory)
-	memoryLock sync.RWMutex
-)
+// memoryLimit is how many recent Q&A pairs are kept per session
+const memoryLimit = 8
 
 // RegisterLiveInterviewRoutes registers all live interview routes
 func RegisterLiveInterviewRoutes(r *gin.RouterGroup) {
@@ -25,9 +24,11 @@ func RegisterLiveInterviewRoutes(r *gin.RouterGroup) {
 	{
 		live.POST("/stream-answer", streamAnswer)
 		live.POST("/transcribe-chunk", transcribeChunk)
+		live.GET("/ws", liveSocketHandler)
 		live.GET("/memory-status", memoryStatus)
 		live.POST("/clear-memory", clearMemory)
 		live.GET("/health", liveHealth)
+		live.GET("/metrics", liveMetrics)
 	}
 }
 
@@ -45,39 +46,50 @@ func streamAnswer(c *gin.Context) {
 		return
 	}
 
+	// Fall back to the profile and JD linked to the interview session when
+	// the request does not carry its own. Q&A memory is only kept for
+	// sessions that exist.
 	sessionID := req.SessionID
-	if sessionID == "" {
-		sessionID = "default"
+	profile := req.Profile
+	jd := resolveJobDescription(req.InterviewContext)
+	sc := resolveSession(sessionID)
+	if sc != nil {
+		if len(profile) == 0 && sc.Profile != nil {
+			profile = profileToMap(sc.Profile)
+		}
+		if jd == nil {
+			jd = sc.JobDesc
+		}
 	}
 
 	// Build system prompt
-	systemPrompt := services.BuildSystemPrompt(req.InterviewContext, req.Profile)
+	systemPrompt := services.BuildSystemPrompt(req.InterviewContext, jd, profile, question)
 
 	// Add conversation history
-	memoryLock.RLock()
-	if mem, exists := memory[sessionID]; exists && len(mem.QA) > 0 {
-		// Get last 3 Q&A pairs
-		start := 0
-		if len(mem.QA) > 3 {
-			start = len(mem.QA) - 3
-		}
-		recent := mem.QA[start:]
-
-		history := "\n\n💬 EARLIER IN THIS INTERVIEW:\n"
-		for _, qa := range recent {
-			q := qa.Question
-			a := qa.Answer
-			if len(q) > 80 {
-				q = q[:80]
+	if sc != nil {
+		if mem, err := store.Memory.Get(sessionID); err == nil && len(mem.QA) > 0 {
+			// Get last 3 Q&A pairs
+			start := 0
+			if len(mem.QA) > 3 {
+				start = len(mem.QA) - 3
 			}
-			if len(a) > 100 {
-				a = a[:100]
+			recent := mem.QA[start:]
+
+			history := "\n\n💬 EARLIER IN THIS INTERVIEW:\n"
+			for _, qa := range recent {
+				q := qa.Question
+				a := qa.Answer
+				if len(q) > 80 {
+					q = q[:80]
+				}
+				if len(a) > 100 {
+					a = a[:100]
+				}
+				history += fmt.Sprintf("Q: %s\nYour answer: %s...\n", q, a)
 			}
-			history += fmt.Sprintf("Q: %s\nYour answer: %s...\n", q, a)
+			systemPrompt += history + "\n\nMaintain consistency with what you've already said."
 		}
-		systemPrompt += history + "\n\nMaintain consistency with what you've already said."
 	}
-	memoryLock.RUnlock()
 
 	// Select model
 	model := "claude-sonnet-4-20250514"
@@ -101,11 +113,13 @@ func streamAnswer(c *gin.Context) {
 	var fullAnswer strings.Builder
 
 	// Start streaming in goroutine
+	start := time.Now()
 	claude := services.NewClaudeService()
 	go claude.StreamAnswer(
 		question,
 		systemPrompt,
 		model,
+		services.NewProfileRedactor(services.ProfileFromMap(profile)),
 		func(text string) {
 			fullAnswer.WriteString(text)
 			textChan <- text
@@ -118,21 +132,32 @@ func streamAnswer(c *gin.Context) {
 		},
 	)
 
+	sendText := func(w io.Writer, text string) {
+		data, _ := json.Marshal(gin.H{"text": text})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		c.Writer.Flush()
+	}
+
 	// Stream response
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case text := <-textChan:
-			data, _ := json.Marshal(gin.H{"text": text})
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			c.Writer.Flush()
+			sendText(w, text)
 			return true
 
 		case <-doneChan:
-			// Store in memory
-			memoryLock.Lock()
-			if _, exists := memory[sessionID]; !exists {
-				memory[sessionID] = &models.SessionMemory{QA: []models.QAPair{}}
+			// The end of the answer is sent just before done, and select
+			// may pick done first
+			for drained := false; !drained; {
+				select {
+				case text := <-textChan:
+					sendText(w, text)
+				default:
+					drained = true
+				}
 			}
+
+			// Store in memory
 			q := question
 			a := fullAnswer.String()
 			if len(q) > 150 {
@@ -141,15 +166,30 @@ func streamAnswer(c *gin.Context) {
 			if len(a) > 200 {
 				a = a[:200]
 			}
-			memory[sessionID].QA = append(memory[sessionID].QA, models.QAPair{
-				Question: q,
-				Answer:   a,
-			})
-			// Keep only last 8
-			if len(memory[sessionID].QA) > 8 {
-				memory[sessionID].QA = memory[sessionID].QA[len(memory[sessionID].QA)-8:]
+			// Keep only the last few pairs
+			if sc != nil {
+				err := store.Memory.Append(sessionID, models.QAPair{Question: q, Answer: a}, memoryLimit)
+				if err != nil {
+					fmt.Printf("[MEMORY] Failed to save Q&A for %s: %v\n", sessionID, err)
+				}
 			}
-			memoryLock.Unlock()
+
+			recordMessages(req.SessionID,
+				models.InterviewMessage{
+					Role:      models.MessageRoleInterviewer,
+					Content:   question,
+					Timestamp: start,
+					Source:    sourceStreamAnswer,
+				},
+				models.InterviewMessage{
+					Role:      models.MessageRoleAssistant,
+					Content:   fullAnswer.String(),
+					Timestamp: time.Now(),
+					Source:    sourceStreamAnswer,
+					Model:     model,
+					LatencyMs: time.Since(start).Milliseconds(),
+				},
+			)
 
 			data, _ := json.Marshal(gin.H{"done": true})
 			fmt.Fprintf(w, "data: %s\n\n", data)
@@ -165,8 +205,16 @@ func streamAnswer(c *gin.Context) {
 	})
 }
 
-// transcribeChunk - uses AWS Transcribe for audio transcription
+// transcribeChunk transcribes an audio chunk with the first configured
+// provider that succeeds, in TRANSCRIBE_PROVIDERS order. The language is the
+// language form field, else the session's; "auto" or neither identifies it.
 func transcribeChunk(c *gin.Context) {
+	chain, lang, err := transcriberFor(c.PostForm("language"), c.PostForm("session_id"), c.PostForm("vocabulary"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusOK, models.TranscribeResponse{Success: false, Text: ""})
@@ -188,38 +236,101 @@ func transcribeChunk(c *gin.Context) {
 
 	fmt.Printf("[TRANSCRIBE] Received audio: %d bytes\n", len(content))
 
-	// Use AWS Transcribe
-	awsService := services.NewAWSTranscribeService()
-	fmt.Printf("[AWS] Configured: %v\n", awsService.IsConfigured())
-	
-	if awsService.IsConfigured() {
-		text, err := awsService.TranscribeAudio(content)
-		if err != nil {
-			fmt.Printf("[AWS] Error: %v\n", err)
-		} else if text != "" {
-			fmt.Printf("[AWS] Result: %s\n", text)
-			c.JSON(http.StatusOK, models.TranscribeResponse{Success: true, Text: text})
-			return
-		} else {
-			fmt.Println("[AWS] Empty result")
+	if len(content) < 1000 {
+		c.JSON(http.StatusOK, models.TranscribeResponse{Success: false, Text: "", Error: "audio too short"})
+		return
+	}
+
+	result, err := chain.Transcribe(c.Request.Context(), content)
+	if err != nil {
+		fmt.Printf("[TRANSCRIBE] Error: %v\n", err)
+		c.JSON(transcribeErrorStatus(err), models.TranscribeResponse{Success: false, Text: "", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TranscribeResponse{
+		Success:          result.Text != "",
+		Text:             result.Text,
+		Provider:         result.Provider,
+		Segments:         result.Segments,
+		Warnings:         result.Warnings,
+		Language:         lang,
+		DetectedLanguage: result.Language,
+	})
+}
+
+// transcribeErrorStatus is the status for a failed transcription: 415 for
+// audio that cannot be decoded here and 503 without a provider. A provider
+// failing is still a 200 with success false, so clients retry the next chunk.
+func transcribeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnsupportedAudio):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrNoTranscriber):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// transcriberFor builds the transcriber chain for a request. The language
+// is the requested one, else the session's; empty means identify it. The
+// vocabulary is the comma-separated terms requested plus those of the
+// session's profile and job description.
+func transcriberFor(language, sessionID, vocabulary string) (*services.TranscriberChain, models.Language, error) {
+	sc := resolveSession(sessionID)
+
+	lang, err := services.ParseLanguage(language)
+	if err != nil {
+		return nil, "", err
+	}
+	if language == "" && sc != nil {
+		if lang, err = services.ParseLanguage(string(sc.Language)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var extra []string
+	for _, term := range strings.Split(vocabulary, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			extra = append(extra, term)
 		}
-	} else {
-		fmt.Println("[AWS] Not configured - check AWS_ACCESS_KEY_ID in .env")
+	}
+	var profile *models.UserProfile
+	var jd *models.JobDescription
+	if sc != nil {
+		profile, jd = sc.Profile, sc.JobDesc
 	}
 
-	c.JSON(http.StatusOK, models.TranscribeResponse{Success: false, Text: ""})
+	chain := services.NewTranscriberChain().
+		WithLanguage(lang).
+		WithVocabulary(services.BuildVocabulary(profile, jd, extra...))
+	return chain, lang, nil
+}
+
+// memorySessionID returns the session_id a memory request names, answering
+// the request itself when it is missing or names no session
+func memorySessionID(c *gin.Context) (string, bool) {
+	sessionID := c.Query("session_id")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "session_id required"})
+		return "", false
+	}
+	if _, err := store.Sessions.GetMeta(sessionID); err != nil {
+		storeError(c, err, "Session not found")
+		return "", false
+	}
+	return sessionID, true
 }
 
 // memoryStatus returns the memory status for a session
 func memoryStatus(c *gin.Context) {
-	sessionID := c.DefaultQuery("session_id", "default")
-
-	memoryLock.RLock()
-	mem, exists := memory[sessionID]
-	memoryLock.RUnlock()
+	sessionID, ok := memorySessionID(c)
+	if !ok {
+		return
+	}
 
 	count := 0
-	if exists {
+	if mem, err := store.Memory.Get(sessionID); err == nil {
 		count = len(mem.QA)
 	}
 
@@ -231,17 +342,72 @@ func memoryStatus(c *gin.Context) {
 
 // clearMemory clears the memory for a session
 func clearMemory(c *gin.Context) {
-	sessionID := c.DefaultQuery("session_id", "default")
+	sessionID, ok := memorySessionID(c)
+	if !ok {
+		return
+	}
 
-	memoryLock.Lock()
-	delete(memory, sessionID)
-	memoryLock.Unlock()
+	if err := store.Memory.Delete(sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
-// liveHealth returns health status
+// liveHealth returns health status and the transcription providers that
+// are ready, in fallback order
 func liveHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ok",
+		"transcribers": services.NewTranscriberChain().Configured(),
+		"ffmpeg":       services.FFmpegAvailable(),
+	})
 }
 
+// liveMetrics reports the live state held by the store: active and archived
+// sessions, sessions with Q&A memory, and what the janitor and size caps have
+// removed since start-up
+func liveMetrics(c *gin.Context) {
+	sessions, err := store.Sessions.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+	memory, err := store.Memory.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"driver":   store.Driver,
+		"sessions": sessions,
+		"memory":   memory,
+		"limits": gin.H{
+			"session_idle_ttl":      store.Options.SessionTTL.String(),
+			"memory_idle_ttl":       store.Options.MemoryTTL.String(),
+			"max_live_sessions":     store.Options.MaxSessions,
+			"max_session_memory":    store.Options.MaxMemory,
+			"max_archived_sessions": store.Options.MaxArchived,
+		},
+	}
+	if last := store.LastSweep(); !last.IsZero() {
+		response["last_sweep"] = last
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// profileToMap converts a stored profile into the loosely typed form the live
+// prompt builder accepts from clients
+func profileToMap(profile *models.UserProfile) map[string]any {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
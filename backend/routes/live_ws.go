@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsMaxMessage caps one binary audio frame; browsers send far less
+	wsMaxMessage = 1 << 20
+	// wsDrainTimeout bounds how long the remaining results are awaited
+	// after the client stops sending audio
+	wsDrainTimeout = 15 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  16 << 10,
+	WriteBufferSize: 16 << 10,
+	// Same policy as the CORS config: any origin may connect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsControl is a text message from the client
+type wsControl struct {
+	Type string `json:"type"`
+}
+
+// liveSocket serializes writes to a live audio WebSocket
+type liveSocket struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (s *liveSocket) send(event models.TranscriptEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return s.conn.WriteJSON(event)
+}
+
+// liveSocketHandler streams audio to one long-lived transcription and pushes
+// partial and final transcript events back as they arrive.
+//
+// Query parameters:
+//   - encoding: pcm (16-bit little-endian mono, the default), webm or ogg
+//     (Opus as recorded by MediaRecorder). webm needs ffmpeg
+//   - sample_rate: rate of pcm audio, default 16000
+//   - language: spoken language, or auto to identify it; defaults to the
+//     session's language
+//   - vocabulary: comma-separated technical terms to expect
+//   - session_id: session whose language, profile skills and job
+//     description terms to use
+//
+// Binary messages carry audio. A text message {"type":"stop"} ends the
+// audio; the server then sends the remaining results, a "done" event and
+// closes the socket.
+func liveSocketHandler(c *gin.Context) {
+	encoding := c.DefaultQuery("encoding", "pcm")
+	sampleRate, err := strconv.Atoi(c.DefaultQuery("sample_rate", "16000"))
+	if err != nil || sampleRate < 8000 || sampleRate > 48000 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "sample_rate must be between 8000 and 48000"})
+		return
+	}
+	if encoding != "pcm" && encoding != "webm" && encoding != "ogg" {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "encoding must be pcm, webm or ogg"})
+		return
+	}
+	if encoding == "webm" && !services.FFmpegAvailable() {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"detail": "webm needs ffmpeg, which is not installed; use encoding=ogg or pcm"})
+		return
+	}
+	chain, lang, err := transcriberFor(c.Query("language"), c.Query("session_id"), c.Query("vocabulary"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		fmt.Printf("[WS] Upgrade failed: %v\n", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(wsMaxMessage)
+	socket := &liveSocket{conn: conn}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := chain.StartStream(ctx)
+	if err != nil {
+		socket.send(models.TranscriptEvent{Type: models.TranscriptEventError, Message: err.Error()})
+		return
+	}
+	fmt.Printf("[WS] Live stream started: provider=%s mode=%s encoding=%s\n", stream.Provider, stream.Mode, encoding)
+
+	// Audio that is not already 16 kHz PCM goes through a long-lived decoder
+	feed := stream.Send
+	var decoder *services.StreamDecoder
+	if encoding != "pcm" || sampleRate != 16000 {
+		format := encoding
+		if format == "pcm" {
+			format = "s16le"
+		}
+		decoder, err = services.NewStreamDecoder(ctx, format, sampleRate, stream.Send)
+		if err != nil {
+			stream.Close()
+			socket.send(models.TranscriptEvent{Type: models.TranscriptEventError, Message: err.Error()})
+			return
+		}
+		feed = func(audio []byte) error {
+			_, err := decoder.Write(audio)
+			return err
+		}
+	}
+
+	socket.send(models.TranscriptEvent{Type: models.TranscriptEventReady, Provider: stream.Provider, Mode: stream.Mode, Language: lang})
+
+	// Relay results until the stream ends
+	relayed := make(chan struct{})
+	go func() {
+		defer close(relayed)
+		for event := range stream.Events() {
+			if err := socket.send(event); err != nil {
+				cancel()
+			}
+		}
+		if err := stream.Err(); err != nil {
+			socket.send(models.TranscriptEvent{Type: models.TranscriptEventError, Message: err.Error()})
+		}
+	}()
+
+	receiveAudio(conn, socket, feed)
+
+	if decoder != nil {
+		if err := decoder.Close(); err != nil {
+			fmt.Printf("[WS] Decoder: %v\n", err)
+		}
+	}
+	stream.Close()
+
+	select {
+	case <-relayed:
+	case <-time.After(wsDrainTimeout):
+		cancel()
+		<-relayed
+	}
+
+	socket.send(models.TranscriptEvent{Type: models.TranscriptEventDone})
+	socket.mu.Lock()
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	socket.mu.Unlock()
+}
+
+// receiveAudio feeds binary messages to the transcription until the client
+// sends stop, disconnects or the feed fails
+func receiveAudio(conn *websocket.Conn, socket *liveSocket, feed func([]byte) error) {
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		switch kind {
+		case websocket.BinaryMessage:
+			if err := feed(data); err != nil {
+				socket.send(models.TranscriptEvent{Type: models.TranscriptEventError, Message: err.Error()})
+				return
+			}
+		case websocket.TextMessage:
+			var msg wsControl
+			if err := json.Unmarshal(data, &msg); err == nil && msg.Type == "stop" {
+				return
+			}
+		}
+	}
+}
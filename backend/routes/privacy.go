@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"nexus-ai/config"
+	"nexus-ai/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPrivacyRoutes registers all privacy routes
+func RegisterPrivacyRoutes(r *gin.RouterGroup) {
+	privacy := r.Group("/privacy")
+	{
+		privacy.GET("/redactions", listRedactions)
+	}
+}
+
+// listRedactions returns the redaction audit log, newest first. Entries show
+// placeholders, kinds and fingerprints, never the redacted values.
+func listRedactions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "Invalid limit"})
+		return
+	}
+
+	cfg := config.GetConfig()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":          cfg.RedactPII,
+		"redact_names":     cfg.RedactNames,
+		"redact_employers": cfg.RedactEmployers,
+		"redactions":       services.RecentRedactions(limit),
+	})
+}
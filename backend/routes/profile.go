@@ -1,21 +1,20 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
 	"strings"
-	"sync"
+	"time"
 
 	"nexus-ai/models"
 	"nexus-ai/services"
+	"nexus-ai/storage"
 
 	"github.com/gin-gonic/gin"
-)
-
-var (
-	profiles     = make(map[string]*models.UserProfile)
-	profilesLock sync.RWMutex
+	"github.com/google/uuid"
 )
 
 // RegisterProfileRoutes registers all profile-related routes
@@ -27,7 +26,19 @@ func RegisterProfileRoutes(r *gin.RouterGroup) {
 		profile.GET("", listProfiles)
 		profile.GET("/:profile_id", getProfile)
 		profile.PUT("/:profile_id", updateProfile)
+		profile.PATCH("/:profile_id", patchProfile)
 		profile.DELETE("/:profile_id", deleteProfile)
+		profile.GET("/:profile_id/export", exportProfile)
+		profile.POST("/:profile_id/export", exportProfile)
+		profile.GET("/:profile_id/variants", listVariants)
+		profile.GET("/:profile_id/timeline", getProfileTimeline)
+		profile.GET("/:profile_id/versions", listProfileVersions)
+		profile.GET("/:profile_id/versions/:version", getProfileVersion)
+		profile.POST("/:profile_id/versions/:version/rollback", rollbackProfile)
+		profile.GET("/:profile_id/diff", diffProfileVersions)
+		profile.POST("/merge", mergeProfiles)
+		profile.GET("/merges/:merge_id", getPendingMerge)
+		profile.POST("/merges/:merge_id/resolve", resolvePendingMerge)
 	}
 }
 
@@ -41,10 +52,10 @@ func uploadResume(c *gin.Context) {
 
 	// Validate file type
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedTypes := map[string]bool{".pdf": true, ".docx": true, ".txt": true}
+	allowedTypes := map[string]bool{".pdf": true, ".docx": true, ".txt": true, ".zip": true}
 	if !allowedTypes[ext] {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"detail": "Unsupported file type. Allowed: .pdf, .docx, .txt",
+			"detail": "Unsupported file type. Allowed: .pdf, .docx, .txt, .zip (LinkedIn data export)",
 		})
 		return
 	}
@@ -63,33 +74,46 @@ func uploadResume(c *gin.Context) {
 		return
 	}
 
-	// Parse resume
-	parser := services.NewResumeParser()
-
-	// Extract text
-	resumeText, err := parser.ExtractText(content, file.Filename)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
-		return
+	var profile *models.UserProfile
+	if ext == ".zip" {
+		// LinkedIn exports are already structured; no LLM parsing needed
+		profile, err = services.ParseLinkedInExport(content)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": err.Error()})
+			return
+		}
+	} else {
+		// Parse resume
+		parser := services.NewResumeParser()
+
+		// Extract text
+		resumeText, err := parser.ExtractText(content, file.Filename)
+		if err != nil {
+			c.JSON(extractErrorStatus(err), gin.H{"detail": err.Error()})
+			return
+		}
+
+		// Parse into structured data
+		profile, err = parser.ParseResume(resumeText)
+		if err != nil {
+			c.JSON(extractErrorStatus(err), gin.H{"detail": err.Error()})
+			return
+		}
 	}
 
-	// Parse into structured data
-	profile, err := parser.ParseResume(resumeText)
+	// Store profile
+	profileID := uuid.New().String()
+
+	version, err := saveProfile(profileID, profile, models.ProfileSourceUpload, file.Filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
 		return
 	}
 
-	// Store profile
-	profileID := strings.ReplaceAll(file.Filename, ".", "_")
-
-	profilesLock.Lock()
-	profiles[profileID] = profile
-	profilesLock.Unlock()
-
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Resume uploaded and parsed successfully",
 		"profile_id": profileID,
+		"version":    version.Version,
 		"profile":    profile,
 	})
 }
@@ -102,34 +126,28 @@ func createManualProfile(c *gin.Context) {
 		return
 	}
 
-	// Generate profile ID
-	profileID := "default"
-	if profile.Name != "" {
-		profileID = strings.ToLower(strings.ReplaceAll(profile.Name, " ", "_"))
-	}
+	profileID := uuid.New().String()
 
-	profilesLock.Lock()
-	profiles[profileID] = &profile
-	profilesLock.Unlock()
+	version, err := saveProfile(profileID, &profile, models.ProfileSourceManual, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Profile created successfully",
 		"profile_id": profileID,
+		"version":    version.Version,
 		"profile":    profile,
 	})
 }
 
 // listProfiles lists all stored profiles
 func listProfiles(c *gin.Context) {
-	profilesLock.RLock()
-	defer profilesLock.RUnlock()
-
-	list := make([]gin.H, 0, len(profiles))
-	for pid, p := range profiles {
-		list = append(list, gin.H{
-			"profile_id": pid,
-			"name":       p.Name,
-		})
+	list, err := store.Profiles.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"profiles": list})
@@ -139,28 +157,28 @@ func listProfiles(c *gin.Context) {
 func getProfile(c *gin.Context) {
 	profileID := c.Param("profile_id")
 
-	profilesLock.RLock()
-	profile, exists := profiles[profileID]
-	profilesLock.RUnlock()
-
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"detail": "Profile not found"})
+	profile, current, ok := loadProfile(c, profileID)
+	if !ok {
 		return
 	}
 
+	c.Header("ETag", profileETag(current))
 	c.JSON(http.StatusOK, profile)
 }
 
-// updateProfile updates an existing profile
+// updateProfile replaces an existing profile. An If-Match header makes the
+// update conditional on the profile not having changed since it was read.
 func updateProfile(c *gin.Context) {
 	profileID := c.Param("profile_id")
 
-	profilesLock.RLock()
-	_, exists := profiles[profileID]
-	profilesLock.RUnlock()
+	current, err := store.Profiles.LatestVersion(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"detail": "Profile not found"})
+	expected, ok := checkIfMatch(c, current)
+	if !ok {
 		return
 	}
 
@@ -170,32 +188,316 @@ func updateProfile(c *gin.Context) {
 		return
 	}
 
-	profilesLock.Lock()
-	profiles[profileID] = &profile
-	profilesLock.Unlock()
+	version, err := saveProfileIfMatch(profileID, &profile, models.ProfileSourceUpdate, "", expected)
+	if err != nil {
+		c.JSON(saveErrorStatus(err), gin.H{"detail": err.Error()})
+		return
+	}
 
+	c.Header("ETag", profileETag(version.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
+		"version": version.Version,
 		"profile": profile,
 	})
 }
 
+// patchProfile partially updates a profile with a JSON Merge Patch
+// (application/merge-patch+json or application/json) or a JSON Patch
+// (application/json-patch+json) document, honoring If-Match
+func patchProfile(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	profile, current, ok := loadProfile(c, profileID)
+	if !ok {
+		return
+	}
+
+	expected, ok := checkIfMatch(c, current)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "Failed to read patch"})
+		return
+	}
+
+	patched, err := services.ApplyProfilePatch(profile, body, c.ContentType())
+	switch {
+	case errors.Is(err, services.ErrUnsupportedPatchType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"detail": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvalidPatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	// Without If-Match, still refuse to apply a patch computed against a
+	// version that changed underneath us
+	if expected == 0 {
+		expected = current
+	}
+
+	version, err := saveProfileIfMatch(profileID, patched, models.ProfileSourcePatch, "", expected)
+	if err != nil {
+		c.JSON(saveErrorStatus(err), gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.Header("ETag", profileETag(version.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile patched successfully",
+		"version": version.Version,
+		"profile": patched,
+	})
+}
+
 // deleteProfile deletes a profile
 func deleteProfile(c *gin.Context) {
 	profileID := c.Param("profile_id")
 
-	profilesLock.Lock()
-	_, exists := profiles[profileID]
-	if exists {
-		delete(profiles, profileID)
+	if err := store.Profiles.Delete(profileID); err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted successfully"})
+}
+
+// errVersionConflict is returned when a conditional save finds the profile
+// has moved on from the version the client last saw
+var errVersionConflict = errors.New("Profile was modified by another request; reload and retry")
+
+// saveProfile normalizes a profile, stores it under profileID and records a
+// new immutable version of it
+func saveProfile(profileID string, profile *models.UserProfile, source, note string) (*models.ProfileVersion, error) {
+	return saveProfileIfMatch(profileID, profile, source, note, 0)
+}
+
+// saveProfileIfMatch is saveProfile that only succeeds while the latest
+// version is still expected. An expected version of 0 skips the check.
+func saveProfileIfMatch(profileID string, profile *models.UserProfile, source, note string, expected int) (*models.ProfileVersion, error) {
+	services.NormalizeProfile(profile)
+
+	version := &models.ProfileVersion{
+		ProfileID: profileID,
+		Source:    source,
+		Note:      note,
+		Profile:   profile,
+		CreatedAt: time.Now(),
+	}
+	if err := store.Profiles.Save(version, expected); err != nil {
+		if errors.Is(err, storage.ErrVersionConflict) {
+			return nil, errVersionConflict
+		}
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	return version, nil
+}
+
+// loadProfile reads a profile with its current version, writing the error
+// response when it cannot. Both are read together, so a concurrent save
+// cannot pair one version's body with another's number.
+func loadProfile(c *gin.Context, profileID string) (*models.UserProfile, int, bool) {
+	profile, current, err := store.Profiles.GetWithVersion(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return nil, 0, false
+	}
+	return profile, current, true
+}
+
+// profileETag is the entity tag of a profile at a given version
+func profileETag(version int) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// checkIfMatch validates an If-Match header against the current version. It
+// returns the version the client expects (0 when there is no precondition)
+// and writes a 412 response when the precondition fails.
+func checkIfMatch(c *gin.Context, current int) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	etag := profileETag(current)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag {
+			return current, true
+		}
+	}
+
+	c.Header("ETag", etag)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"detail": "Profile has changed since it was read", "etag": etag})
+	return 0, false
+}
+
+// saveErrorStatus maps a failed conditional save to an HTTP status
+func saveErrorStatus(err error) int {
+	if errors.Is(err, errVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusInternalServerError
+}
+
+// exportProfile renders a profile (or a saved variant of it) as JSON Resume,
+// Markdown, plain text or PDF. With a job description the profile is
+// tailored first; a POST also saves the result as a named variant.
+func exportProfile(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	var req models.ExportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	if req.JobDescID != "" {
+		jd := lookupJobDescription(req.JobDescID)
+		if jd == nil {
+			c.JSON(http.StatusNotFound, gin.H{"detail": "Job description not found"})
+			return
+		}
+		req.JobDescription = jd.RawText
 	}
-	profilesLock.Unlock()
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"detail": "Profile not found"})
+	format, ok := services.NormalizeExportFormat(req.Format)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"detail": "Unsupported format. Allowed: json, markdown, text, pdf",
+		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted successfully"})
+	profile, err := store.Profiles.Get(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+	var variant *models.ProfileVariant
+	if req.Variant != "" {
+		variant, err = store.Profiles.Variant(profileID, req.Variant)
+		if err != nil {
+			storeError(c, err, "Variant not found")
+			return
+		}
+	}
+
+	target := profile
+	variantName := ""
+	switch {
+	case variant != nil:
+		target = &variant.Profile
+		variantName = variant.Name
+
+	case strings.TrimSpace(req.JobDescription) != "":
+		tailored, err := services.NewResumeTailor().TailorProfile(profile, req.JobDescription)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+			return
+		}
+
+		target = tailored
+		if c.Request.Method == http.MethodGet {
+			break
+		}
+
+		// Generated names carry a random suffix so two exports in the same
+		// second do not overwrite each other
+		variantName = req.VariantName
+		if variantName == "" {
+			variantName = "tailored-" + time.Now().Format("20060102-150405") + "-" + uuid.NewString()[:8]
+		}
+
+		err = store.Profiles.SaveVariant(profileID, &models.ProfileVariant{
+			Name:           variantName,
+			JobDescription: req.JobDescription,
+			Profile:        *tailored,
+			CreatedAt:      time.Now(),
+		})
+		if err != nil {
+			storeError(c, err, "Profile not found")
+			return
+		}
+	}
+
+	exported, err := services.ExportProfile(target, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	filename := profileID
+	if variantName != "" {
+		filename += "-" + variantName
+		c.Header("X-Profile-Variant", variantName)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+exported.Extension))
+	c.Data(http.StatusOK, exported.ContentType, exported.Data)
+}
+
+// listVariants lists the tailored variants saved for a profile
+func listVariants(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	saved, err := store.Profiles.Variants(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	list := make([]gin.H, 0, len(saved))
+	for _, v := range saved {
+		list = append(list, gin.H{
+			"name":       v.Name,
+			"created_at": v.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile_id": profileID, "variants": list})
 }
 
+func lookupProfile(profileID string) *models.UserProfile {
+	if profileID == "" {
+		return nil
+	}
+
+	profile, err := store.Profiles.Get(profileID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			fmt.Printf("[PROFILE] Failed to load profile %s: %v\n", profileID, err)
+		}
+		return nil
+	}
+	return profile
+}
+
+// getProfileTimeline returns the career timeline of a profile: dated roles,
+// total and per-skill years, gaps and overlapping roles
+func getProfileTimeline(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	profile, err := store.Profiles.Get(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, services.BuildTimeline(profile, time.Now()))
+}
+
+// extractErrorStatus maps resume extraction errors to an HTTP status. Files we
+// cannot read text from are the client's problem, not a server failure.
+func extractErrorStatus(err error) int {
+	if errors.Is(err, services.ErrOCRUnavailable) || errors.Is(err, services.ErrEmptyResume) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
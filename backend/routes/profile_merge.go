@@ -0,0 +1,223 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+	"nexus-ai/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Pending merges are drafts: they are not persisted, and ones left
+// unresolved are dropped after a day or when too many pile up
+const (
+	pendingMergeTTL = 24 * time.Hour
+	maxPendingMerge = 500
+)
+
+// pendingMerges holds merges waiting on conflict resolution. mergesLock
+// guards changes to the drafts themselves.
+var (
+	pendingMerges = storage.NewCache[*models.PendingMerge](pendingMergeTTL, maxPendingMerge, nil)
+	mergesLock    sync.Mutex
+)
+
+// mergeProfiles combines several stored profiles (e.g. a parsed resume, a
+// LinkedIn export and a manual profile of side projects). Without conflicts
+// the result is saved straight away; otherwise it is held as a pending merge
+// until the conflicts are resolved.
+func mergeProfiles(c *gin.Context) {
+	var req models.ProfileMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	sources := make([]services.MergeSource, 0, len(req.ProfileIDs))
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, id := range req.ProfileIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		profile, err := store.Profiles.Get(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+			return
+		}
+		sources = append(sources, services.MergeSource{ProfileID: id, Profile: profile})
+	}
+
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Profile not found: " + strings.Join(missing, ", ")})
+		return
+	}
+	if len(sources) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "At least two different profiles are required"})
+		return
+	}
+	targetVersion := 0
+	if req.TargetProfileID != "" {
+		var err error
+		targetVersion, err = store.Profiles.LatestVersion(req.TargetProfileID)
+		if err != nil {
+			storeError(c, err, "Target profile not found")
+			return
+		}
+	}
+
+	merged, conflicts, err := services.MergeProfiles(sources, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	pending := &models.PendingMerge{
+		ID:              uuid.New().String(),
+		SourceIDs:       req.ProfileIDs,
+		TargetProfileID: req.TargetProfileID,
+		Profile:         merged,
+		Conflicts:       conflicts,
+		TargetVersion:   targetVersion,
+		CreatedAt:       time.Now(),
+	}
+
+	if len(conflicts) == 0 {
+		finishMerge(c, pending)
+		return
+	}
+
+	// Once stored the draft may be resolved concurrently, so respond with
+	// a copy taken before
+	draft := copyMerge(pending)
+	pendingMerges.Set(pending.ID, pending)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Profiles merged with conflicts; resolve them to save the profile",
+		"merge_id":  draft.ID,
+		"conflicts": draft.Conflicts,
+		"profile":   draft.Profile,
+	})
+}
+
+// getPendingMerge returns a merge that is waiting on conflict resolution
+func getPendingMerge(c *gin.Context) {
+	mergesLock.Lock()
+	pending, exists := pendingMerges.Get(c.Param("merge_id"))
+	if exists {
+		pending = copyMerge(pending)
+	}
+	mergesLock.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Merge not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pending)
+}
+
+// copyMerge deep-copies a draft through its JSON form, so it can be
+// written out after mergesLock is released
+func copyMerge(pending *models.PendingMerge) *models.PendingMerge {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		panic(err)
+	}
+	var draft models.PendingMerge
+	if err := json.Unmarshal(data, &draft); err != nil {
+		panic(err)
+	}
+	return &draft
+}
+
+// resolvePendingMerge applies conflict resolutions. Once every conflict is
+// resolved, or the request sets finalize, the merged profile is saved.
+func resolvePendingMerge(c *gin.Context) {
+	mergeID := c.Param("merge_id")
+
+	var req models.ResolveMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	mergesLock.Lock()
+	pending, exists := pendingMerges.Get(mergeID)
+	if !exists {
+		mergesLock.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Merge not found"})
+		return
+	}
+	err := services.ResolveMergeConflicts(pending, req.Resolutions)
+	remaining := services.UnresolvedConflicts(pending)
+	done := err == nil && (remaining == 0 || req.Finalize)
+	if done {
+		pendingMerges.Delete(mergeID)
+	} else {
+		pending = copyMerge(pending)
+	}
+	mergesLock.Unlock()
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidResolution) || errors.Is(err, services.ErrInvalidPatch) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"detail": err.Error()})
+		return
+	}
+
+	if !done {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "Conflicts updated",
+			"merge_id":  mergeID,
+			"remaining": remaining,
+			"conflicts": pending.Conflicts,
+			"profile":   pending.Profile,
+		})
+		return
+	}
+
+	finishMerge(c, pending)
+}
+
+// finishMerge saves a merged profile, as a new version of the target profile
+// or as a brand new profile
+func finishMerge(c *gin.Context, pending *models.PendingMerge) {
+	profileID := pending.TargetProfileID
+	if profileID == "" {
+		profileID = uuid.New().String()
+	}
+
+	note := "merged from " + strings.Join(pending.SourceIDs, ", ")
+	version, err := saveProfileIfMatch(profileID, pending.Profile, models.ProfileSourceMerge, note, pending.TargetVersion)
+	if err != nil {
+		// Keep the draft so the resolutions are not lost
+		pendingMerges.Set(pending.ID, pending)
+
+		c.JSON(saveErrorStatus(err), gin.H{"detail": err.Error(), "merge_id": pending.ID})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Profiles merged successfully",
+		"profile_id": profileID,
+		"version":    version.Version,
+		"conflicts":  pending.Conflicts,
+		"profile":    pending.Profile,
+	})
+}
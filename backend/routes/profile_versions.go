@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"nexus-ai/models"
+	"nexus-ai/services"
+	"nexus-ai/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listProfileVersions lists the version history of a profile, oldest first
+func listProfileVersions(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	history, err := store.Profiles.Versions(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	list := make([]gin.H, 0, len(history))
+	for _, v := range history {
+		list = append(list, gin.H{
+			"version":    v.Version,
+			"source":     v.Source,
+			"note":       v.Note,
+			"created_at": v.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile_id": profileID, "versions": list})
+}
+
+// getProfileVersion returns one version of a profile in full
+func getProfileVersion(c *gin.Context) {
+	version, status, err := lookupProfileVersion(c.Param("profile_id"), c.Param("version"))
+	if err != nil {
+		c.JSON(status, gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// rollbackProfile restores an earlier version. History is never rewritten:
+// the restored profile is saved as a new version.
+func rollbackProfile(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	target, status, err := lookupProfileVersion(profileID, c.Param("version"))
+	if err != nil {
+		c.JSON(status, gin.H{"detail": err.Error()})
+		return
+	}
+
+	profile := target.Profile
+	note := fmt.Sprintf("rolled back to version %d", target.Version)
+	version, err := saveProfile(profileID, profile, models.ProfileSourceRollback, note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Profile rolled back to version %d", target.Version),
+		"version": version.Version,
+		"profile": profile,
+	})
+}
+
+// diffProfileVersions returns the field-level changes between two versions.
+// "to" defaults to the latest version and "from" to the one before it.
+func diffProfileVersions(c *gin.Context) {
+	profileID := c.Param("profile_id")
+
+	latest, err := store.Profiles.LatestVersion(profileID)
+	if err != nil {
+		storeError(c, err, "Profile not found")
+		return
+	}
+
+	to := latest
+	if v := c.Query("to"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > latest {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": "Invalid 'to' version"})
+			return
+		}
+		to = n
+	}
+	from := to - 1
+	if v := c.Query("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > latest {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": "Invalid 'from' version"})
+			return
+		}
+		from = n
+	}
+
+	// Version 0 is the empty profile, so the first version diffs as all adds
+	var before *models.UserProfile
+	if from > 0 {
+		v, err := store.Profiles.Version(profileID, from)
+		if err != nil {
+			storeError(c, err, "Version not found")
+			return
+		}
+		before = v.Profile
+	}
+	after, err := store.Profiles.Version(profileID, to)
+	if err != nil {
+		storeError(c, err, "Version not found")
+		return
+	}
+	changes, err := services.DiffProfiles(before, after.Profile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProfileDiff{
+		ProfileID: profileID,
+		From:      from,
+		To:        to,
+		Changes:   changes,
+	})
+}
+
+// lookupProfileVersion finds a version by its number, returning the HTTP
+// status to use when it cannot
+func lookupProfileVersion(profileID, number string) (*models.ProfileVersion, int, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return nil, http.StatusBadRequest, errors.New("Invalid version")
+	}
+
+	if _, err := store.Profiles.LatestVersion(profileID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, http.StatusNotFound, errors.New("Profile not found")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	version, err := store.Profiles.Version(profileID, n)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, http.StatusNotFound, errors.New("Version not found")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	return version, http.StatusOK, nil
+}
@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nexus-ai/models"
+)
+
+// recordingSaveInterval is how often a recording being transcribed is saved
+// with its progress so far
+const recordingSaveInterval = 2 * time.Second
+
+// recordingJobs transcribes queued recordings in the background
+var recordingJobs = &recordingQueue{
+	queue:   make(chan string, 256),
+	running: map[string]*recordingJob{},
+}
+
+// recordingQueue hands queued recordings to a fixed pool of workers
+type recordingQueue struct {
+	queue chan string
+
+	mu      sync.Mutex
+	running map[string]*recordingJob
+}
+
+// recordingJob is a recording being transcribed. Once deleted is set its
+// progress is no longer saved, so a deleted recording stays deleted.
+type recordingJob struct {
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	deleted bool
+}
+
+// StartRecordingWorkers starts the workers that transcribe uploaded
+// recordings and queues again any a previous run left queued or unfinished.
+// Call it after SetStore.
+func StartRecordingWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go recordingJobs.work()
+	}
+
+	list, err := store.Recordings.List()
+	if err != nil {
+		fmt.Printf("[RECORDING] Could not list recordings to resume: %v\n", err)
+		return
+	}
+	// List is newest first; resume the oldest first
+	for i := len(list) - 1; i >= 0; i-- {
+		rec := list[i]
+		if rec.Status == models.RecordingQueued || rec.Status == models.RecordingProcessing {
+			fmt.Printf("[RECORDING] Resuming %s\n", rec.ID)
+			if err := queueRecording(rec); err != nil {
+				fmt.Printf("[RECORDING] Could not queue %s: %v\n", rec.ID, err)
+			}
+		}
+	}
+}
+
+// queueRecording marks a recording queued and hands it to the workers
+func queueRecording(rec *models.Recording) error {
+	rec.Status = models.RecordingQueued
+	rec.Error = ""
+	rec.UpdatedAt = time.Now()
+	if err := store.Recordings.Save(rec); err != nil {
+		return err
+	}
+	recordingJobs.enqueue(rec.ID)
+	return nil
+}
+
+func (q *recordingQueue) enqueue(id string) {
+	select {
+	case q.queue <- id:
+	default:
+		// The recording stays queued in the store; wait for room without
+		// holding up the request
+		go func() { q.queue <- id }()
+	}
+}
+
+// cancel stops the transcription of a recording, if one is running
+func (q *recordingQueue) cancel(id string) {
+	q.mu.Lock()
+	job := q.running[id]
+	q.mu.Unlock()
+	if job == nil {
+		return
+	}
+	job.mu.Lock()
+	job.deleted = true
+	job.cancel()
+	job.mu.Unlock()
+}
+
+func (q *recordingQueue) work() {
+	for id := range q.queue {
+		q.process(id)
+	}
+}
+
+// process transcribes one recording, saving its segments as they come. On
+// success the audio is removed; on failure it is kept for a retry.
+func (q *recordingQueue) process(id string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &recordingJob{cancel: cancel}
+	// Registered before the recording is read, so a delete from here on
+	// is never undone by a save
+	q.mu.Lock()
+	q.running[id] = job
+	q.mu.Unlock()
+	defer func() {
+		cancel()
+		q.mu.Lock()
+		delete(q.running, id)
+		q.mu.Unlock()
+	}()
+
+	rec, err := store.Recordings.Get(id)
+	if err != nil || rec.Status != models.RecordingQueued {
+		return
+	}
+	rec.Status = models.RecordingProcessing
+	rec.Progress, rec.Duration = 0, 0
+	rec.Text, rec.DetectedLanguage = "", ""
+	rec.Segments = []models.TranscriptSegment{}
+	rec.Warnings = nil
+	if !job.save(rec) {
+		return
+	}
+
+	start := time.Now()
+	fmt.Printf("[RECORDING] Transcribing %s (%d bytes)\n", id, rec.Received)
+	if err := transcribeRecording(ctx, job, rec); err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("[RECORDING] %s deleted during transcription\n", id)
+			return
+		}
+		fmt.Printf("[RECORDING] %s failed after %v: %v\n", id, time.Since(start).Round(time.Second), err)
+		rec.Status = models.RecordingFailed
+		rec.Error = err.Error()
+		job.save(rec)
+		return
+	}
+
+	fmt.Printf("[RECORDING] %s done: %d segments, %.0fs of audio in %v\n", id, len(rec.Segments), rec.Duration, time.Since(start).Round(time.Second))
+	if job.save(rec) {
+		if err := store.Spool.Remove(id); err != nil {
+			fmt.Printf("[RECORDING] Removing audio of %s failed: %v\n", id, err)
+		}
+	}
+}
+
+// transcribeRecording streams a recording's audio from the spool through the
+// transcriber, filling in rec as windows are transcribed
+func transcribeRecording(ctx context.Context, job *recordingJob, rec *models.Recording) error {
+	chain, _, err := transcriberFor(string(rec.Language), rec.SessionID, strings.Join(rec.Vocabulary, ","))
+	if err != nil {
+		return err
+	}
+	audio, err := store.Spool.Open(rec.ID)
+	if err != nil {
+		return fmt.Errorf("recording audio is unavailable: %w", err)
+	}
+	defer audio.Close()
+
+	read := &countingReader{r: audio}
+	rec.Provider = strings.Join(chain.Configured(), ",")
+	lastSave := time.Now()
+	failed, firstError := 0, ""
+	err = chain.TranscribeLong(ctx, read, func(event models.TranscriptEvent) {
+		switch event.Type {
+		case models.TranscriptEventFinal:
+			rec.Segments = append(rec.Segments, models.TranscriptSegment{Start: event.Start, End: event.End, Text: event.Text})
+			if event.Language != "" {
+				rec.DetectedLanguage = event.Language
+			}
+		case models.TranscriptEventError:
+			failed++
+			warning := fmt.Sprintf("%s-%s not transcribed: %s", clock(event.Start), clock(event.End), event.Message)
+			if firstError == "" {
+				firstError = warning
+			}
+			rec.Warnings = append(rec.Warnings, warning)
+		case models.TranscriptEventWarning:
+			if !slices.Contains(rec.Warnings, event.Message) {
+				rec.Warnings = append(rec.Warnings, event.Message)
+			}
+			return
+		}
+		rec.Duration = event.End
+		// Audio is read a little ahead of transcription, so hold back
+		// from 100% until the end
+		if rec.Received > 0 {
+			rec.Progress = math.Min(float64(read.count())/float64(rec.Received), 0.99)
+		}
+		if time.Since(lastSave) >= recordingSaveInterval {
+			job.save(rec)
+			lastSave = time.Now()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(rec.Segments) == 0 && failed > 0 {
+		return fmt.Errorf("no part of the recording could be transcribed: %s", firstError)
+	}
+
+	texts := make([]string, len(rec.Segments))
+	for i, s := range rec.Segments {
+		texts[i] = s.Text
+	}
+	rec.Text = strings.Join(texts, " ")
+	rec.Status = models.RecordingDone
+	rec.Progress = 1
+	return nil
+}
+
+// save stores a recording unless it has been deleted, and reports whether
+// it did
+func (j *recordingJob) save(rec *models.Recording) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.deleted {
+		return false
+	}
+	rec.UpdatedAt = time.Now()
+	if err := store.Recordings.Save(rec); err != nil {
+		fmt.Printf("[RECORDING] Saving %s failed: %v\n", rec.ID, err)
+	}
+	return true
+}
+
+// countingReader counts the bytes read through it, safe to check from
+// another goroutine
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func (c *countingReader) count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// clock formats seconds as H:MM:SS
+func clock(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,425 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-ai/config"
+	"nexus-ai/models"
+	"nexus-ai/services"
+	"nexus-ai/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RegisterRecordingRoutes registers the routes for transcribing long
+// recordings in the background
+func RegisterRecordingRoutes(r *gin.RouterGroup) {
+	recordings := r.Group("/recordings")
+	{
+		recordings.POST("", createRecording)
+		recordings.GET("/:recording_id", getRecording)
+		recordings.HEAD("/:recording_id", recordingOffset)
+		recordings.PATCH("/:recording_id", appendRecording)
+		recordings.POST("/:recording_id/complete", completeRecording)
+		recordings.GET("/:recording_id/export", exportRecording)
+		recordings.DELETE("/:recording_id", deleteRecording)
+	}
+}
+
+// uploadSaves orders deletes against the saves that end an upload request,
+// so a recording deleted while its audio was arriving stays deleted
+var uploadSaves sync.Mutex
+
+// uploadFormMemory is how much of a form parsed by parseUploadForm is held
+// in memory, as gin does; larger files are buffered on disk
+const uploadFormMemory = 32 << 20
+
+// Content types of the transcript exports
+var exportContentTypes = map[string]string{
+	services.TranscriptFormatText: "text/plain; charset=utf-8",
+	services.TranscriptFormatSRT:  "application/x-subrip; charset=utf-8",
+	services.TranscriptFormatVTT:  "text/vtt; charset=utf-8",
+}
+
+// createRecording starts a recording upload. A multipart form with a file
+// field is streamed to disk in one request and queued at once. A JSON
+// models.RecordingRequest instead opens a resumable upload, which the client
+// fills with PATCH requests. Either way, session_id, language and
+// vocabulary work as for /live/transcribe-chunk.
+func createRecording(c *gin.Context) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		uploadRecording(c)
+		return
+	}
+
+	var req models.RecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	limit := config.GetConfig().RecordingMaxBytes
+	if req.Size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "size must not be negative"})
+		return
+	}
+	if req.Size > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"detail": fmt.Sprintf("Recording exceeds the %d MB limit", limit>>20)})
+		return
+	}
+
+	rec := newRecording(c, uuid.New().String(), req.Filename, req.SessionID, req.Language, req.Vocabulary)
+	if rec == nil {
+		return
+	}
+	rec.Size = req.Size
+	if err := store.Recordings.Save(rec); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+
+	fmt.Printf("[RECORDING] Upload %s started: %d bytes expected\n", rec.ID, rec.Size)
+	c.Header("Location", "/recordings/"+rec.ID)
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, rec)
+}
+
+// uploadRecording streams a multipart upload to the spool part by part, so
+// the recording is never held in memory
+func uploadRecording(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+
+	id := uuid.New().String()
+	limit := config.GetConfig().RecordingMaxBytes
+	fields := map[string]string{}
+	var filename string
+	var received int64
+	uploaded := false
+	fail := func(status int, detail string) {
+		store.Spool.Remove(id)
+		c.JSON(status, gin.H{"detail": detail})
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if part.FormName() != "file" {
+			value, _ := io.ReadAll(io.LimitReader(part, 64<<10))
+			fields[part.FormName()] = string(value)
+			continue
+		}
+		if uploaded {
+			fail(http.StatusBadRequest, "Upload one recording at a time")
+			return
+		}
+		uploaded = true
+		filename = part.FileName()
+		if received, err = store.Spool.Append(id, 0, io.LimitReader(part, limit)); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if n, _ := part.Read(make([]byte, 1)); n > 0 {
+			fail(http.StatusRequestEntityTooLarge, fmt.Sprintf("Recording exceeds the %d MB limit", limit>>20))
+			return
+		}
+	}
+	if received == 0 {
+		fail(http.StatusBadRequest, "No recording uploaded")
+		return
+	}
+
+	rec := newRecording(c, id, filename, fields["session_id"], fields["language"], fields["vocabulary"])
+	if rec == nil {
+		store.Spool.Remove(id)
+		return
+	}
+	rec.Size, rec.Received = received, received
+
+	fmt.Printf("[RECORDING] Received %s: %d bytes\n", rec.ID, received)
+	if err := queueRecording(rec); err != nil {
+		fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Location", "/recordings/"+rec.ID)
+	c.JSON(http.StatusAccepted, rec)
+}
+
+// newRecording checks the transcription settings of a new upload and returns
+// its record, or writes the error response and returns nil
+func newRecording(c *gin.Context, id, filename, sessionID, language, vocabulary string) *models.Recording {
+	if sessionID != "" && resolveSession(sessionID) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"detail": "Session not found"})
+		return nil
+	}
+	_, lang, err := transcriberFor(language, sessionID, vocabulary)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return nil
+	}
+
+	var terms []string
+	for _, term := range strings.Split(vocabulary, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	if filename != "" {
+		filename = filepath.Base(filename)
+	}
+	now := time.Now()
+	return &models.Recording{
+		ID:         id,
+		Status:     models.RecordingUploading,
+		Filename:   filename,
+		SessionID:  sessionID,
+		Language:   lang,
+		Vocabulary: terms,
+		Segments:   []models.TranscriptSegment{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// getRecording returns a recording's status and progress, and its
+// timestamped transcript once there is one
+func getRecording(c *gin.Context) {
+	rec, err := store.Recordings.Get(c.Param("recording_id"))
+	if err != nil {
+		storeError(c, err, "Recording not found")
+		return
+	}
+	c.JSON(http.StatusOK, rec)
+}
+
+// recordingOffset tells a client resuming an upload where to carry on from,
+// in the Upload-Offset header
+func recordingOffset(c *gin.Context) {
+	rec, err := store.Recordings.Get(c.Param("recording_id"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	offset := rec.Received
+	if rec.Status == models.RecordingUploading {
+		if offset, err = store.Spool.Size(rec.ID); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	if rec.Size > 0 {
+		c.Header("Upload-Length", strconv.FormatInt(rec.Size, 10))
+	}
+	c.Status(http.StatusOK)
+}
+
+// appendRecording adds the request body to a resumable upload. The
+// Upload-Offset header must match the bytes received so far; on a mismatch
+// the response is 409 with the current offset. An upload of declared size
+// is queued once it is all there.
+func appendRecording(c *gin.Context) {
+	rec, err := store.Recordings.Get(c.Param("recording_id"))
+	if err != nil {
+		storeError(c, err, "Recording not found")
+		return
+	}
+	if rec.Status != models.RecordingUploading {
+		c.JSON(http.StatusConflict, gin.H{"detail": "Recording upload is already complete"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "Upload-Offset header required"})
+		return
+	}
+
+	limit := config.GetConfig().RecordingMaxBytes
+	if rec.Size > 0 {
+		limit = rec.Size
+	}
+	received, err := store.Spool.Append(rec.ID, offset, io.LimitReader(c.Request.Body, limit-offset))
+	c.Header("Upload-Offset", strconv.FormatInt(received, 10))
+	switch {
+	case errors.Is(err, storage.ErrOffsetMismatch):
+		c.JSON(http.StatusConflict, gin.H{"detail": err.Error(), "received": received})
+		return
+	case errors.Is(err, storage.ErrUploadBusy):
+		c.JSON(http.StatusConflict, gin.H{"detail": err.Error()})
+		return
+	}
+
+	rec.Received = received
+	rec.UpdatedAt = time.Now()
+	if err != nil {
+		// Keep what arrived, so the client can resume from there
+		saveUpload(rec, false)
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error(), "received": received})
+		return
+	}
+	n, _ := c.Request.Body.Read(make([]byte, 1))
+	tooLarge := n > 0
+
+	// An upload cut off at its declared size is still complete
+	if err := saveUpload(rec, rec.Size > 0 && received == rec.Size); err != nil {
+		storeError(c, err, "Recording was deleted during the upload")
+		return
+	}
+	if tooLarge {
+		detail := fmt.Sprintf("Recording exceeds the %d MB limit", limit>>20)
+		if rec.Size > 0 {
+			detail = fmt.Sprintf("Upload exceeds its declared size of %d bytes; the rest was ignored", rec.Size)
+		}
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"detail": detail, "received": received})
+		return
+	}
+	c.JSON(http.StatusOK, rec)
+}
+
+// parseUploadForm parses a multipart form carrying a recording that is read
+// whole, refusing bodies over RECORDING_MAX_MB. Failures are answered here.
+func parseUploadForm(c *gin.Context) bool {
+	limit := config.GetConfig().RecordingMaxBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	if err := c.Request.ParseMultipartForm(uploadFormMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"detail": fmt.Sprintf("Recording exceeds the %d MB limit", limit>>20)})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return false
+	}
+	return true
+}
+
+// saveUpload stores a recording after audio was appended to it, queuing it
+// for transcription when queue is set. If the recording was deleted in the
+// meantime, the audio the append wrote back is removed and ErrNotFound
+// returned.
+func saveUpload(rec *models.Recording, queue bool) error {
+	uploadSaves.Lock()
+	defer uploadSaves.Unlock()
+
+	if _, err := store.Recordings.Get(rec.ID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			store.Spool.Remove(rec.ID)
+		}
+		return err
+	}
+	if queue {
+		return queueRecording(rec)
+	}
+	return store.Recordings.Save(rec)
+}
+
+// completeRecording queues an upload of unknown size once the client has
+// sent all of it, or a failed recording for another attempt
+func completeRecording(c *gin.Context) {
+	rec, err := store.Recordings.Get(c.Param("recording_id"))
+	if err != nil {
+		storeError(c, err, "Recording not found")
+		return
+	}
+	if rec.Status != models.RecordingUploading && rec.Status != models.RecordingFailed {
+		c.JSON(http.StatusConflict, gin.H{"detail": "Recording is already " + rec.Status})
+		return
+	}
+
+	received, err := store.Spool.Size(rec.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+	switch {
+	case received == 0 && rec.Status == models.RecordingFailed:
+		c.JSON(http.StatusConflict, gin.H{"detail": "Recording audio is no longer available; upload it again"})
+		return
+	case received == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "No recording uploaded"})
+		return
+	case rec.Size > 0 && received != rec.Size:
+		c.JSON(http.StatusConflict, gin.H{"detail": fmt.Sprintf("Upload incomplete: %d of %d bytes received", received, rec.Size), "received": received})
+		return
+	}
+
+	rec.Received = received
+	if err := queueRecording(rec); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, rec)
+}
+
+// exportRecording downloads a finished transcript as plain text (txt), SRT
+// or WebVTT (vtt)
+func exportRecording(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", services.TranscriptFormatText))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": "format must be txt, srt or vtt"})
+		return
+	}
+
+	rec, err := store.Recordings.Get(c.Param("recording_id"))
+	if err != nil {
+		storeError(c, err, "Recording not found")
+		return
+	}
+	if rec.Status != models.RecordingDone {
+		c.JSON(http.StatusConflict, gin.H{"detail": "Recording is not transcribed yet (status: " + rec.Status + ")"})
+		return
+	}
+
+	content, err := services.ExportTranscript(rec.Segments, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	filename := strings.TrimSuffix(rec.Filename, filepath.Ext(rec.Filename))
+	if filename == "" {
+		filename = "recording-" + rec.ID
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	c.Data(http.StatusOK, contentType, []byte(content))
+}
+
+// deleteRecording stops any transcription in progress and removes the
+// recording with its audio
+func deleteRecording(c *gin.Context) {
+	id := c.Param("recording_id")
+
+	recordingJobs.cancel(id)
+	uploadSaves.Lock()
+	defer uploadSaves.Unlock()
+	if err := store.Recordings.Delete(id); err != nil {
+		storeError(c, err, "Recording not found")
+		return
+	}
+	if err := store.Spool.Remove(id); err != nil {
+		fmt.Printf("[RECORDING] Removing audio of %s failed: %v\n", id, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recording deleted successfully"})
+}
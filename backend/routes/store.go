@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"nexus-ai/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// store holds profiles, sessions, session memory, job descriptions and
+// recordings. It starts out in memory; main swaps in the configured backend
+// with SetStore before serving.
+var store = storage.NewMemoryStore()
+
+// SetStore replaces the store used by all routes. Call it before the server
+// starts handling requests.
+func SetStore(s *storage.Store) {
+	store = s
+}
+
+// storeError writes the response for a failed repository call: 404 with the
+// given detail when the entity does not exist, 500 otherwise
+func storeError(c *gin.Context, err error, notFound string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"detail": notFound})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+}
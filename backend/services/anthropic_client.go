@@ -34,11 +34,18 @@ func NewAnthropicClient() *AnthropicClient {
 
 // MessageRequest represents a request to the messages API
 type MessageRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	System    string          `json:"system,omitempty"`
-	Messages  []MessageInput  `json:"messages"`
-	Stream    bool            `json:"stream,omitempty"`
+	Model     string         `json:"model"`
+	MaxTokens int            `json:"max_tokens"`
+	System    string         `json:"system,omitempty"`
+	Messages  []MessageInput `json:"messages"`
+	Stream    bool           `json:"stream,omitempty"`
+
+	// Redactor strips personal data from System and Messages before the
+	// call and restores it in the reply. When nil, the default redactor
+	// (emails, phones, addresses) is used if PII_REDACTION is on.
+	Redactor *Redactor `json:"-"`
+	// Purpose labels the call in the redaction audit log
+	Purpose string `json:"-"`
 }
 
 // MessageInput represents an input message
@@ -49,12 +56,12 @@ type MessageInput struct {
 
 // MessageResponse represents a response from the messages API
 type MessageResponse struct {
-	ID      string          `json:"id"`
-	Type    string          `json:"type"`
-	Role    string          `json:"role"`
-	Content []ContentBlock  `json:"content"`
-	Model   string          `json:"model"`
-	Usage   UsageInfo       `json:"usage"`
+	ID      string         `json:"id"`
+	Type    string         `json:"type"`
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+	Model   string         `json:"model"`
+	Usage   UsageInfo      `json:"usage"`
 }
 
 // ContentBlock represents a content block in the response
@@ -71,10 +78,10 @@ type UsageInfo struct {
 
 // StreamEvent represents a streaming event
 type StreamEvent struct {
-	Type         string       `json:"type"`
-	Index        int          `json:"index,omitempty"`
+	Type         string        `json:"type"`
+	Index        int           `json:"index,omitempty"`
 	ContentBlock *ContentBlock `json:"content_block,omitempty"`
-	Delta        *DeltaBlock  `json:"delta,omitempty"`
+	Delta        *DeltaBlock   `json:"delta,omitempty"`
 }
 
 // DeltaBlock represents a delta in streaming
@@ -83,10 +90,38 @@ type DeltaBlock struct {
 	Text string `json:"text"`
 }
 
+// redact returns a copy of the request with personal data replaced by
+// placeholders, and the redactor that can restore it
+func (req MessageRequest) redact() (MessageRequest, *Redactor) {
+	r := req.Redactor
+	if r == nil {
+		r = NewRedactor()
+	}
+	if r == nil {
+		return req, nil
+	}
+
+	req.System = r.Redact(req.System)
+	messages := make([]MessageInput, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = MessageInput{Role: m.Role, Content: r.Redact(m.Content)}
+	}
+	req.Messages = messages
+
+	purpose := req.Purpose
+	if purpose == "" {
+		purpose = req.Model
+	}
+	RecordRedaction(r, purpose)
+
+	return req, r
+}
+
 // CreateMessage sends a non-streaming message request
 func (c *AnthropicClient) CreateMessage(req MessageRequest) (*MessageResponse, error) {
 	req.Stream = false
-	
+	req, redactor := req.redact()
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -119,6 +154,10 @@ func (c *AnthropicClient) CreateMessage(req MessageRequest) (*MessageResponse, e
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	for i := range result.Content {
+		result.Content[i].Text = redactor.Rehydrate(result.Content[i].Text)
+	}
+
 	return &result, nil
 }
 
@@ -130,6 +169,23 @@ func (c *AnthropicClient) CreateMessageStream(
 	onError func(err error),
 ) {
 	req.Stream = true
+	req, redactor := req.redact()
+
+	// Placeholders can be split across deltas, so rehydrate through a buffer
+	rehydrator := redactor.NewStreamRehydrator()
+	emit := onText
+	onText = func(text string) {
+		if out := rehydrator.Write(text); out != "" {
+			emit(out)
+		}
+	}
+	finish := onDone
+	onDone = func() {
+		if out := rehydrator.Flush(); out != "" {
+			emit(out)
+		}
+		finish()
+	}
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -162,10 +218,10 @@ func (c *AnthropicClient) CreateMessageStream(
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
 		line := scanner.Text()
-		
+
 		if strings.HasPrefix(line, "data: ") {
 			data := strings.TrimPrefix(line, "data: ")
-			
+
 			if data == "[DONE]" {
 				onDone()
 				return
@@ -212,4 +268,3 @@ func (r *MessageResponse) GetText() string {
 	}
 	return text.String()
 }
-
@@ -20,6 +20,11 @@ func NewClaudeService() *ClaudeService {
 	}
 }
 
+// Model is the model used for non-streaming requests
+func (s *ClaudeService) Model() string {
+	return s.model
+}
+
 // GenerateInterviewResponse generates a tailored interview response
 func (s *ClaudeService) GenerateInterviewResponse(
 	question string,
@@ -28,6 +33,7 @@ func (s *ClaudeService) GenerateInterviewResponse(
 	ctx string,
 	assistanceLevel string,
 	language string,
+	jd *models.JobDescription,
 ) (*models.AssistanceResponse, error) {
 
 	levelInstructions := map[string]string{
@@ -38,11 +44,19 @@ func (s *ClaudeService) GenerateInterviewResponse(
 
 	profileJSON, _ := json.MarshalIndent(userProfile, "", "  ")
 
+	jdSection := "Not specified"
+	if jd != nil {
+		jdSection = FormatJobDescription(jd)
+	}
+
 	systemPrompt := fmt.Sprintf(`You are NEXUS AI assistant, an expert interview coach helping candidates succeed in job interviews.
 
 USER PROFILE:
 %s
 
+TARGET JOB:
+%s
+
 INTERVIEW TYPE: %s
 
 RESPONSE LANGUAGE: %s
@@ -52,7 +66,7 @@ ASSISTANCE LEVEL: %s
 
 Your task is to help the candidate answer interview questions by:
 1. Analyzing the question type (behavioral, technical, situational)
-2. Connecting the answer to their specific background and experience
+2. Connecting the answer to their specific background and experience, and to the target job's requirements
 3. Using the STAR method for behavioral questions
 4. Being concise yet comprehensive
 5. Maintaining a professional, confident tone
@@ -61,7 +75,7 @@ Respond in JSON format with these fields:
 - suggested_answer: The full suggested response
 - key_points: Array of 3-5 key points to remember
 - follow_up_tips: Any tips for potential follow-up questions
-- confidence_score: Your confidence in this answer (0.0-1.0)`, string(profileJSON), interviewType, language, assistanceLevel, levelInstructions[assistanceLevel])
+- confidence_score: Your confidence in this answer (0.0-1.0)`, string(profileJSON), jdSection, interviewType, language, assistanceLevel, levelInstructions[assistanceLevel])
 
 	userMessage := fmt.Sprintf(`Interview Question: %s
 
@@ -76,6 +90,8 @@ Please provide a tailored response that highlights my relevant experience and sk
 		Messages: []MessageInput{
 			{Role: "user", Content: userMessage},
 		},
+		Redactor: NewProfileRedactor(ProfileFromMap(userProfile)),
+		Purpose:  "interview_assist",
 	})
 
 	if err != nil {
@@ -170,6 +186,7 @@ Respond in JSON format with these fields:
 		Messages: []MessageInput{
 			{Role: "user", Content: userMessage},
 		},
+		Purpose: "coding_assist",
 	})
 
 	if err != nil {
@@ -217,11 +234,13 @@ Respond in JSON format with these fields:
 	return result, nil
 }
 
-// AnalyzeResponseFeedback analyzes user's interview response
+// AnalyzeResponseFeedback analyzes user's interview response. For a spoken
+// answer the measured delivery grounds the tone analysis.
 func (s *ClaudeService) AnalyzeResponseFeedback(
 	question string,
 	userResponse string,
 	interviewType string,
+	delivery *models.DeliveryMetrics,
 ) (*models.FeedbackResponse, error) {
 
 	systemPrompt := fmt.Sprintf(`You are NEXUS AI's feedback analyzer, providing constructive feedback on interview responses.
@@ -242,6 +261,15 @@ Respond in JSON format:
 - improvements: Array of specific improvement suggestions
 - detailed_feedback: Comprehensive feedback paragraph`, interviewType)
 
+	if delivery != nil {
+		systemPrompt += fmt.Sprintf(`
+
+The response was spoken; it is a transcript. Its delivery was measured from the recording:
+%s
+
+Base the tone analysis on these measurements as well as the wording, and mention delivery where it matters. Do not re-estimate the numbers.`, DescribeDelivery(delivery))
+	}
+
 	userMessage := fmt.Sprintf(`Interview Question: %s
 
 Candidate's Response: %s
@@ -255,6 +283,7 @@ Please analyze this response and provide constructive feedback.`, question, user
 		Messages: []MessageInput{
 			{Role: "user", Content: userMessage},
 		},
+		Purpose: "response_feedback",
 	})
 
 	if err != nil {
@@ -330,6 +359,7 @@ func (s *ClaudeService) TranslateText(text string, targetLanguage string) (strin
 		Messages: []MessageInput{
 			{Role: "user", Content: text},
 		},
+		Purpose: "translate",
 	})
 
 	if err != nil {
@@ -339,8 +369,11 @@ func (s *ClaudeService) TranslateText(text string, targetLanguage string) (strin
 	return resp.GetText(), nil
 }
 
-// BuildSystemPrompt builds dynamic system prompt for live interview
-func BuildSystemPrompt(ctx *models.InterviewContext, profile map[string]interface{}) string {
+// BuildSystemPrompt builds dynamic system prompt for live interview. The job
+// description is passed in structured form; callers resolve ctx.JobDescID or
+// parse raw ctx.JobDescription before calling. The question is used to pick
+// the profile skills worth mentioning.
+func BuildSystemPrompt(ctx *models.InterviewContext, jd *models.JobDescription, profile map[string]interface{}, question string) string {
 	base := `You are being interviewed for a job. Respond exactly like a real human would in an interview - natural, confident, conversational.
 
 SPEAK LIKE A REAL PERSON:
@@ -365,22 +398,30 @@ WHAT TO AVOID:
 - Saying "I think" or "maybe" - be confident
 `
 
-	// Add role context
-	if ctx != nil && ctx.Role != "" {
-		base += fmt.Sprintf("\n\n🎯 ROLE YOU'RE INTERVIEWING FOR: %s", ctx.Role)
-		if ctx.Company != "" {
-			base += fmt.Sprintf(" at %s", ctx.Company)
+	// Add role context, falling back to the JD's title and company
+	role, company := "", ""
+	if ctx != nil {
+		role, company = ctx.Role, ctx.Company
+	}
+	if jd != nil {
+		if role == "" {
+			role = jd.Role
+		}
+		if company == "" {
+			company = jd.Company
+		}
+	}
+	if role != "" {
+		base += fmt.Sprintf("\n\n🎯 ROLE YOU'RE INTERVIEWING FOR: %s", role)
+		if company != "" {
+			base += fmt.Sprintf(" at %s", company)
 		}
 		base += "\nTailor your answers to demonstrate you're perfect for THIS specific role."
 	}
 
 	// Add JD context
-	if ctx != nil && ctx.JobDescription != "" {
-		jdSnippet := ctx.JobDescription
-		if len(jdSnippet) > 800 {
-			jdSnippet = jdSnippet[:800]
-		}
-		base += fmt.Sprintf("\n\n📋 JOB REQUIREMENTS TO ADDRESS:\n%s", jdSnippet)
+	if jdSection := FormatJobDescription(jd); jdSection != "" {
+		base += fmt.Sprintf("\n\n📋 JOB REQUIREMENTS TO ADDRESS:\n%s", jdSection)
 		base += "\n\nWhen relevant, naturally reference how your experience matches these requirements."
 	}
 
@@ -392,16 +433,13 @@ WHAT TO AVOID:
 
 		var skillsStr string
 		if len(skills) > 0 {
-			skillsList := make([]string, 0, 12)
-			for i, s := range skills {
-				if i >= 12 {
-					break
-				}
+			skillsList := make([]string, 0, len(skills))
+			for _, s := range skills {
 				if str, ok := s.(string); ok {
 					skillsList = append(skillsList, str)
 				}
 			}
-			skillsStr = strings.Join(skillsList, ", ")
+			skillsStr = strings.Join(SelectRelevantSkills(skillsList, question, jd, 12), ", ")
 		}
 
 		base += "\n\n👤 YOUR BACKGROUND:"
@@ -432,6 +470,10 @@ WHAT TO AVOID:
 			}
 		}
 
+		if summary := SeniorityContext(ProfileFromMap(profile), question, jd); summary != "" {
+			base += "\n" + summary
+		}
+
 		base += "\n\nUse YOUR real background naturally in answers - speak as yourself!"
 	}
 
@@ -444,11 +486,26 @@ EXAMPLE OF GOOD HUMAN RESPONSE:
 	return base
 }
 
+// ProfileFromMap decodes a loosely typed live-interview profile into a
+// UserProfile; fields that do not fit the model are ignored
+func ProfileFromMap(profile map[string]interface{}) *models.UserProfile {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil
+	}
+	var p models.UserProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil
+	}
+	return &p
+}
+
 // StreamAnswer generates streaming response for live interview
 func (s *ClaudeService) StreamAnswer(
 	question string,
 	systemPrompt string,
 	model string,
+	redactor *Redactor,
 	onText func(text string),
 	onDone func(),
 	onError func(err error),
@@ -469,6 +526,8 @@ Respond naturally like you're actually in the interview. Be yourself!`, question
 			Messages: []MessageInput{
 				{Role: "user", Content: userMessage},
 			},
+			Redactor: redactor,
+			Purpose:  "live_answer",
 		},
 		onText,
 		onDone,
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"nexus-ai/audio"
+	"nexus-ai/config"
+)
+
+// Uploads are sniffed from their first bytes rather than trusting the
+// filename or content type. WAV and Ogg/Opus, what browsers and most
+// recorders produce, are decoded in Go; anything else goes to ffmpeg when
+// it is installed.
+
+// sniffLen is how much of a stream is held back to identify its format,
+// the most mimetype looks at
+const sniffLen = 3072
+
+// ErrUnsupportedAudio is returned for audio that only ffmpeg can decode when
+// ffmpeg is not installed
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+var (
+	ffmpegPath string
+	ffmpegOnce sync.Once
+)
+
+// DetectFFmpeg looks for ffmpeg on the first call and logs what it found,
+// so a missing binary shows up at startup rather than on the first upload
+// that needs it
+func DetectFFmpeg() {
+	ffmpegOnce.Do(func() {
+		command := config.GetConfig().FFmpegCommand
+		path, err := exec.LookPath(command)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var out []byte
+			if out, err = exec.CommandContext(ctx, path, "-hide_banner", "-version").Output(); err == nil {
+				version, _, _ := strings.Cut(string(out), "\n")
+				fmt.Printf("[AUDIO] Using %s for formats other than WAV and Ogg/Opus\n", version)
+				ffmpegPath = path
+				return
+			}
+		}
+		fmt.Printf("[AUDIO] %s unavailable (%v): only WAV and Ogg/Opus audio can be decoded\n", command, err)
+	})
+}
+
+// FFmpegAvailable reports whether ffmpeg was found
+func FFmpegAvailable() bool {
+	DetectFFmpeg()
+	return ffmpegPath != ""
+}
+
+// needsFFmpeg is the error for audio of the given format without ffmpeg
+func needsFFmpeg(format string) error {
+	format, _, _ = strings.Cut(format, ";")
+	return fmt.Errorf("%w: %s audio needs ffmpeg, which is not installed; send WAV or Ogg/Opus instead", ErrUnsupportedAudio, format)
+}
+
+// nativeDecoder returns the Go decoder for audio sniffed as kind, or nil when
+// only ffmpeg reads it
+func nativeDecoder(kind *mimetype.MIME) func(r io.Reader, channels int) (io.Reader, error) {
+	switch {
+	case kind.Is("audio/wav"):
+		return func(r io.Reader, channels int) (io.Reader, error) {
+			w, err := audio.NewWAVReader(r, channels)
+			if err != nil {
+				return nil, err
+			}
+			return w, nil
+		}
+	case kind.Is("audio/ogg") || kind.Is("application/ogg"):
+		return func(r io.Reader, channels int) (io.Reader, error) {
+			o, err := audio.NewOpusReader(r, channels)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+		}
+	}
+	return nil
+}
+
+// DecodeToPCM converts an audio upload to 16 kHz mono 16-bit PCM. Audio is
+// decoded in memory so nothing is written to disk.
+func DecodeToPCM(ctx context.Context, audio []byte) ([]byte, error) {
+	return decodePCM(ctx, audio, pcmChannels)
+}
+
+// decodePCM converts audio to 16 kHz 16-bit PCM with the given number of
+// interleaved channels. Mono input is copied to every channel. Audio the Go
+// decoders reject, such as compressed WAV or Ogg Vorbis, gets a second try
+// with ffmpeg.
+func decodePCM(ctx context.Context, data []byte, channels int) ([]byte, error) {
+	kind := mimetype.Detect(data)
+	decode := nativeDecoder(kind)
+	if decode == nil {
+		if !FFmpegAvailable() {
+			return nil, needsFFmpeg(kind.String())
+		}
+		return ffmpegDecode(ctx, data, channels)
+	}
+
+	r, err := decode(bytes.NewReader(data), channels)
+	if err == nil {
+		var pcm []byte
+		if pcm, err = io.ReadAll(r); err == nil {
+			return pcm, nil
+		}
+	}
+	if !FFmpegAvailable() {
+		if errors.Is(err, audio.ErrUnsupported) {
+			return nil, fmt.Errorf("%w: %v; ffmpeg, which is not installed, may read it", ErrUnsupportedAudio, err)
+		}
+		return nil, err
+	}
+	fmt.Printf("[AUDIO] Could not decode %s natively, trying ffmpeg: %v\n", kind, err)
+	return ffmpegDecode(ctx, data, channels)
+}
+
+func ffmpegDecode(ctx context.Context, data []byte, channels int) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, ffmpegPath, ffmpegArgs("", 0, channels)...)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %v - %s", err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// ffmpegArgs has ffmpeg read format from stdin, probing it when empty, and
+// write 16 kHz 16-bit PCM with the given channels to stdout. Raw s16le input
+// is mono at sampleRate.
+func ffmpegArgs(format string, sampleRate, channels int) []string {
+	args := []string{"-loglevel", "error"}
+	if format != "" {
+		args = append(args, "-f", format)
+	}
+	if format == "s16le" {
+		args = append(args, "-ar", fmt.Sprint(sampleRate), "-ac", "1")
+	}
+	return append(args,
+		"-i", "pipe:0",
+		"-ar", fmt.Sprint(pcmSampleRate),
+		"-ac", fmt.Sprint(channels),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"pipe:1",
+	)
+}
+
+// StreamDecoder converts a continuous audio stream to 16 kHz mono PCM as it
+// arrives. Input formats are ffmpeg demuxer names: ogg, for the Opus audio
+// browsers record, and s16le, raw PCM at another sample rate, are decoded
+// in Go, and others such as webm by a long-lived ffmpeg process. An empty
+// format sniffs the first bytes of the stream.
+type StreamDecoder struct {
+	ctx        context.Context
+	format     string
+	sampleRate int
+	emit       func(pcm []byte) error
+
+	head []byte         // input held back until the format is known
+	in   *io.PipeWriter // input of the running decoder
+	done chan struct{}
+	err  error
+}
+
+// NewStreamDecoder returns a decoder that calls emit with each block of
+// decoded PCM, from a single goroutine, until the input is closed. It fails
+// straight away for a format that needs ffmpeg when ffmpeg is missing.
+func NewStreamDecoder(ctx context.Context, format string, sampleRate int, emit func(pcm []byte) error) (*StreamDecoder, error) {
+	d := &StreamDecoder{ctx: ctx, format: format, sampleRate: sampleRate, emit: emit}
+	if format == "" {
+		return d, nil
+	}
+	if format != "ogg" && format != "s16le" && !FFmpegAvailable() {
+		return nil, needsFFmpeg(format)
+	}
+	d.start()
+	return d, nil
+}
+
+// Write feeds encoded audio to the decoder
+func (d *StreamDecoder) Write(p []byte) (int, error) {
+	if d.in != nil {
+		return d.in.Write(p)
+	}
+	d.head = append(d.head, p...)
+	if len(d.head) >= sniffLen {
+		if err := d.sniff(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close ends the input and waits until all decoded audio has been emitted
+func (d *StreamDecoder) Close() error {
+	if d.in == nil {
+		if err := d.sniff(); err != nil {
+			return err
+		}
+	}
+	d.in.Close()
+	<-d.done
+	return d.err
+}
+
+// sniff picks the decoder for a stream of unknown format and replays the
+// input held back so far
+func (d *StreamDecoder) sniff() error {
+	kind := mimetype.Detect(d.head)
+	if nativeDecoder(kind) == nil && !FFmpegAvailable() {
+		return needsFFmpeg(kind.String())
+	}
+	d.start()
+	head := d.head
+	d.head = nil
+	_, err := d.in.Write(head)
+	return err
+}
+
+// start runs the decoder for the stream in a goroutine fed through a pipe.
+// When the Go decoder rejects the stream's header the input read so far is
+// replayed to ffmpeg instead.
+func (d *StreamDecoder) start() {
+	open := d.opener()
+	pr, pw := io.Pipe()
+	d.in = pw
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		var err error
+		if open == nil {
+			err = d.runFFmpeg(pr)
+		} else {
+			replay := &recordingReader{r: pr}
+			var pcm io.Reader
+			if pcm, err = open(replay); err == nil {
+				replay.stop()
+				err = d.pump(pcm)
+			} else if FFmpegAvailable() {
+				fmt.Printf("[AUDIO] Could not decode stream natively, trying ffmpeg: %v\n", err)
+				err = d.runFFmpeg(io.MultiReader(replay.stop(), pr))
+			}
+		}
+		if err != nil {
+			d.err = err
+			pr.CloseWithError(err)
+			return
+		}
+		// Anything after the end of the audio is ignored
+		go io.Copy(io.Discard, pr)
+	}()
+}
+
+// opener returns the Go decoder for the stream, or nil for ffmpeg
+func (d *StreamDecoder) opener() func(io.Reader) (io.Reader, error) {
+	switch d.format {
+	case "s16le":
+		return func(r io.Reader) (io.Reader, error) {
+			return audio.NewPCMReader(r, d.sampleRate, 1, pcmChannels), nil
+		}
+	case "ogg":
+		return func(r io.Reader) (io.Reader, error) {
+			return nativeDecoder(mimetype.Lookup("audio/ogg"))(r, pcmChannels)
+		}
+	case "":
+		if decode := nativeDecoder(mimetype.Detect(d.head)); decode != nil {
+			return func(r io.Reader) (io.Reader, error) {
+				return decode(r, pcmChannels)
+			}
+		}
+	}
+	return nil
+}
+
+// pump passes decoded PCM to emit in 250ms blocks. After emit fails the rest
+// is still read, so whatever produces it is not left blocked.
+func (d *StreamDecoder) pump(pcm io.Reader) error {
+	var emitErr error
+	buf := make([]byte, pcmSampleRate*audio.BytesPerSample/4)
+	for {
+		n, err := io.ReadFull(pcm, buf)
+		if n > 0 && emitErr == nil {
+			block := make([]byte, n)
+			copy(block, buf[:n])
+			emitErr = d.emit(block)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return emitErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// runFFmpeg decodes input with ffmpeg until it ends
+func (d *StreamDecoder) runFFmpeg(input io.Reader) error {
+	cmd := exec.CommandContext(d.ctx, ffmpegPath, ffmpegArgs(d.format, d.sampleRate, pcmChannels)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	go func() {
+		io.Copy(stdin, input)
+		stdin.Close()
+	}()
+
+	pumpErr := d.pump(stdout)
+	if err := cmd.Wait(); err != nil && pumpErr == nil {
+		return fmt.Errorf("ffmpeg: %v - %s", err, stderr.String())
+	}
+	return pumpErr
+}
+
+// recordingReader keeps a copy of what is read until stopped, so a stream
+// can be replayed to another decoder
+type recordingReader struct {
+	r       io.Reader
+	buf     bytes.Buffer
+	stopped bool
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if !r.stopped {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// stop ends the recording and returns what was read
+func (r *recordingReader) stop() io.Reader {
+	r.stopped = true
+	return &r.buf
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"nexus-ai/models"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	deepgramListenURL = "https://api.deepgram.com/v1/listen"
+	deepgramStreamURL = "wss://api.deepgram.com/v1/listen"
+)
+
+// DeepgramTranscriber transcribes with Deepgram's pre-recorded audio API
+type DeepgramTranscriber struct {
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func NewDeepgramTranscriber(apiKey, model string) *DeepgramTranscriber {
+	return &DeepgramTranscriber{apiKey: apiKey, model: model, client: &http.Client{}}
+}
+
+func (t *DeepgramTranscriber) Name() string { return "deepgram" }
+
+func (t *DeepgramTranscriber) IsConfigured() bool {
+	return t.apiKey != ""
+}
+
+type deepgramResponse struct {
+	Results struct {
+		Channels []struct {
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+			} `json:"alternatives"`
+			DetectedLanguage string `json:"detected_language"`
+		} `json:"channels"`
+	} `json:"results"`
+}
+
+// query returns the options shared by batch and live requests. Without a
+// language, batch requests ask Deepgram to detect it. The vocabulary is
+// boosted.
+func (t *DeepgramTranscriber) query(opts TranscribeOptions) (url.Values, error) {
+	query := url.Values{}
+	if opts.Language != "" {
+		code, err := providerLanguage(t.Name(), opts.Language, deepgramCode)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("language", code)
+	} else {
+		query.Set("detect_language", "true")
+	}
+	query.Set("model", t.model)
+	query.Set("smart_format", "true")
+	if opts.FillerWords {
+		query.Set("filler_words", "true")
+	}
+	query.Set("encoding", "linear16")
+	query.Set("sample_rate", fmt.Sprint(pcmSampleRate))
+	query.Set("channels", fmt.Sprint(pcmChannels))
+
+	// Nova-3 takes whole key terms; older models boost single keywords
+	if strings.HasPrefix(t.model, "nova-3") {
+		for _, term := range opts.Vocabulary {
+			query.Add("keyterm", term)
+		}
+	} else {
+		seen := map[string]bool{}
+		for _, term := range opts.Vocabulary {
+			for _, word := range strings.Fields(term) {
+				if !seen[word] {
+					seen[word] = true
+					query.Add("keywords", word)
+				}
+			}
+		}
+	}
+	return query, nil
+}
+
+func (t *DeepgramTranscriber) Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error) {
+	query, err := t.query(opts)
+	if err != nil {
+		return nil, err
+	}
+	body, err := t.post(ctx, query, pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	var result deepgramResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse deepgram response: %w", err)
+	}
+	if len(result.Results.Channels) == 0 || len(result.Results.Channels[0].Alternatives) == 0 {
+		return &Transcription{}, nil
+	}
+	channel := result.Results.Channels[0]
+	return &Transcription{
+		Text:     channel.Alternatives[0].Transcript,
+		Language: languageFrom(deepgramCode, channel.DetectedLanguage),
+	}, nil
+}
+
+// deepgramUtterances is the response to a diarized request
+type deepgramUtterances struct {
+	Results struct {
+		Utterances []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			Speaker    int     `json:"speaker"`
+			Transcript string  `json:"transcript"`
+		} `json:"utterances"`
+	} `json:"results"`
+}
+
+// TranscribeSpeakers asks Deepgram to diarize the audio and returns its
+// utterances, each spoken by one speaker
+func (t *DeepgramTranscriber) TranscribeSpeakers(ctx context.Context, pcm []byte, opts TranscribeOptions) ([]models.SpeakerSegment, error) {
+	query, err := t.query(opts)
+	if err != nil {
+		return nil, err
+	}
+	query.Set("diarize", "true")
+	query.Set("utterances", "true")
+
+	body, err := t.post(ctx, query, pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	var result deepgramUtterances
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse deepgram response: %w", err)
+	}
+	segments := []models.SpeakerSegment{}
+	for _, u := range result.Results.Utterances {
+		segments = append(segments, models.SpeakerSegment{
+			Speaker: fmt.Sprintf("spk_%d", u.Speaker),
+			Text:    u.Transcript,
+			Start:   u.Start,
+			End:     u.End,
+		})
+	}
+	return segments, nil
+}
+
+// post sends audio to the pre-recorded API and returns the response body
+func (t *DeepgramTranscriber) post(ctx context.Context, query url.Values, pcm []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, deepgramListenURL+"?"+query.Encode(), bytes.NewReader(pcm))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+t.apiKey)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("deepgram request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("deepgram error %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// StartStream opens a Deepgram live transcription socket with interim
+// results. Live transcription cannot detect the language, so one must be
+// given.
+func (t *DeepgramTranscriber) StartStream(ctx context.Context, opts TranscribeOptions) (TranscriptStream, error) {
+	if opts.Language == "" {
+		return nil, fmt.Errorf("deepgram cannot identify the language of a live stream")
+	}
+	query, err := t.query(opts)
+	if err != nil {
+		return nil, err
+	}
+	query.Set("interim_results", "true")
+
+	header := http.Header{}
+	header.Set("Authorization", "Token "+t.apiKey)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, deepgramStreamURL+"?"+query.Encode(), header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("deepgram stream error %d: %w", resp.StatusCode, err)
+		}
+		return nil, fmt.Errorf("deepgram stream failed: %w", err)
+	}
+
+	s := &deepgramStream{
+		conn:   conn,
+		events: make(chan models.TranscriptEvent, 32),
+		ended:  make(chan struct{}),
+	}
+	go s.read()
+	go func() {
+		// Drop the socket if the caller goes away without closing
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-s.ended:
+		}
+	}()
+	return s, nil
+}
+
+// deepgramMessage is the subset of a live "Results" message we use
+type deepgramMessage struct {
+	Type     string  `json:"type"`
+	IsFinal  bool    `json:"is_final"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	Channel  struct {
+		Alternatives []struct {
+			Transcript string `json:"transcript"`
+		} `json:"alternatives"`
+	} `json:"channel"`
+}
+
+// deepgramStream adapts a Deepgram live socket to TranscriptStream
+type deepgramStream struct {
+	conn   *websocket.Conn
+	events chan models.TranscriptEvent
+	ended  chan struct{}
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	err     error
+}
+
+func (s *deepgramStream) read() {
+	defer close(s.ended)
+	defer close(s.events)
+	defer s.conn.Close()
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			// Deepgram closes normally once CloseStream has been handled
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+			}
+			return
+		}
+
+		var msg deepgramMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "Results" {
+			continue
+		}
+		if len(msg.Channel.Alternatives) == 0 || msg.Channel.Alternatives[0].Transcript == "" {
+			continue
+		}
+		eventType := models.TranscriptEventPartial
+		if msg.IsFinal {
+			eventType = models.TranscriptEventFinal
+		}
+		s.events <- models.TranscriptEvent{
+			Type:  eventType,
+			Text:  msg.Channel.Alternatives[0].Transcript,
+			Start: msg.Start,
+			End:   msg.Start + msg.Duration,
+		}
+	}
+}
+
+func (s *deepgramStream) Send(pcm []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteMessage(websocket.BinaryMessage, pcm)
+}
+
+func (s *deepgramStream) Events() <-chan models.TranscriptEvent {
+	return s.events
+}
+
+// Close asks Deepgram to flush its remaining results and close the socket
+func (s *deepgramStream) Close() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
+}
+
+func (s *deepgramStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"nexus-ai/audio"
+	"nexus-ai/models"
+)
+
+// Verdicts on an answer's length and pace
+const (
+	DurationShort    = "short"
+	DurationOnTarget = "on_target"
+	DurationLong     = "long"
+
+	PaceSlow = "slow"
+	PaceGood = "good"
+	PaceFast = "fast"
+)
+
+const (
+	// paceSlowWPM and paceFastWPM bound a comfortable interview pace
+	paceSlowWPM = 110
+	paceFastWPM = 170
+	// durationTolerance is how far from the target an answer may run and
+	// still count as on target
+	durationTolerance = 0.25
+	// minPause and longPause are the silences counted as a pause and as a
+	// long one
+	minPause  = 0.5
+	longPause = 2.0
+	// fillersPerMinuteHigh is the rate at which fillers start to distract
+	fillersPerMinuteHigh = 4
+	// levelWindow is the span volume is measured over: about a word or two
+	levelWindow = 500 * time.Millisecond
+	// levelFloorDB drops windows that are mostly silence from the volume
+	// measurements
+	levelFloorDB = -50
+	// Level variation, as a standard deviation in dB, of perfectly
+	// consistent and wildly inconsistent speech
+	steadyVariationDB   = 4
+	unsteadyVariationDB = 14
+	// fadeDB is the drop in level from the first third of an answer to the
+	// last that counts as trailing off
+	fadeDB = 6
+)
+
+// answerTargets is the usual length of a spoken answer by interview type;
+// others get defaultAnswerTarget
+var answerTargets = map[models.InterviewType]time.Duration{
+	models.InterviewTypeBehavioral:  2 * time.Minute,
+	models.InterviewTypeSituational: 2 * time.Minute,
+	models.InterviewTypeTechnical:   150 * time.Second,
+	models.InterviewTypeCoding:      3 * time.Minute,
+}
+
+const defaultAnswerTarget = 2 * time.Minute
+
+// AnswerTarget returns the usual length of an answer in an interview of the
+// given type
+func AnswerTarget(interviewType models.InterviewType) time.Duration {
+	if target, ok := answerTargets[interviewType]; ok {
+		return target
+	}
+	return defaultAnswerTarget
+}
+
+// AnalyzeDelivery measures a spoken answer from its transcript and its audio
+// as 16 kHz mono PCM: pace, filler words, pauses, length against target and
+// how steady the volume was. Pauses and volume come from the audio alone,
+// so they do not depend on how a provider punctuates or times words.
+func AnalyzeDelivery(pcm []byte, transcript string, target time.Duration) *models.DeliveryMetrics {
+	vadCfg := audio.DefaultVADConfig()
+	vadCfg.Padding = 0 // measure pauses between the words themselves
+	segments := audio.NewVAD(vadCfg).Segments(pcm)
+
+	m := &models.DeliveryMetrics{
+		TargetDuration: target.Seconds(),
+		Fillers:        map[string]int{},
+		Observations:   []string{},
+	}
+
+	first, last := -1, -1
+	for i, s := range segments {
+		if s.Speech {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			m.SpeakingTime += s.Duration()
+		}
+	}
+	if first < 0 {
+		m.Observations = append(m.Observations, "No speech was detected in the recording")
+		return m
+	}
+	m.Duration = segments[last].End - segments[first].Start
+
+	// Pauses are the silences between the first word and the last
+	var pauses []float64
+	for _, s := range segments[first:last] {
+		if !s.Speech && s.Duration() >= minPause {
+			pauses = append(pauses, s.Duration())
+		}
+	}
+	m.PauseCount = len(pauses)
+	for _, p := range pauses {
+		m.AveragePause += p
+		m.LongestPause = math.Max(m.LongestPause, p)
+		if p >= longPause {
+			m.LongPauseCount++
+		}
+	}
+	if len(pauses) > 0 {
+		m.AveragePause /= float64(len(pauses))
+	}
+
+	words := transcriptWords(transcript)
+	m.WordCount = len(words)
+	m.Fillers = countFillers(words)
+	for _, n := range m.Fillers {
+		m.FillerCount += n
+	}
+	minutes := m.Duration / 60
+	if minutes > 0 {
+		m.WordsPerMinute = float64(m.WordCount) / minutes
+		m.FillersPerMinute = float64(m.FillerCount) / minutes
+	}
+
+	switch {
+	case m.WordsPerMinute < paceSlowWPM:
+		m.PaceVerdict = PaceSlow
+	case m.WordsPerMinute > paceFastWPM:
+		m.PaceVerdict = PaceFast
+	default:
+		m.PaceVerdict = PaceGood
+	}
+
+	if m.TargetDuration > 0 {
+		m.DurationRatio = m.Duration / m.TargetDuration
+	}
+	switch {
+	case m.TargetDuration <= 0:
+	case m.DurationRatio < 1-durationTolerance:
+		m.DurationVerdict = DurationShort
+	case m.DurationRatio > 1+durationTolerance:
+		m.DurationVerdict = DurationLong
+	default:
+		m.DurationVerdict = DurationOnTarget
+	}
+
+	levels := speechLevels(pcm, segments)
+	m.AverageLevelDB, m.LevelVariationDB = meanStddev(levels)
+	m.VolumeConsistency = volumeConsistency(m.LevelVariationDB)
+
+	m.AverageLevelDB = round1(m.AverageLevelDB)
+	m.LevelVariationDB = round1(m.LevelVariationDB)
+	m.Duration = round1(m.Duration)
+	m.SpeakingTime = round1(m.SpeakingTime)
+	m.DurationRatio = math.Round(m.DurationRatio*100) / 100
+	m.WordsPerMinute = math.Round(m.WordsPerMinute)
+	m.FillersPerMinute = round1(m.FillersPerMinute)
+	m.AveragePause = round1(m.AveragePause)
+	m.LongestPause = round1(m.LongestPause)
+
+	m.Observations = deliveryObservations(m, fade(levels))
+	return m
+}
+
+// transcriptWord is a lower-case word of a transcript, with whether a
+// break comes before or after it: punctuation, which providers put at
+// pauses and sentence ends, or the start or end of the answer
+type transcriptWord struct {
+	text        string
+	breakBefore bool
+	breakAfter  bool
+}
+
+// pauseMarks are the punctuation marks taken as a break in speech
+const pauseMarks = ",.;:!?…—"
+
+// transcriptWords splits a transcript into words, keeping apostrophes so
+// contractions stay whole
+func transcriptWords(transcript string) []transcriptWord {
+	transcript = strings.ReplaceAll(strings.ToLower(transcript), "’", "'")
+	var words []transcriptWord
+	var word strings.Builder
+	broken := true
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, transcriptWord{text: word.String(), breakBefore: broken})
+			word.Reset()
+			broken = false
+		}
+	}
+	for _, r := range transcript {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		if strings.ContainsRune(pauseMarks, r) {
+			broken = true
+			if len(words) > 0 {
+				words[len(words)-1].breakAfter = true
+			}
+		}
+	}
+	flush()
+	if len(words) > 0 {
+		words[len(words)-1].breakAfter = true
+	}
+	return words
+}
+
+// fillerSounds are hesitations, in the spellings providers use for them
+var fillerSounds = map[string]string{
+	"um": "um", "umm": "um", "ummm": "um", "erm": "um",
+	"uh": "uh", "uhh": "uh", "uhm": "uh",
+	"er": "er", "err": "er",
+	"ah": "ah", "ahh": "ah",
+	"hmm": "hmm", "hm": "hmm", "mm": "hmm", "mmm": "hmm",
+}
+
+// fillerWords are words that are fillers wherever they are said
+var fillerWords = map[string]bool{"basically": true, "literally": true}
+
+// isFiller reports whether a word is a filler wherever it is said
+func isFiller(word string) bool {
+	_, sound := fillerSounds[word]
+	return sound || fillerWords[word]
+}
+
+// likeAsVerb are words before "like" that make it a verb or a comparison,
+// as in "I'd like to" or "looks like", rather than a filler
+var likeAsVerb = map[string]bool{
+	"i": true, "you": true, "we": true, "they": true, "he": true, "she": true,
+	"i'd": true, "you'd": true, "we'd": true, "they'd": true, "would": true,
+	"do": true, "don't": true, "did": true, "didn't": true, "not": true,
+	"really": true, "also": true, "just": true, "feel": true, "feels": true,
+	"felt": true, "look": true, "looks": true, "looked": true, "sounds": true,
+	"seem": true, "seems": true, "something": true, "things": true,
+	"anything": true, "nothing": true, "more": true, "much": true,
+}
+
+// youKnowAsQuestion are words before "you know" that make it part of a
+// question or clause, as in "do you know" or "as you know"
+var youKnowAsQuestion = map[string]bool{
+	"do": true, "did": true, "don't": true, "didn't": true, "if": true,
+	"as": true, "would": true, "will": true, "can": true, "could": true,
+}
+
+// countFillers counts filler words and phrases. "like", "you know" and "I
+// mean" are also ordinary speech, so the words around them decide. "like"
+// is mostly a comparison, as in "tools like Terraform", so it only counts
+// at a break or next to another filler.
+func countFillers(words []transcriptWord) map[string]int {
+	counts := map[string]int{}
+	prev := func(i int) string {
+		if i > 0 {
+			return words[i-1].text
+		}
+		return ""
+	}
+	next := func(i int) string {
+		if i+1 < len(words) {
+			return words[i+1].text
+		}
+		return ""
+	}
+
+	for i, word := range words {
+		w := word.text
+		if sound, ok := fillerSounds[w]; ok {
+			counts[sound]++
+			continue
+		}
+		switch {
+		case fillerWords[w]:
+			counts[w]++
+		case w == "like" && !likeAsVerb[prev(i)] && next(i) != "to" &&
+			(word.breakBefore || word.breakAfter || isFiller(prev(i)) || isFiller(next(i))):
+			counts[w]++
+		case w == "you" && next(i) == "know" && !youKnowAsQuestion[prev(i)]:
+			counts["you know"]++
+		case w == "i" && next(i) == "mean" && prev(i) != "what":
+			counts["i mean"]++
+		}
+	}
+	return counts
+}
+
+// speechLevels measures the volume of each window of speech, leaving out
+// windows that are mostly silence
+func speechLevels(pcm []byte, segments []audio.Segment) []float64 {
+	var levels []float64
+	for _, s := range segments {
+		if !s.Speech {
+			continue
+		}
+		span := pcm[audio.Offset(s.Start):min(audio.Offset(s.End), len(pcm))]
+		for _, level := range audio.Levels(span, levelWindow) {
+			if level > levelFloorDB {
+				levels = append(levels, level)
+			}
+		}
+	}
+	return levels
+}
+
+func meanStddev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// volumeConsistency scores level variation from 100, as steady as speech
+// gets, down to 0
+func volumeConsistency(variationDB float64) int {
+	score := 100 * (unsteadyVariationDB - variationDB) / (unsteadyVariationDB - steadyVariationDB)
+	return int(math.Round(math.Max(0, math.Min(100, score))))
+}
+
+// fade returns how many dB quieter the last third of the speech was than
+// the first
+func fade(levels []float64) float64 {
+	third := len(levels) / 3
+	if third == 0 {
+		return 0
+	}
+	start, _ := meanStddev(levels[:third])
+	end, _ := meanStddev(levels[len(levels)-third:])
+	return start - end
+}
+
+// deliveryObservations turns whatever metrics are off into advice
+func deliveryObservations(m *models.DeliveryMetrics, fadeDBs float64) []string {
+	notes := []string{}
+	switch m.PaceVerdict {
+	case PaceFast:
+		notes = append(notes, fmt.Sprintf("Speaking at %.0f words per minute is fast; aim for %d-%d so each point lands", m.WordsPerMinute, paceSlowWPM+10, paceFastWPM-10))
+	case PaceSlow:
+		notes = append(notes, fmt.Sprintf("Speaking at %.0f words per minute is slow; aim for %d-%d to keep the listener engaged", m.WordsPerMinute, paceSlowWPM+10, paceFastWPM-10))
+	}
+
+	if m.FillersPerMinute >= fillersPerMinuteHigh {
+		notes = append(notes, fmt.Sprintf("%d filler words (%.1f per minute), mostly %q; pause silently instead", m.FillerCount, m.FillersPerMinute, topFiller(m.Fillers)))
+	}
+
+	switch {
+	case m.LongPauseCount == 1:
+		notes = append(notes, fmt.Sprintf("A pause of %.1fs; bridge with a short summary while you think", m.LongestPause))
+	case m.LongPauseCount > 1:
+		notes = append(notes, fmt.Sprintf("%d pauses of %.0f seconds or more, the longest %.1fs; bridge with a short summary while you think", m.LongPauseCount, longPause, m.LongestPause))
+	}
+
+	switch m.DurationVerdict {
+	case DurationShort:
+		notes = append(notes, fmt.Sprintf("The answer ran %s against a %s target; add a concrete example or the outcome", clockTime(m.Duration), clockTime(m.TargetDuration)))
+	case DurationLong:
+		notes = append(notes, fmt.Sprintf("The answer ran %s against a %s target; tighten it to the key points", clockTime(m.Duration), clockTime(m.TargetDuration)))
+	}
+
+	if m.LevelVariationDB > (steadyVariationDB+unsteadyVariationDB)/2 {
+		notes = append(notes, fmt.Sprintf("Volume varied by %.1f dB between phrases; keep a steady distance from the microphone", m.LevelVariationDB))
+	}
+	if fadeDBs >= fadeDB {
+		notes = append(notes, fmt.Sprintf("Volume dropped %.0f dB towards the end; finish as strongly as you started", fadeDBs))
+	}
+	return notes
+}
+
+// topFiller returns the most used filler, the first alphabetically on a tie
+func topFiller(fillers map[string]int) string {
+	names := make([]string, 0, len(fillers))
+	for name := range fillers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if fillers[names[i]] != fillers[names[j]] {
+			return fillers[names[i]] > fillers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// clockTime formats seconds as M:SS
+func clockTime(seconds float64) string {
+	s := int(math.Round(seconds))
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// DescribeDelivery summarizes the metrics for the feedback prompt
+func DescribeDelivery(m *models.DeliveryMetrics) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- Length: %s against a %s target (%s)\n", clockTime(m.Duration), clockTime(m.TargetDuration), m.DurationVerdict)
+	fmt.Fprintf(&b, "- Pace: %.0f words per minute (%s)\n", m.WordsPerMinute, m.PaceVerdict)
+	fmt.Fprintf(&b, "- Filler words: %d (%.1f per minute)\n", m.FillerCount, m.FillersPerMinute)
+	fmt.Fprintf(&b, "- Pauses: %d of %.1fs or more, %d of %.0fs or more, longest %.1fs\n", m.PauseCount, minPause, m.LongPauseCount, longPause, m.LongestPause)
+	fmt.Fprintf(&b, "- Volume consistency: %d/100", m.VolumeConsistency)
+	return b.String()
+}
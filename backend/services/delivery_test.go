@@ -0,0 +1,53 @@
+package services
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestCountFillers(t *testing.T) {
+	tests := []struct {
+		transcript string
+		want       map[string]int
+	}{
+		{"I've worked with tools like Terraform and Pulumi.", map[string]int{}},
+		{"It was, like, fine.", map[string]int{"like": 1}},
+		{"Like, we shipped it weekly.", map[string]int{"like": 1}},
+		{"We had, um like three services.", map[string]int{"um": 1, "like": 1}},
+		{"I'd like to talk about scaling.", map[string]int{}},
+		{"It looks like a queue, like.", map[string]int{"like": 1}},
+		{"It's, you know, a monolith.", map[string]int{"you know": 1}},
+		{"Do you know Kubernetes?", map[string]int{}},
+		{"I mean, the tests were flaky.", map[string]int{"i mean": 1}},
+		{"That's what I mean by ownership.", map[string]int{}},
+		{"Umm, uhh, Erm... hmm.", map[string]int{"um": 2, "uh": 1, "hmm": 1}},
+		{"It basically, literally halved latency.", map[string]int{"basically": 1, "literally": 1}},
+		{"", map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transcript, func(t *testing.T) {
+			if got := countFillers(transcriptWords(tt.transcript)); !maps.Equal(got, tt.want) {
+				t.Fatalf("countFillers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscriptWords(t *testing.T) {
+	words := transcriptWords("Well, I’d say… it WORKED")
+	want := []transcriptWord{
+		{"well", true, true},
+		{"i'd", true, false},
+		{"say", false, true},
+		{"it", true, false},
+		{"worked", false, true},
+	}
+	if len(words) != len(want) {
+		t.Fatalf("transcriptWords = %+v, want %+v", words, want)
+	}
+	for i := range want {
+		if words[i] != want[i] {
+			t.Fatalf("transcriptWords = %+v, want %+v", words, want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"nexus-ai/audio"
+	"nexus-ai/models"
+)
+
+// How speakers were told apart
+const (
+	DiarizeSpeakerLabels = "speaker_labels"
+	DiarizeChannels      = "channels"
+)
+
+// Diarization modes a caller can ask for. Auto uses the channels of a
+// two-track recording and otherwise the provider's speaker labels.
+const (
+	DiarizeAuto   = "auto"
+	DiarizeStereo = "stereo"
+	DiarizeMono   = "mono"
+)
+
+// ErrNoSpeakerLabels is returned for single-track audio when no configured
+// provider can label speakers
+var ErrNoSpeakerLabels = errors.New("no configured transcription provider labels speakers; upload a two-track recording with one speaker per channel, or configure aws or deepgram")
+
+// ErrNotSeparated is returned in stereo mode when both channels carry the
+// same speakers
+var ErrNotSeparated = errors.New("recording does not have one speaker per channel")
+
+// SpeakerTranscriber is a Transcriber that can also label who spoke each
+// part of a single-track recording
+type SpeakerTranscriber interface {
+	Transcriber
+	TranscribeSpeakers(ctx context.Context, pcm []byte, opts TranscribeOptions) ([]models.SpeakerSegment, error)
+}
+
+// Diarization is a recording transcribed into speaker turns, in order
+type Diarization struct {
+	Segments []models.SpeakerSegment
+	Provider string
+	Method   string
+	Duration float64 // seconds
+	Warnings []string
+}
+
+// Diarize transcribes a recording into speaker turns. A two-track recording
+// is split into its channels, each transcribed on its own; anything else
+// goes to the first configured provider that labels speakers.
+func (c *TranscriberChain) Diarize(ctx context.Context, data []byte, mode string) (*Diarization, error) {
+	if len(c.Configured()) == 0 {
+		return nil, ErrNoTranscriber
+	}
+
+	stereo, err := decodePCM(ctx, data, 2)
+	if err != nil {
+		return nil, fmt.Errorf("conversion failed: %w", err)
+	}
+	left, right := audio.Deinterleave(stereo)
+	if len(left) < pcmSampleRate/10*2 { // 100ms
+		return nil, fmt.Errorf("no audio after conversion")
+	}
+
+	vad := c.vad
+	if vad == nil {
+		vad = audio.NewVAD(audio.DefaultVADConfig())
+	}
+
+	separated := mode != DiarizeMono && vad.Separated(left, right)
+	if mode == DiarizeStereo && !separated {
+		return nil, ErrNotSeparated
+	}
+
+	var result *Diarization
+	var warnings []string
+	if separated {
+		// Each track has its own microphone, so is preprocessed on its own
+		leftPre, rightPre := c.newPreprocessor(), c.newPreprocessor()
+		left, right = leftPre.Process(left), rightPre.Process(right)
+		warnings = leftPre.Warnings()
+		for _, w := range rightPre.Warnings() {
+			if !slices.Contains(warnings, w) {
+				warnings = append(warnings, w)
+			}
+		}
+		result, err = c.diarizeChannels(ctx, vad, left, right)
+	} else {
+		pre := c.newPreprocessor()
+		mixed := pre.Process(audio.Mix(left, right))
+		warnings = pre.Warnings()
+		result, err = c.diarizeSpeakers(ctx, mixed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = audio.Seconds(left)
+	result.Warnings = warnings
+	result.Segments = mergeTurns(result.Segments)
+	return result, nil
+}
+
+// diarizeChannels transcribes each utterance of each channel separately, so
+// turns from the two tracks interleave in time
+func (c *TranscriberChain) diarizeChannels(ctx context.Context, vad *audio.VAD, channels ...[]byte) (*Diarization, error) {
+	result := &Diarization{Method: DiarizeChannels}
+	for i, pcm := range channels {
+		speaker := fmt.Sprintf("channel_%d", i)
+		for _, s := range vad.Segments(pcm) {
+			if !s.Speech {
+				continue
+			}
+			for _, chunk := range audio.Split(pcm, []audio.Segment{s}, c.maxChunk) {
+				part, err := c.TranscribePCM(ctx, chunk.PCM)
+				if err != nil {
+					return nil, err
+				}
+				result.Provider = part.Provider
+				if part.Text == "" {
+					continue
+				}
+				result.Segments = append(result.Segments, models.SpeakerSegment{
+					Speaker: speaker,
+					Text:    part.Text,
+					Start:   chunk.Start,
+					End:     chunk.Start + audio.Seconds(chunk.PCM),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result.Segments, func(i, j int) bool {
+		return result.Segments[i].Start < result.Segments[j].Start
+	})
+	return result, nil
+}
+
+// diarizeSpeakers hands single-track audio to each configured provider that
+// labels speakers until one succeeds. The whole recording goes in one call,
+// so the timeout grows with its length.
+func (c *TranscriberChain) diarizeSpeakers(ctx context.Context, pcm []byte) (*Diarization, error) {
+	timeout := c.timeout + time.Duration(audio.Seconds(pcm)*float64(time.Second))
+
+	var errs []string
+	for _, t := range c.providers {
+		st, ok := t.(SpeakerTranscriber)
+		if !ok || !t.IsConfigured() {
+			continue
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		segments, err := st.TranscribeSpeakers(tctx, pcm, c.opts)
+		cancel()
+		if err != nil {
+			fmt.Printf("[DIARIZE] %s failed after %v: %v\n", t.Name(), time.Since(start).Round(time.Millisecond), err)
+			errs = append(errs, t.Name()+": "+err.Error())
+			continue
+		}
+
+		for i := range segments {
+			segments[i].Text = c.corrector.Correct(segments[i].Text)
+		}
+		fmt.Printf("[DIARIZE] %s: %d segments in %v\n", t.Name(), len(segments), time.Since(start).Round(time.Millisecond))
+		return &Diarization{Segments: segments, Provider: t.Name(), Method: DiarizeSpeakerLabels}, nil
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoSpeakerLabels
+	}
+	return nil, fmt.Errorf("all speaker labelling providers failed: %s", strings.Join(errs, "; "))
+}
+
+// mergeTurns joins consecutive segments from the same speaker into one turn
+func mergeTurns(segments []models.SpeakerSegment) []models.SpeakerSegment {
+	turns := []models.SpeakerSegment{}
+	for _, s := range segments {
+		if n := len(turns); n > 0 && turns[n-1].Speaker == s.Speaker {
+			turns[n-1].Text += " " + s.Text
+			turns[n-1].End = s.End
+			continue
+		}
+		turns = append(turns, s)
+	}
+	return turns
+}
+
+// AssignRoles sets each segment's role and returns the interviewer labels.
+// When interviewers is empty, the speaker who asks the most questions per
+// turn is taken as the interviewer, and on a tie whoever spoke first, as
+// interviewers open. Every other speaker is a candidate.
+func AssignRoles(segments []models.SpeakerSegment, interviewers []string) []string {
+	if len(interviewers) == 0 {
+		interviewers = []string{}
+		if best := questioner(segments); best != "" {
+			interviewers = append(interviewers, best)
+		}
+	}
+
+	isInterviewer := map[string]bool{}
+	for _, label := range interviewers {
+		isInterviewer[label] = true
+	}
+	for i := range segments {
+		segments[i].Role = models.MessageRoleCandidate
+		if isInterviewer[segments[i].Speaker] {
+			segments[i].Role = models.MessageRoleInterviewer
+		}
+	}
+	return interviewers
+}
+
+// questioner returns the speaker with the highest share of turns that ask a
+// question, preferring the earliest speaker on a tie
+func questioner(segments []models.SpeakerSegment) string {
+	type tally struct{ turns, questions int }
+	tallies := map[string]*tally{}
+	var order []string
+	for _, s := range segments {
+		t, ok := tallies[s.Speaker]
+		if !ok {
+			t = &tally{}
+			tallies[s.Speaker] = t
+			order = append(order, s.Speaker)
+		}
+		t.turns++
+		if strings.Contains(s.Text, "?") {
+			t.questions++
+		}
+	}
+
+	best, bestRatio := "", -1.0
+	for _, speaker := range order {
+		t := tallies[speaker]
+		if ratio := float64(t.questions) / float64(t.turns); ratio > bestRatio {
+			best, bestRatio = speaker, ratio
+		}
+	}
+	return best
+}
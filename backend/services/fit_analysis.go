@@ -0,0 +1,379 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"nexus-ai/models"
+)
+
+// Requirement categories in a fit report
+const (
+	RequirementRequired       = "required"
+	RequirementNiceToHave     = "nice_to_have"
+	RequirementResponsibility = "responsibility"
+)
+
+// Coverage thresholds for a requirement's status
+const (
+	fitMetThreshold     = 0.75
+	fitPartialThreshold = 0.35
+)
+
+// maxEvidencePerRequirement keeps the matrix readable for broad requirements
+const maxEvidencePerRequirement = 5
+
+// profileSnippet is a searchable piece of a profile
+type profileSnippet struct {
+	kind   string
+	source string
+	text   string
+	terms  map[string]bool
+}
+
+// AnalyzeFit builds a requirement-by-requirement evidence matrix for a
+// profile against a job description. It is fully deterministic so the same
+// inputs always produce the same report.
+func AnalyzeFit(profile *models.UserProfile, jd *models.JobDescription) *models.FitReport {
+	report := &models.FitReport{
+		Role:            jd.Role,
+		Company:         jd.Company,
+		Requirements:    []models.RequirementMatch{},
+		MissingKeywords: []string{},
+		Gaps:            []models.FitGap{},
+	}
+
+	snippets := profileSnippets(profile)
+
+	categories := []struct {
+		name  string
+		items []string
+	}{
+		{RequirementRequired, jd.RequiredSkills},
+		{RequirementResponsibility, jd.Responsibilities},
+		{RequirementNiceToHave, jd.NiceToHaveSkills},
+	}
+
+	coverage := map[string][]float64{}
+	for _, cat := range categories {
+		for _, req := range cat.items {
+			match := matchRequirement(req, cat.name, snippets)
+			if match == nil {
+				continue
+			}
+			report.Requirements = append(report.Requirements, *match)
+			coverage[cat.name] = append(coverage[cat.name], match.Coverage)
+		}
+	}
+
+	report.RequiredCoverage = average(coverage[RequirementRequired])
+	report.NiceToHaveCoverage = average(coverage[RequirementNiceToHave])
+	report.ResponsibilityCoverage = average(coverage[RequirementResponsibility])
+
+	// Weighted score, re-normalized over the categories the JD actually has
+	weights := map[string]float64{
+		RequirementRequired:       0.7,
+		RequirementResponsibility: 0.2,
+		RequirementNiceToHave:     0.1,
+	}
+	var total, weightSum float64
+	for cat, w := range weights {
+		if len(coverage[cat]) == 0 {
+			continue
+		}
+		total += w * average(coverage[cat])
+		weightSum += w
+	}
+	if weightSum > 0 {
+		report.OverallScore = round2(total / weightSum * 100)
+	}
+
+	report.MissingKeywords = missingKeywords(profile, jd, snippets)
+	report.Gaps = rankGaps(report.Requirements)
+
+	return report
+}
+
+// profileSnippets flattens a profile into individually searchable snippets
+func profileSnippets(profile *models.UserProfile) []profileSnippet {
+	snippets := []profileSnippet{}
+	add := func(kind, source, text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		snippets = append(snippets, profileSnippet{
+			kind:   kind,
+			source: source,
+			text:   text,
+			terms:  termSet(text),
+		})
+	}
+
+	for _, skill := range profile.Skills {
+		add("skill", "", skill)
+	}
+	for _, exp := range profile.Experience {
+		source := experienceHeading(exp)
+		add("experience", source, exp.Title+" "+exp.Description)
+		for _, a := range exp.Achievements {
+			add("experience", source, a)
+		}
+	}
+	for _, proj := range profile.Projects {
+		add("project", proj.Name, proj.Name+": "+proj.Description+" "+strings.Join(proj.Technologies, " "))
+	}
+	for _, a := range profile.Achievements {
+		add("achievement", "", a)
+	}
+
+	return snippets
+}
+
+// requirementTerms returns the distinct meaningful terms in a requirement,
+// dropping bare numbers such as the "5+" in "5+ years of Go". Skill aliases
+// are rewritten to canonical names first so "k8s" matches "Kubernetes".
+func requirementTerms(text string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, tok := range tokenize(DefaultSkillTaxonomy().ReplaceAliases(text)) {
+		if len(tok) < 2 || stopWords[tok] || !hasLetter(tok) {
+			continue
+		}
+		tok = stem(tok)
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		terms = append(terms, tok)
+	}
+	return terms
+}
+
+// stem strips common English inflections so "pipelines" matches "pipeline"
+// and "deployed" matches "deploying". Tech names with punctuation are kept.
+func stem(tok string) string {
+	if len(tok) <= 4 || strings.ContainsAny(tok, "+#./-") {
+		return tok
+	}
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(tok, suffix) && len(tok)-len(suffix) >= 4 {
+			return strings.TrimSuffix(tok, suffix)
+		}
+	}
+	return tok
+}
+
+func termSet(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, t := range requirementTerms(text) {
+		set[t] = true
+	}
+	return set
+}
+
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRequirement scores one requirement against the profile snippets
+func matchRequirement(requirement, category string, snippets []profileSnippet) *models.RequirementMatch {
+	terms := requirementTerms(requirement)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	covered := map[string]bool{}
+	type scored struct {
+		evidence models.FitEvidence
+		score    int
+	}
+	candidates := []scored{}
+
+	for _, snip := range snippets {
+		matched := []string{}
+		for _, t := range terms {
+			if snip.terms[t] {
+				matched = append(matched, t)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		for _, t := range matched {
+			covered[t] = true
+		}
+		score := len(matched) * 2
+		if snip.kind == "skill" {
+			score++ // an explicit skill is the strongest single signal
+		}
+		candidates = append(candidates, scored{
+			evidence: models.FitEvidence{
+				Kind:         snip.kind,
+				Source:       snip.source,
+				Text:         snip.text,
+				MatchedTerms: matched,
+			},
+			score: score,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	match := &models.RequirementMatch{
+		Requirement: requirement,
+		Category:    category,
+		Evidence:    []models.FitEvidence{},
+	}
+	for i, c := range candidates {
+		if i >= maxEvidencePerRequirement {
+			break
+		}
+		match.Evidence = append(match.Evidence, c.evidence)
+	}
+	for _, t := range terms {
+		if !covered[t] {
+			match.MissingTerms = append(match.MissingTerms, t)
+		}
+	}
+
+	match.Coverage = round2(float64(len(covered)) / float64(len(terms)))
+	switch {
+	case match.Coverage >= fitMetThreshold:
+		match.Status = "met"
+	case match.Coverage >= fitPartialThreshold:
+		match.Status = "partial"
+	default:
+		match.Status = "missing"
+	}
+
+	return match
+}
+
+// missingKeywords lists JD terms an ATS would look for that appear nowhere in
+// the profile. Skill terms come first, then terms repeated in the JD body.
+func missingKeywords(profile *models.UserProfile, jd *models.JobDescription, snippets []profileSnippet) []string {
+	present := map[string]bool{}
+	for _, snip := range snippets {
+		for t := range snip.terms {
+			present[t] = true
+		}
+	}
+	for t := range termSet(profile.Summary) {
+		present[t] = true
+	}
+
+	seen := map[string]bool{}
+	missing := []string{}
+	add := func(term string) {
+		if present[term] || seen[term] {
+			return
+		}
+		seen[term] = true
+		missing = append(missing, term)
+	}
+
+	for _, group := range [][]string{jd.RequiredSkills, jd.NiceToHaveSkills} {
+		for _, skill := range group {
+			for _, t := range requirementTerms(skill) {
+				add(t)
+			}
+		}
+	}
+
+	type freq struct {
+		term  string
+		count int
+	}
+	repeated := []freq{}
+	for term, count := range extractKeywords(jd.RawText) {
+		if count >= 2 && hasLetter(term) {
+			repeated = append(repeated, freq{stem(term), count})
+		}
+	}
+	sort.Slice(repeated, func(i, j int) bool {
+		if repeated[i].count != repeated[j].count {
+			return repeated[i].count > repeated[j].count
+		}
+		return repeated[i].term < repeated[j].term
+	})
+	for _, f := range repeated {
+		add(f.term)
+	}
+
+	return missing
+}
+
+// rankGaps orders unmet requirements by what to study first: required
+// before responsibilities before nice-to-haves, least covered first
+func rankGaps(matches []models.RequirementMatch) []models.FitGap {
+	priority := map[string]int{
+		RequirementRequired:       0,
+		RequirementResponsibility: 1,
+		RequirementNiceToHave:     2,
+	}
+
+	unmet := []models.RequirementMatch{}
+	for _, m := range matches {
+		if m.Status != "met" {
+			unmet = append(unmet, m)
+		}
+	}
+	sort.SliceStable(unmet, func(i, j int) bool {
+		if priority[unmet[i].Category] != priority[unmet[j].Category] {
+			return priority[unmet[i].Category] < priority[unmet[j].Category]
+		}
+		return unmet[i].Coverage < unmet[j].Coverage
+	})
+
+	gaps := make([]models.FitGap, 0, len(unmet))
+	for _, m := range unmet {
+		gaps = append(gaps, models.FitGap{
+			Requirement: m.Requirement,
+			Category:    m.Category,
+			Coverage:    m.Coverage,
+			Suggestion:  gapSuggestion(m),
+		})
+	}
+	return gaps
+}
+
+func gapSuggestion(m models.RequirementMatch) string {
+	missing := strings.Join(m.MissingTerms, ", ")
+	switch {
+	case m.Status == "partial" && m.Category == RequirementResponsibility:
+		return fmt.Sprintf("Prepare a concrete story that shows this responsibility end to end; your profile does not yet mention %s", missing)
+	case m.Status == "partial":
+		return fmt.Sprintf("You have related experience; review %s and be ready to connect it to your past work", missing)
+	case m.Category == RequirementResponsibility:
+		return "No matching experience found; prepare how you would approach this and any adjacent work you have done"
+	case m.Category == RequirementNiceToHave:
+		return fmt.Sprintf("Optional: learn the basics of %s so you can discuss it if asked", missing)
+	default:
+		return fmt.Sprintf("Study %s before the interview and prepare an honest answer about your exposure", missing)
+	}
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return round2(sum / float64(len(values)))
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
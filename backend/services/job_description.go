@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"nexus-ai/models"
+
+	"golang.org/x/net/html"
+)
+
+type JobDescriptionParser struct {
+	client *AnthropicClient
+	model  string
+}
+
+func NewJobDescriptionParser() *JobDescriptionParser {
+	return &JobDescriptionParser{
+		client: NewAnthropicClient(),
+		model:  "claude-3-5-haiku-20241022",
+	}
+}
+
+// ExtractText extracts JD text from an uploaded PDF, HTML page or text file.
+// It returns the detected source kind alongside the text.
+func (p *JobDescriptionParser) ExtractText(content []byte, filename string) (string, string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch ext {
+	case ".pdf":
+		text, err := NewResumeParser().ExtractTextFromPDF(content)
+		return text, "pdf", err
+	case ".html", ".htm":
+		text, err := ExtractTextFromHTML(content)
+		return text, "html", err
+	case ".txt", ".md":
+		return string(content), "text", nil
+	default:
+		return "", "", fmt.Errorf("unsupported file type: %s", ext)
+	}
+}
+
+// ExtractTextFromHTML returns the visible text of a saved web page, keeping
+// block elements and list items on their own lines
+func ExtractTextFromHTML(content []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("HTML parse error: %w", err)
+	}
+
+	skip := map[string]bool{
+		"script": true, "style": true, "noscript": true, "nav": true,
+		"header": true, "footer": true, "svg": true, "form": true, "head": true,
+	}
+	block := map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "br": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"ul": true, "ol": true, "tr": true, "li": true,
+	}
+
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && skip[n.Data] {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "li" {
+			b.WriteString("\n- ")
+		} else if n.Type == html.ElementNode && block[n.Data] {
+			b.WriteString("\n")
+		}
+		if n.Type == html.TextNode {
+			b.WriteString(strings.Join(strings.Fields(n.Data), " "))
+			b.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode && block[n.Data] {
+			b.WriteString("\n")
+		}
+	}
+	walk(doc)
+
+	lines := []string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "-" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Parse uses Claude to structure a job description. If the API call fails
+// the heuristic parser is used so the JD is still usable.
+func (p *JobDescriptionParser) Parse(text string) (*models.JobDescription, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("job description is empty")
+	}
+
+	systemPrompt := `You are an expert technical recruiter. Extract structured information from the job description.
+
+Return a JSON object with these fields:
+- role: The job title
+- company: The hiring company (empty string if not stated)
+- seniority: One of intern, junior, mid, senior, staff, principal, lead, manager (best guess)
+- required_skills: Array of short skill or technology names that are required
+- nice_to_have_skills: Array of short skill or technology names listed as preferred or a plus
+- responsibilities: Array of the main responsibilities, one sentence each
+
+Use short canonical names for skills (e.g. "Kubernetes", "Terraform", "Python"), not full sentences.`
+
+	jd := truncateUTF8(text, 12000)
+
+	resp, err := p.client.CreateMessage(MessageRequest{
+		Model:     p.model,
+		MaxTokens: 2000,
+		System:    systemPrompt,
+		Messages: []MessageInput{
+			{Role: "user", Content: fmt.Sprintf("Parse this job description:\n\n%s", jd)},
+		},
+		Purpose: "job_description_parse",
+	})
+	if err != nil {
+		fmt.Printf("[JD] Claude parse failed, using heuristics: %v\n", err)
+		return ParseJobDescriptionHeuristic(text), nil
+	}
+
+	responseText := resp.GetText()
+	result := &models.JobDescription{
+		RequiredSkills:   []string{},
+		NiceToHaveSkills: []string{},
+		Responsibilities: []string{},
+		RawText:          text,
+	}
+
+	start := strings.Index(responseText, "{")
+	end := strings.LastIndex(responseText, "}") + 1
+	if start == -1 || end <= start {
+		return ParseJobDescriptionHeuristic(text), nil
+	}
+
+	var parsed struct {
+		Role             string   `json:"role"`
+		Company          string   `json:"company"`
+		Seniority        string   `json:"seniority"`
+		RequiredSkills   []string `json:"required_skills"`
+		NiceToHaveSkills []string `json:"nice_to_have_skills"`
+		Responsibilities []string `json:"responsibilities"`
+	}
+	if err := json.Unmarshal([]byte(responseText[start:end]), &parsed); err != nil {
+		return ParseJobDescriptionHeuristic(text), nil
+	}
+
+	result.Role = parsed.Role
+	result.Company = parsed.Company
+	result.Seniority = strings.ToLower(parsed.Seniority)
+	if parsed.RequiredSkills != nil {
+		result.RequiredSkills = parsed.RequiredSkills
+	}
+	if parsed.NiceToHaveSkills != nil {
+		result.NiceToHaveSkills = parsed.NiceToHaveSkills
+	}
+	if parsed.Responsibilities != nil {
+		result.Responsibilities = parsed.Responsibilities
+	}
+
+	return result, nil
+}
+
+var (
+	requiredHeading = regexp.MustCompile(`(?i)^(requirements|qualifications|required|must[- ]haves?|what you('ll| will) (need|bring)|who you are|minimum qualifications|basic qualifications|skills)\b`)
+	niceHeading     = regexp.MustCompile(`(?i)^(nice[- ]to[- ]haves?|preferred( qualifications)?|bonus( points)?|pluses|it('s| is) a plus|desired)\b`)
+	respHeading     = regexp.MustCompile(`(?i)^(responsibilities|what you('ll| will) do|duties|the role|your role|key responsibilities|in this role)\b`)
+	otherHeading    = regexp.MustCompile(`(?i)^(about (us|the company|the team)|benefits|perks|compensation|salary|how to apply|equal opportunity)\b`)
+	titleLine       = regexp.MustCompile(`(?i)^(job title|title|position|role)\s*[:\-]\s*(.+)$`)
+	companyLine     = regexp.MustCompile(`(?i)^(company|employer|organization)\s*[:\-]\s*(.+)$`)
+	aboutCompany    = regexp.MustCompile(`(?i)^about\s+([A-Z][\w&.\- ]{1,40})$`)
+	seniorityWord   = regexp.MustCompile(`(?i)\b(intern|junior|jr\.?|mid[- ]level|senior|sr\.?|staff|principal|lead|head of|manager)\b`)
+	yearsRequired   = regexp.MustCompile(`(?i)(\d+)\+?\s*(?:-\s*\d+\s*)?years?`)
+	bulletPrefix    = regexp.MustCompile(`^\s*([-*•·▪◦‣]|\d+[.)])\s+`)
+)
+
+// ParseJobDescriptionHeuristic structures a JD without an LLM call by
+// splitting it into the usual posting sections. It is used for raw JD text
+// on the live path, where latency matters more than precision.
+func ParseJobDescriptionHeuristic(text string) *models.JobDescription {
+	jd := &models.JobDescription{
+		RequiredSkills:   []string{},
+		NiceToHaveSkills: []string{},
+		Responsibilities: []string{},
+		RawText:          text,
+	}
+
+	const (
+		sectionNone = iota
+		sectionRequired
+		sectionNice
+		sectionResp
+		sectionOther
+	)
+	section := sectionNone
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		heading := strings.TrimRight(line, ":")
+
+		if m := titleLine.FindStringSubmatch(line); m != nil && jd.Role == "" {
+			jd.Role = strings.TrimSpace(m[2])
+			continue
+		}
+		if m := companyLine.FindStringSubmatch(line); m != nil && jd.Company == "" {
+			jd.Company = strings.TrimSpace(m[2])
+			continue
+		}
+
+		isBullet := bulletPrefix.MatchString(line)
+		if !isBullet && len(heading) < 60 {
+			switch {
+			case niceHeading.MatchString(heading):
+				section = sectionNice
+				continue
+			case requiredHeading.MatchString(heading):
+				section = sectionRequired
+				continue
+			case respHeading.MatchString(heading):
+				section = sectionResp
+				continue
+			case otherHeading.MatchString(heading):
+				if m := aboutCompany.FindStringSubmatch(heading); m != nil && jd.Company == "" &&
+					!strings.EqualFold(m[1], "us") && !strings.HasPrefix(strings.ToLower(m[1]), "the ") {
+					jd.Company = strings.TrimSpace(m[1])
+				}
+				section = sectionOther
+				continue
+			}
+			if m := aboutCompany.FindStringSubmatch(heading); m != nil {
+				if jd.Company == "" {
+					jd.Company = strings.TrimSpace(m[1])
+				}
+				section = sectionOther
+				continue
+			}
+		}
+
+		// First short non-bullet line is usually the job title
+		if jd.Role == "" && section == sectionNone && !isBullet && len(line) < 80 {
+			jd.Role = line
+			continue
+		}
+
+		item := strings.TrimSpace(bulletPrefix.ReplaceAllString(line, ""))
+		if item == "" {
+			continue
+		}
+		switch section {
+		case sectionRequired:
+			jd.RequiredSkills = append(jd.RequiredSkills, item)
+		case sectionNice:
+			jd.NiceToHaveSkills = append(jd.NiceToHaveSkills, item)
+		case sectionResp:
+			jd.Responsibilities = append(jd.Responsibilities, item)
+		}
+	}
+
+	jd.Seniority = detectSeniority(jd.Role, text)
+
+	return jd
+}
+
+// detectSeniority infers seniority from the title first, then from the
+// years of experience asked for in the body
+func detectSeniority(role, text string) string {
+	if m := seniorityWord.FindString(role); m != "" {
+		return normalizeSeniority(m)
+	}
+
+	if m := yearsRequired.FindStringSubmatch(text); m != nil {
+		years := 0
+		fmt.Sscanf(m[1], "%d", &years)
+		switch {
+		case years >= 8:
+			return "staff"
+		case years >= 5:
+			return "senior"
+		case years >= 2:
+			return "mid"
+		case years >= 0:
+			return "junior"
+		}
+	}
+
+	if m := seniorityWord.FindString(text); m != "" {
+		return normalizeSeniority(m)
+	}
+	return ""
+}
+
+func normalizeSeniority(word string) string {
+	w := strings.ToLower(strings.TrimSuffix(word, "."))
+	switch {
+	case w == "jr":
+		return "junior"
+	case w == "sr":
+		return "senior"
+	case strings.HasPrefix(w, "mid"):
+		return "mid"
+	case w == "head of":
+		return "lead"
+	default:
+		return w
+	}
+}
+
+// FormatJobDescription renders a structured JD as a compact prompt section
+func FormatJobDescription(jd *models.JobDescription) string {
+	if jd == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	if jd.Role != "" {
+		fmt.Fprintf(&b, "Role: %s", jd.Role)
+		if jd.Company != "" {
+			fmt.Fprintf(&b, " at %s", jd.Company)
+		}
+		b.WriteString("\n")
+	} else if jd.Company != "" {
+		fmt.Fprintf(&b, "Company: %s\n", jd.Company)
+	}
+	if jd.Seniority != "" {
+		fmt.Fprintf(&b, "Seniority: %s\n", jd.Seniority)
+	}
+	if len(jd.RequiredSkills) > 0 {
+		fmt.Fprintf(&b, "Must have: %s\n", joinLimited(jd.RequiredSkills, 12))
+	}
+	if len(jd.NiceToHaveSkills) > 0 {
+		fmt.Fprintf(&b, "Nice to have: %s\n", joinLimited(jd.NiceToHaveSkills, 8))
+	}
+	if len(jd.Responsibilities) > 0 {
+		b.WriteString("Key responsibilities:\n")
+		for i, r := range jd.Responsibilities {
+			if i >= 5 {
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", truncateUTF8(r, 160))
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func joinLimited(items []string, limit int) string {
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return strings.Join(items, ", ")
+}
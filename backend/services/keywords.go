@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are dropped from keyword extraction. The list is tuned for job
+// descriptions and resume bullets rather than general English.
+var stopWords = map[string]bool{
+	"a": true, "about": true, "across": true, "after": true, "all": true, "also": true,
+	"an": true, "and": true, "any": true, "are": true, "as": true, "at": true,
+	"be": true, "been": true, "being": true, "both": true, "but": true, "by": true,
+	"can": true, "candidate": true, "company": true, "do": true, "etc": true,
+	"every": true, "experience": true, "for": true, "from": true, "good": true,
+	"great": true, "has": true, "have": true, "help": true, "if": true, "in": true,
+	"including": true, "into": true, "is": true, "it": true, "its": true,
+	"job": true, "join": true, "knowledge": true, "like": true, "looking": true,
+	"more": true, "must": true, "new": true, "of": true, "on": true, "or": true,
+	"our": true, "over": true, "plus": true, "preferred": true, "required": true,
+	"requirements": true, "role": true, "should": true, "skills": true,
+	"strong": true, "such": true, "team": true, "that": true, "the": true,
+	"their": true, "them": true, "this": true, "to": true, "understanding": true,
+	"using": true, "we": true, "well": true, "what": true, "will": true,
+	"with": true, "within": true, "work": true, "working": true, "years": true,
+	"you": true, "your": true,
+}
+
+// tokenize splits text into lowercase terms, keeping characters that are
+// meaningful in tech names (c++, c#, node.js, ci/cd)
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+		switch r {
+		case '+', '#', '.', '/', '-':
+			return false
+		}
+		return true
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.Trim(f, ".-/")
+		if f != "" {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// extractKeywords returns term frequencies for the meaningful words in text
+func extractKeywords(text string) map[string]int {
+	keywords := make(map[string]int)
+	for _, tok := range tokenize(text) {
+		if len(tok) < 2 || stopWords[tok] {
+			continue
+		}
+		keywords[tok]++
+	}
+	return keywords
+}
+
+// keywordScore counts how many distinct keywords appear in text
+func keywordScore(text string, keywords map[string]int) int {
+	if len(keywords) == 0 {
+		return 0
+	}
+	seen := make(map[string]bool)
+	score := 0
+	for _, tok := range tokenize(text) {
+		if keywords[tok] > 0 && !seen[tok] {
+			seen[tok] = true
+			score++
+		}
+	}
+	return score
+}
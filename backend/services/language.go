@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"nexus-ai/models"
+)
+
+// LanguageAuto asks the provider to identify the spoken language
+const LanguageAuto models.Language = "auto"
+
+// languageCodes is how each provider names a language. An empty code means
+// the provider cannot transcribe it.
+type languageCodes struct {
+	aws      string // Transcribe streaming language code
+	deepgram string // Deepgram nova-2 language
+	whisper  string // whisper's English name, as its server reports it
+}
+
+// transcribeLanguages maps every models.Language to each provider. Whisper
+// itself takes the ISO 639-1 code, which is the models.Language value.
+var transcribeLanguages = map[models.Language]languageCodes{
+	models.LanguageEnglish:    {aws: "en-US", deepgram: "en", whisper: "english"},
+	models.LanguageSpanish:    {aws: "es-US", deepgram: "es", whisper: "spanish"},
+	models.LanguageFrench:     {aws: "fr-FR", deepgram: "fr", whisper: "french"},
+	models.LanguageGerman:     {aws: "de-DE", deepgram: "de", whisper: "german"},
+	models.LanguageChinese:    {aws: "zh-CN", deepgram: "zh", whisper: "chinese"},
+	models.LanguageJapanese:   {aws: "ja-JP", deepgram: "ja", whisper: "japanese"},
+	models.LanguageKorean:     {aws: "ko-KR", deepgram: "ko", whisper: "korean"},
+	models.LanguageHindi:      {aws: "hi-IN", deepgram: "hi", whisper: "hindi"},
+	models.LanguageArabic:     {aws: "ar-SA", whisper: "arabic"},
+	models.LanguagePortuguese: {aws: "pt-BR", deepgram: "pt", whisper: "portuguese"},
+	models.LanguageRussian:    {aws: "ru-RU", deepgram: "ru", whisper: "russian"},
+	models.LanguageItalian:    {aws: "it-IT", deepgram: "it", whisper: "italian"},
+	models.LanguageDutch:      {aws: "nl-NL", deepgram: "nl", whisper: "dutch"},
+	models.LanguageTurkish:    {aws: "tr-TR", deepgram: "tr", whisper: "turkish"},
+	models.LanguagePolish:     {aws: "pl-PL", deepgram: "pl", whisper: "polish"},
+}
+
+// ParseLanguage checks a requested transcription language. Empty and "auto"
+// both ask for detection and return an empty language.
+func ParseLanguage(value string) (models.Language, error) {
+	lang := models.Language(strings.ToLower(strings.TrimSpace(value)))
+	if lang == "" || lang == LanguageAuto {
+		return "", nil
+	}
+	if _, ok := transcribeLanguages[lang]; !ok {
+		return "", fmt.Errorf("unsupported language %q", value)
+	}
+	return lang, nil
+}
+
+// providerLanguage returns a provider's code for a language, or an error
+// naming the provider when it does not support it
+func providerLanguage(provider string, lang models.Language, code func(languageCodes) string) (string, error) {
+	if c := code(transcribeLanguages[lang]); c != "" {
+		return c, nil
+	}
+	return "", fmt.Errorf("%s does not support language %q", provider, lang)
+}
+
+// languageFrom maps a provider's code back to a models.Language, or returns
+// empty when it is not one we support
+func languageFrom(code func(languageCodes) string, value string) models.Language {
+	value = strings.ToLower(value)
+	for lang, codes := range transcribeLanguages {
+		if c := strings.ToLower(code(codes)); c != "" && (c == value || string(lang) == value) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// detectCandidates returns a provider's codes for the languages to choose
+// between when identifying the language, in a stable order
+func detectCandidates(opts TranscribeOptions, code func(languageCodes) string) []string {
+	langs := opts.DetectLanguages
+	if len(langs) == 0 {
+		for lang := range transcribeLanguages {
+			langs = append(langs, lang)
+		}
+	}
+	codes := []string{}
+	for _, lang := range langs {
+		if c := code(transcribeLanguages[lang]); c != "" {
+			codes = append(codes, c)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func awsCode(c languageCodes) string      { return c.aws }
+func deepgramCode(c languageCodes) string { return c.deepgram }
+func whisperName(c languageCodes) string  { return c.whisper }
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"nexus-ai/models"
+)
+
+var ErrNotLinkedInExport = errors.New("not a LinkedIn data export: no Profile.csv or Positions.csv found")
+
+// maxLinkedInFileSize caps each CSV read from the archive
+const maxLinkedInFileSize = 5 << 20
+
+// ParseLinkedInExport builds a profile from the zip LinkedIn produces under
+// Settings > Data privacy > Get a copy of your data. Profile, Positions,
+// Education, Skills, Projects and Email Addresses are read; anything else in
+// the archive is ignored.
+func ParseLinkedInExport(content []byte) (*models.UserProfile, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+
+	tables := map[string][]map[string]string{}
+	for _, f := range archive.File {
+		name := strings.ToLower(path.Base(f.Name))
+		if !strings.HasSuffix(name, ".csv") {
+			continue
+		}
+		rows, err := readLinkedInCSV(f)
+		if err != nil {
+			fmt.Printf("[LINKEDIN] Skipping %s: %v\n", f.Name, err)
+			continue
+		}
+		tables[strings.TrimSuffix(name, ".csv")] = rows
+	}
+
+	if tables["profile"] == nil && tables["positions"] == nil {
+		return nil, ErrNotLinkedInExport
+	}
+
+	profile := &models.UserProfile{
+		Skills:       []string{},
+		Experience:   []models.Experience{},
+		Education:    []models.Education{},
+		Projects:     []models.Project{},
+		Achievements: []string{},
+	}
+
+	if rows := tables["profile"]; len(rows) > 0 {
+		row := rows[0]
+		profile.Name = strings.TrimSpace(row["first name"] + " " + row["last name"])
+		profile.Summary = row["summary"]
+		if profile.Summary == "" {
+			profile.Summary = row["headline"]
+		}
+	}
+
+	for _, row := range tables["email addresses"] {
+		if profile.Email == "" || strings.EqualFold(row["primary"], "yes") {
+			profile.Email = row["email address"]
+		}
+	}
+	for _, row := range tables["phonenumbers"] {
+		if profile.Phone == "" {
+			profile.Phone = row["number"]
+		}
+	}
+
+	for _, row := range tables["positions"] {
+		profile.Experience = append(profile.Experience, models.Experience{
+			Company:     row["company name"],
+			Title:       row["title"],
+			Duration:    linkedInDuration(row["started on"], row["finished on"]),
+			Description: row["description"],
+		})
+	}
+
+	for _, row := range tables["education"] {
+		profile.Education = append(profile.Education, models.Education{
+			Institution: row["school name"],
+			Degree:      row["degree name"],
+			Field:       row["notes"],
+			Year:        strings.Trim(row["start date"]+" - "+row["end date"], " -"),
+		})
+	}
+
+	for _, row := range tables["skills"] {
+		if name := strings.TrimSpace(row["name"]); name != "" {
+			profile.Skills = append(profile.Skills, name)
+		}
+	}
+
+	for _, row := range tables["projects"] {
+		profile.Projects = append(profile.Projects, models.Project{
+			Name:        row["title"],
+			Description: row["description"],
+		})
+	}
+
+	return profile, nil
+}
+
+// readLinkedInCSV reads one CSV into rows keyed by lowercase column name.
+// Some exports start with a few lines of notes before the header row, so the
+// header is taken to be the first row that looks like one.
+func readLinkedInCSV(f *zip.File) ([]map[string]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	r := csv.NewReader(io.LimitReader(rc, maxLinkedInFileSize))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var header []string
+	rows := []map[string]string{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			columns := make([]string, len(record))
+			for i, h := range record {
+				columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+			}
+			// Skills.csv has a single "Name" column
+			if len(columns) > 1 || (len(columns) == 1 && columns[0] == "name") {
+				header = columns
+			}
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(record) {
+				row[h] = strings.TrimSpace(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// linkedInDuration renders LinkedIn's "Started On"/"Finished On" columns
+// ("Jan 2020", or empty for a current role) as a duration string
+func linkedInDuration(started, finished string) string {
+	switch {
+	case started == "":
+		return finished
+	case finished == "":
+		return started + " - Present"
+	default:
+		return started + " - " + finished
+	}
+}
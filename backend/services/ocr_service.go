@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"nexus-ai/config"
+)
+
+// ErrOCRUnavailable is returned when a PDF has no text layer and no OCR
+// engine is installed to recover it.
+var ErrOCRUnavailable = errors.New("this PDF looks like a scanned image and has no extractable text; install tesseract and poppler-utils (pdftoppm) to enable OCR, or upload a text-based PDF, DOCX or TXT resume")
+
+// maxOCRPages caps how many pages are rasterized; resumes rarely run longer
+// and each page costs several seconds of OCR time.
+const maxOCRPages = 5
+
+// OCRService recovers text from image-only PDFs by rasterizing each page and
+// piping it through a locally installed OCR engine.
+type OCRService struct {
+	command    string
+	language   string
+	rasterizer string
+	timeout    time.Duration
+}
+
+func NewOCRService() *OCRService {
+	cfg := config.GetConfig()
+	return &OCRService{
+		command:    cfg.OCRCommand,
+		language:   cfg.OCRLanguage,
+		rasterizer: cfg.PDFRasterizer,
+		timeout:    2 * time.Minute,
+	}
+}
+
+// IsAvailable reports whether both the rasterizer and OCR engine are on PATH
+func (s *OCRService) IsAvailable() bool {
+	if _, err := exec.LookPath(s.rasterizer); err != nil {
+		return false
+	}
+	if _, err := exec.LookPath(s.command); err != nil {
+		return false
+	}
+	return true
+}
+
+// ExtractTextFromPDF rasterizes up to maxOCRPages pages and OCRs each one.
+// Everything is passed through pipes so no page images touch the disk.
+func (s *OCRService) ExtractTextFromPDF(content []byte, numPages int) (string, error) {
+	if !s.IsAvailable() {
+		return "", ErrOCRUnavailable
+	}
+
+	if numPages < 1 {
+		numPages = 1
+	}
+	if numPages > maxOCRPages {
+		numPages = maxOCRPages
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var text strings.Builder
+	for i := 1; i <= numPages; i++ {
+		image, err := s.rasterizePage(ctx, content, i)
+		if err != nil {
+			return "", fmt.Errorf("rasterize page %d: %w", i, err)
+		}
+
+		pageText, err := s.recognize(ctx, image)
+		if err != nil {
+			return "", fmt.Errorf("OCR page %d: %w", i, err)
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+
+	fmt.Printf("[OCR] Recovered %d characters from %d page(s)\n", text.Len(), numPages)
+
+	return strings.TrimSpace(text.String()), nil
+}
+
+func (s *OCRService) rasterizePage(ctx context.Context, content []byte, page int) ([]byte, error) {
+	p := strconv.Itoa(page)
+	cmd := exec.CommandContext(ctx, s.rasterizer,
+		"-f", p,
+		"-l", p,
+		"-r", "300",
+		"-gray",
+		"-png",
+		"-singlefile",
+		"-",
+	)
+	cmd.Stdin = bytes.NewReader(content)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %v - %s", s.rasterizer, err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func (s *OCRService) recognize(ctx context.Context, image []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, s.command, "stdin", "stdout", "-l", s.language)
+	cmd.Stdin = bytes.NewReader(image)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v - %s", s.command, err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
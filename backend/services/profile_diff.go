@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"nexus-ai/models"
+)
+
+// Field change operations
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DiffProfiles returns the field-level changes that turn a into b, ordered by
+// path. Lists of plain values such as skills are compared as sets; lists of
+// objects such as experience are compared entry by entry.
+func DiffProfiles(a, b *models.UserProfile) ([]models.ProfileFieldChange, error) {
+	before, err := toGeneric(a)
+	if err != nil {
+		return nil, err
+	}
+	after, err := toGeneric(b)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.ProfileFieldChange{}
+	diffValues("", before, after, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// toGeneric converts a profile into plain maps and slices via its JSON form,
+// so paths and values match what clients see
+func toGeneric(profile *models.UserProfile) (any, error) {
+	if profile == nil {
+		return map[string]any{}, nil
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff profile: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to diff profile: %w", err)
+	}
+	return v, nil
+}
+
+func diffValues(path string, a, b any, changes *[]models.ProfileFieldChange) {
+	// A missing list compares as an empty one so its items diff individually
+	if _, ok := b.([]any); ok && a == nil {
+		a = []any{}
+	}
+	if _, ok := a.([]any); ok && b == nil {
+		b = []any{}
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			diffMaps(path, av, bv, changes)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			diffSlices(path, av, bv, changes)
+			return
+		}
+	}
+
+	switch {
+	case isEmptyValue(a) && isEmptyValue(b):
+	case isEmptyValue(a):
+		*changes = append(*changes, models.ProfileFieldChange{Path: path, Op: ChangeAdded, New: b})
+	case isEmptyValue(b):
+		*changes = append(*changes, models.ProfileFieldChange{Path: path, Op: ChangeRemoved, Old: a})
+	case !reflect.DeepEqual(a, b):
+		*changes = append(*changes, models.ProfileFieldChange{Path: path, Op: ChangeChanged, Old: a, New: b})
+	}
+}
+
+func diffMaps(path string, a, b map[string]any, changes *[]models.ProfileFieldChange) {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	for k := range keys {
+		diffValues(joinPath(path, k), a[k], b[k], changes)
+	}
+}
+
+func diffSlices(path string, a, b []any, changes *[]models.ProfileFieldChange) {
+	if isScalarSlice(a) && isScalarSlice(b) {
+		for _, v := range a {
+			if !containsValue(b, v) {
+				*changes = append(*changes, models.ProfileFieldChange{Path: path, Op: ChangeRemoved, Old: v})
+			}
+		}
+		for _, v := range b {
+			if !containsValue(a, v) {
+				*changes = append(*changes, models.ProfileFieldChange{Path: path, Op: ChangeAdded, New: v})
+			}
+		}
+		return
+	}
+
+	for i := 0; i < len(a) || i < len(b); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, models.ProfileFieldChange{Path: itemPath, Op: ChangeAdded, New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, models.ProfileFieldChange{Path: itemPath, Op: ChangeRemoved, Old: a[i]})
+		default:
+			diffValues(itemPath, a[i], b[i], changes)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isScalarSlice(values []any) bool {
+	for _, v := range values {
+		switch v.(type) {
+		case map[string]any, []any:
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []any, v any) bool {
+	for _, other := range values {
+		if reflect.DeepEqual(other, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue treats absent fields, null, "" and empty lists alike so that
+// omitempty round trips do not show up as changes
+func isEmptyValue(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"nexus-ai/models"
+)
+
+var ErrInvalidResolution = errors.New("invalid merge resolution")
+
+// MergeSource is one stored profile taking part in a merge
+type MergeSource struct {
+	ProfileID string
+	Profile   *models.UserProfile
+}
+
+// companySuffixes are legal-form words ignored when comparing employers, so
+// "Acme Inc." and "ACME" are the same company
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "llc": true, "ltd": true, "limited": true,
+	"corp": true, "corporation": true, "co": true, "company": true, "gmbh": true,
+	"plc": true, "sa": true, "ag": true, "bv": true, "pvt": true,
+}
+
+var nonAlnum = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// MergeProfiles combines several profiles into one draft. Experience entries
+// at the same company are merged when their dates overlap (or, undated, when
+// their titles match); education and projects are merged by name; skills and
+// achievements are unioned. Where sources disagree on a single value the
+// first source wins in the draft and the disagreement is returned as a
+// conflict for the user to resolve.
+func MergeProfiles(sources []MergeSource, now time.Time) (*models.UserProfile, []models.MergeConflict, error) {
+	clones := make([]MergeSource, 0, len(sources))
+	for _, src := range sources {
+		clone, err := CloneProfile(src.Profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		NormalizeProfileDates(clone, now)
+		clones = append(clones, MergeSource{ProfileID: src.ProfileID, Profile: clone})
+	}
+
+	m := &profileMerger{now: now}
+	merged := &models.UserProfile{
+		Skills:       []string{},
+		Experience:   []models.Experience{},
+		Education:    []models.Education{},
+		Projects:     []models.Project{},
+		Achievements: []string{},
+	}
+
+	field := func(path string, get func(p *models.UserProfile) string) string {
+		options := make([]models.MergeOption, 0, len(clones))
+		for _, src := range clones {
+			options = append(options, models.MergeOption{ProfileID: src.ProfileID, Value: get(src.Profile)})
+		}
+		return m.pick(path, options)
+	}
+	merged.Name = field("name", func(p *models.UserProfile) string { return p.Name })
+	merged.Email = field("email", func(p *models.UserProfile) string { return p.Email })
+	merged.Phone = field("phone", func(p *models.UserProfile) string { return p.Phone })
+	merged.Summary = field("summary", func(p *models.UserProfile) string { return p.Summary })
+
+	skills := []string{}
+	userSkills := map[string]bool{}
+	for _, src := range clones {
+		skills = append(skills, src.Profile.Skills...)
+		merged.Achievements = appendUnique(merged.Achievements, src.Profile.Achievements...)
+		if merged.RawResumeText == "" {
+			merged.RawResumeText = src.Profile.RawResumeText
+		}
+		// Keep proficiency the user set by hand; derived detail is rebuilt on save
+		for _, detail := range src.Profile.SkillDetails {
+			key := strings.ToLower(detail.Name)
+			if detail.ProficiencySource == "user" && !userSkills[key] {
+				userSkills[key] = true
+				merged.SkillDetails = append(merged.SkillDetails, detail)
+			}
+		}
+	}
+	merged.Skills = DefaultSkillTaxonomy().NormalizeSkills(skills)
+
+	merged.Experience = m.mergeExperience(clones)
+	merged.Education = mergeEducation(clones)
+	merged.Projects = mergeProjects(clones)
+
+	return merged, m.conflicts, nil
+}
+
+// profileMerger collects conflicts while a merge is built
+type profileMerger struct {
+	now       time.Time
+	conflicts []models.MergeConflict
+}
+
+// pick returns the first non-empty option. If other sources hold a
+// different non-empty value, a conflict listing every distinct value is
+// recorded against path.
+func (m *profileMerger) pick(path string, options []models.MergeOption) string {
+	distinct := []models.MergeOption{}
+	seen := map[string]bool{}
+	for _, opt := range options {
+		key := normalizeMergeText(opt.Value)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, opt)
+	}
+
+	switch len(distinct) {
+	case 0:
+		return ""
+	case 1:
+		return distinct[0].Value
+	}
+
+	m.conflicts = append(m.conflicts, models.MergeConflict{
+		ID:      fmt.Sprintf("c%d", len(m.conflicts)+1),
+		Path:    path,
+		Options: distinct,
+		Value:   distinct[0].Value,
+	})
+	return distinct[0].Value
+}
+
+// experienceGroup is one real-world role as seen by one or more sources
+type experienceGroup struct {
+	entries []models.Experience
+	sources []string
+	start   string
+}
+
+func (m *profileMerger) mergeExperience(sources []MergeSource) []models.Experience {
+	groups := []*experienceGroup{}
+	for _, src := range sources {
+		for _, exp := range src.Profile.Experience {
+			var match *experienceGroup
+			for _, g := range groups {
+				if m.sameExperience(g.entries[0], exp) {
+					match = g
+					break
+				}
+			}
+			if match == nil {
+				match = &experienceGroup{}
+				groups = append(groups, match)
+			}
+			match.entries = append(match.entries, exp)
+			match.sources = append(match.sources, src.ProfileID)
+			if exp.StartDate > match.start {
+				match.start = exp.StartDate
+			}
+		}
+	}
+
+	// Most recent role first; undated roles keep their order at the end
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].start == "" || groups[j].start == "" {
+			return groups[i].start != "" && groups[j].start == ""
+		}
+		return groups[i].start > groups[j].start
+	})
+
+	result := make([]models.Experience, 0, len(groups))
+	for i, g := range groups {
+		base := g.entries[0]
+		merged := base
+
+		options := func(get func(e models.Experience) string) []models.MergeOption {
+			opts := make([]models.MergeOption, len(g.entries))
+			for k, e := range g.entries {
+				opts[k] = models.MergeOption{ProfileID: g.sources[k], Value: get(e)}
+			}
+			return opts
+		}
+
+		merged.Company = firstNonEmpty(options(func(e models.Experience) string { return e.Company }))
+
+		// Similar titles ("Sr. Engineer" / "Senior Engineer") are not worth
+		// asking about; keep the longest
+		titles := options(func(e models.Experience) string { return e.Title })
+		if allSimilarTitles(titles) {
+			merged.Title = longestOption(titles)
+		} else {
+			merged.Title = m.pick(fmt.Sprintf("experience[%d].title", i), titles)
+		}
+
+		merged.Duration = m.pick(fmt.Sprintf("experience[%d].duration", i),
+			options(func(e models.Experience) string { return e.Duration }))
+		for _, e := range g.entries {
+			if e.Duration == merged.Duration {
+				merged.StartDate, merged.EndDate, merged.IsCurrent = e.StartDate, e.EndDate, e.IsCurrent
+				break
+			}
+		}
+
+		merged.Description = longestOption(options(func(e models.Experience) string { return e.Description }))
+		merged.Achievements = nil
+		for _, e := range g.entries {
+			merged.Achievements = appendUnique(merged.Achievements, e.Achievements...)
+		}
+
+		result = append(result, merged)
+	}
+	return result
+}
+
+// sameExperience reports whether two entries describe the same role: same
+// employer and, when both are dated, at least half of the shorter one
+// overlapping; undated entries fall back to comparing titles
+func (m *profileMerger) sameExperience(a, b models.Experience) bool {
+	ka, kb := companyKey(a.Company), companyKey(b.Company)
+	if ka == "" || ka != kb {
+		return false
+	}
+
+	ra, okA := experienceRange(a, m.now)
+	rb, okB := experienceRange(b, m.now)
+	if okA && okB {
+		start, end := ra.Start, ra.End
+		if rb.Start > start {
+			start = rb.Start
+		}
+		if rb.End < end {
+			end = rb.End
+		}
+		shorter := ra.Months()
+		if rb.Months() < shorter {
+			shorter = rb.Months()
+		}
+		return end > start && int(end-start)*2 >= shorter
+	}
+
+	return titlesSimilar(a.Title, b.Title)
+}
+
+func mergeEducation(sources []MergeSource) []models.Education {
+	result := []models.Education{}
+	for _, src := range sources {
+		for _, edu := range src.Profile.Education {
+			idx := -1
+			for i, existing := range result {
+				if companyKey(existing.Institution) == companyKey(edu.Institution) &&
+					(existing.Degree == "" || edu.Degree == "" || titlesSimilar(existing.Degree, edu.Degree)) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				result = append(result, edu)
+				continue
+			}
+			existing := &result[idx]
+			if existing.Degree == "" {
+				existing.Degree = edu.Degree
+			}
+			if existing.Field == "" {
+				existing.Field = edu.Field
+			}
+			if existing.Year == "" {
+				existing.Year, existing.StartDate, existing.EndDate = edu.Year, edu.StartDate, edu.EndDate
+			}
+		}
+	}
+	return result
+}
+
+func mergeProjects(sources []MergeSource) []models.Project {
+	result := []models.Project{}
+	for _, src := range sources {
+		for _, proj := range src.Profile.Projects {
+			idx := -1
+			for i, existing := range result {
+				if normalizeMergeText(existing.Name) == normalizeMergeText(proj.Name) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				proj.Technologies = appendUnique(nil, proj.Technologies...)
+				result = append(result, proj)
+				continue
+			}
+			existing := &result[idx]
+			if len(proj.Description) > len(existing.Description) {
+				existing.Description = proj.Description
+			}
+			existing.Technologies = appendUnique(existing.Technologies, proj.Technologies...)
+		}
+	}
+	return result
+}
+
+// ResolveMergeConflicts applies resolutions to a pending merge, updating the
+// draft profile and marking each conflict resolved
+func ResolveMergeConflicts(pending *models.PendingMerge, resolutions []models.MergeResolution) error {
+	for _, res := range resolutions {
+		idx := -1
+		for i := range pending.Conflicts {
+			if pending.Conflicts[i].ID == res.ConflictID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("%w: unknown conflict %q", ErrInvalidResolution, res.ConflictID)
+		}
+		conflict := &pending.Conflicts[idx]
+
+		var value string
+		switch {
+		case res.Value != nil:
+			value = *res.Value
+		case res.Option != nil && *res.Option >= 0 && *res.Option < len(conflict.Options):
+			value = conflict.Options[*res.Option].Value
+		default:
+			return fmt.Errorf("%w: conflict %s needs an option between 0 and %d or a value",
+				ErrInvalidResolution, conflict.ID, len(conflict.Options)-1)
+		}
+
+		profile, err := setProfileField(pending.Profile, conflict.Path, value)
+		if err != nil {
+			return err
+		}
+		pending.Profile = profile
+		conflict.Value = value
+		conflict.Resolved = true
+	}
+	return nil
+}
+
+// UnresolvedConflicts counts the conflicts still waiting on the user
+func UnresolvedConflicts(pending *models.PendingMerge) int {
+	n := 0
+	for _, c := range pending.Conflicts {
+		if !c.Resolved {
+			n++
+		}
+	}
+	return n
+}
+
+// setProfileField sets a string field addressed by a conflict path such as
+// "experience[1].title"
+func setProfileField(profile *models.UserProfile, path, value string) (*models.UserProfile, error) {
+	pointer := "/" + strings.NewReplacer("[", "/", "]", "", ".", "/").Replace(path)
+	patch, err := json.Marshal([]map[string]any{{"op": "add", "path": pointer, "value": value}})
+	if err != nil {
+		return nil, err
+	}
+	return ApplyProfilePatch(profile, patch, JSONPatchContentType)
+}
+
+func companyKey(name string) string {
+	words := strings.Fields(nonAlnum.ReplaceAllString(strings.ToLower(name), " "))
+	kept := words[:0]
+	for _, w := range words {
+		if !companySuffixes[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// titlesSimilar compares titles by their words, ignoring common
+// abbreviations; titles match when one contains the other or at least half
+// of their words are shared
+func titlesSimilar(a, b string) bool {
+	ta, tb := titleWords(a), titleWords(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return len(ta) == len(tb)
+	}
+	shared := 0
+	for w := range ta {
+		if tb[w] {
+			shared++
+		}
+	}
+	smaller, union := len(ta), len(ta)+len(tb)-shared
+	if len(tb) < smaller {
+		smaller = len(tb)
+	}
+	return shared == smaller || shared*2 >= union
+}
+
+var titleAbbreviations = map[string]string{
+	"sr": "senior", "jr": "junior", "eng": "engineer", "engr": "engineer",
+	"dev": "developer", "mgr": "manager", "swe": "software engineer",
+}
+
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.Fields(nonAlnum.ReplaceAllString(strings.ToLower(title), " ")) {
+		if full, ok := titleAbbreviations[w]; ok {
+			w = full
+		}
+		for _, part := range strings.Fields(w) {
+			if !stopWords[part] {
+				words[part] = true
+			}
+		}
+	}
+	return words
+}
+
+func allSimilarTitles(options []models.MergeOption) bool {
+	first := ""
+	for _, opt := range options {
+		if opt.Value == "" {
+			continue
+		}
+		if first == "" {
+			first = opt.Value
+			continue
+		}
+		if !titlesSimilar(first, opt.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(options []models.MergeOption) string {
+	for _, opt := range options {
+		if strings.TrimSpace(opt.Value) != "" {
+			return opt.Value
+		}
+	}
+	return ""
+}
+
+func longestOption(options []models.MergeOption) string {
+	longest := ""
+	for _, opt := range options {
+		if len(opt.Value) > len(longest) {
+			longest = opt.Value
+		}
+	}
+	return longest
+}
+
+// appendUnique appends the values not already present, comparing
+// case-insensitively and ignoring punctuation and spacing
+func appendUnique(list []string, values ...string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		seen[normalizeMergeText(v)] = true
+	}
+	for _, v := range values {
+		key := normalizeMergeText(v)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		list = append(list, v)
+	}
+	return list
+}
+
+func normalizeMergeText(s string) string {
+	return strings.TrimSpace(nonAlnum.ReplaceAllString(strings.ToLower(s), " "))
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"time"
+
+	"nexus-ai/models"
+)
+
+// NormalizeProfile brings a profile into canonical form before it is saved:
+// durations are parsed into structured dates, skills are mapped onto the
+// taxonomy and skill details are re-derived from the experience timeline
+func NormalizeProfile(profile *models.UserProfile) {
+	now := time.Now()
+	NormalizeProfileDates(profile, now)
+	timeline := BuildTimeline(profile, now)
+	NormalizeProfileSkills(profile, timeline.SkillYears)
+}
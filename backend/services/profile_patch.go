@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+
+	"nexus-ai/models"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Patch document media types
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	ErrUnsupportedPatchType = errors.New("unsupported patch content type")
+	ErrInvalidPatch         = errors.New("invalid patch")
+)
+
+// ApplyProfilePatch applies a JSON Merge Patch or JSON Patch document to a
+// profile and returns the patched copy; the original is left untouched.
+// Plain application/json is treated as a merge patch. The result must still
+// be a valid profile: unknown fields and wrong types are rejected.
+func ApplyProfilePatch(profile *models.UserProfile, patch []byte, contentType string) (*models.UserProfile, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = MergePatchContentType
+	}
+
+	original, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	var patched []byte
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case JSONPatchContentType:
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(patch); err == nil {
+			patched, err = ops.Apply(original)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s (use %s or %s)", ErrUnsupportedPatchType, mediaType, MergePatchContentType, JSONPatchContentType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	var result models.UserProfile
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: patched profile is not valid: %v", ErrInvalidPatch, err)
+	}
+	return &result, nil
+}
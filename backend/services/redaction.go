@@ -0,0 +1,347 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"nexus-ai/config"
+	"nexus-ai/models"
+)
+
+// Kinds of redacted data
+const (
+	RedactEmail    = "EMAIL"
+	RedactPhone    = "PHONE"
+	RedactAddress  = "ADDRESS"
+	RedactName     = "NAME"
+	RedactEmployer = "EMPLOYER"
+)
+
+// maxRedactionAudit is how many audit records are kept in memory
+const maxRedactionAudit = 500
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+	// Phone candidates are checked for 7-15 digits afterwards, which keeps
+	// year ranges such as "2019-2021" from matching
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{3,4}\b`)
+
+	addressPattern = regexp.MustCompile(`\b\d{1,6}\s+(?:[A-Z0-9][A-Za-z0-9.'-]*\s+){1,5}` +
+		`(?i:street|st|avenue|ave|road|rd|boulevard|blvd|lane|ln|drive|dr|court|ct|way|place|pl|terrace|parkway|pkwy|circle|cir|highway|hwy|square|sq)\b\.?` +
+		`(?:,?\s*(?i:apt|apartment|suite|ste|unit|#)\.?\s*[A-Za-z0-9-]+)?`)
+
+	placeholderPattern = regexp.MustCompile(`\[(?:EMAIL|PHONE|ADDRESS|NAME|EMPLOYER)_\d+\]`)
+)
+
+// RedactionOptions selects what a Redactor removes. Emails, phone numbers
+// and street addresses are always covered when redaction is on.
+type RedactionOptions struct {
+	Names     bool
+	Employers bool
+}
+
+// Redactor replaces personal data with stable placeholders such as
+// "[EMAIL_1]" before text leaves the process, and puts the original values
+// back into model output. One Redactor is used per outbound call so the same
+// value always maps to the same placeholder within a prompt and its answer.
+type Redactor struct {
+	opts     RedactionOptions
+	mu       sync.Mutex
+	values   map[string]string // original value -> placeholder
+	original map[string]string // placeholder -> original value
+	kinds    map[string]string // placeholder -> kind
+	counts   map[string]int
+	terms    []redactionTerm
+}
+
+// redactionTerm is a known literal (a name or employer) to redact
+type redactionTerm struct {
+	kind  string
+	value string
+}
+
+// NewRedactor returns a redactor configured from PII_REDACTION,
+// REDACT_NAMES and REDACT_EMPLOYERS, or nil when redaction is disabled
+func NewRedactor() *Redactor {
+	cfg := config.GetConfig()
+	if !cfg.RedactPII {
+		return nil
+	}
+	return NewRedactorWithOptions(RedactionOptions{
+		Names:     cfg.RedactNames,
+		Employers: cfg.RedactEmployers,
+	})
+}
+
+func NewRedactorWithOptions(opts RedactionOptions) *Redactor {
+	return &Redactor{
+		opts:     opts,
+		values:   make(map[string]string),
+		original: make(map[string]string),
+		kinds:    make(map[string]string),
+		counts:   make(map[string]int),
+	}
+}
+
+// NewProfileRedactor returns the configured redactor primed with the name and
+// employers of a profile, so they are caught wherever they appear in a prompt
+func NewProfileRedactor(profile *models.UserProfile) *Redactor {
+	r := NewRedactor()
+	if r != nil {
+		r.AddProfile(profile)
+	}
+	return r
+}
+
+// AddProfile registers a profile's name and employers as literal terms, if
+// the options ask for them. Nil-safe.
+func (r *Redactor) AddProfile(profile *models.UserProfile) {
+	if r == nil || profile == nil {
+		return
+	}
+	if r.opts.Names {
+		r.AddTerm(RedactName, profile.Name)
+		// Also catch the first or last name used on its own
+		for _, part := range strings.Fields(profile.Name) {
+			if len([]rune(part)) >= 3 {
+				r.AddTerm(RedactName, part)
+			}
+		}
+	}
+	if r.opts.Employers {
+		for _, exp := range profile.Experience {
+			r.AddTerm(RedactEmployer, exp.Company)
+		}
+	}
+}
+
+// AddTerm registers a literal value to redact wherever it appears
+func (r *Redactor) AddTerm(kind, value string) {
+	if r == nil {
+		return
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.terms {
+		if strings.EqualFold(t.value, value) {
+			return
+		}
+	}
+	r.terms = append(r.terms, redactionTerm{kind: kind, value: value})
+	// Longest first so "Jane Doe" is replaced before "Jane"
+	sort.SliceStable(r.terms, func(i, j int) bool {
+		return len(r.terms[i].value) > len(r.terms[j].value)
+	})
+}
+
+// Redact replaces personal data in text with placeholders. Nil-safe: a nil
+// Redactor returns text unchanged.
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	text = emailPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return r.placeholder(RedactEmail, m)
+	})
+	text = addressPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return r.placeholder(RedactAddress, m)
+	})
+	text = phonePattern.ReplaceAllStringFunc(text, func(m string) string {
+		digits := 0
+		for _, c := range m {
+			if unicode.IsDigit(c) {
+				digits++
+			}
+		}
+		if digits < 7 || digits > 15 {
+			return m
+		}
+		return r.placeholder(RedactPhone, m)
+	})
+
+	for _, t := range r.terms {
+		text = replaceTerm(text, t.value, func(m string) string {
+			return r.placeholder(t.kind, m)
+		})
+	}
+	return text
+}
+
+// placeholder returns the stable placeholder for a value, allocating one on
+// first sight. Callers hold r.mu.
+func (r *Redactor) placeholder(kind, value string) string {
+	key := kind + "\x00" + strings.ToLower(value)
+	if p, ok := r.values[key]; ok {
+		return p
+	}
+	r.counts[kind]++
+	p := fmt.Sprintf("[%s_%d]", kind, r.counts[kind])
+	r.values[key] = p
+	r.original[p] = value
+	r.kinds[p] = kind
+	return p
+}
+
+// Rehydrate puts the original values back in place of placeholders.
+// Unknown placeholders are left alone. Nil-safe.
+func (r *Redactor) Rehydrate(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.original) == 0 {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		if v, ok := r.original[p]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+// Entries lists what was redacted, without the values themselves: each
+// placeholder with its kind and a short fingerprint of the value
+func (r *Redactor) Entries() []models.RedactionEntry {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]models.RedactionEntry, 0, len(r.original))
+	for p, v := range r.original {
+		sum := sha256.Sum256([]byte(strings.ToLower(v)))
+		entries = append(entries, models.RedactionEntry{
+			Placeholder: p,
+			Kind:        r.kinds[p],
+			Fingerprint: hex.EncodeToString(sum[:6]),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Placeholder < entries[j].Placeholder
+	})
+	return entries
+}
+
+// StreamRehydrator rehydrates streamed text whose placeholders may be split
+// across chunks. It holds back a trailing "[..." until it can tell whether it
+// is a placeholder.
+type StreamRehydrator struct {
+	redactor *Redactor
+	pending  string
+}
+
+// maxPlaceholderLen bounds how much text is held back
+const maxPlaceholderLen = 24
+
+func (r *Redactor) NewStreamRehydrator() *StreamRehydrator {
+	return &StreamRehydrator{redactor: r}
+}
+
+// Write takes the next chunk and returns the text that is safe to emit
+func (s *StreamRehydrator) Write(chunk string) string {
+	text := s.pending + chunk
+	s.pending = ""
+
+	if open := strings.LastIndex(text, "["); open >= 0 && !strings.Contains(text[open:], "]") && len(text)-open < maxPlaceholderLen {
+		s.pending = text[open:]
+		text = text[:open]
+	}
+	return s.redactor.Rehydrate(text)
+}
+
+// Flush returns whatever is still held back
+func (s *StreamRehydrator) Flush() string {
+	text := s.pending
+	s.pending = ""
+	return s.redactor.Rehydrate(text)
+}
+
+// replaceTerm replaces whole-word, case-insensitive occurrences of term
+func replaceTerm(text, term string, replace func(string) string) string {
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(term))
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if !isTermBoundary(text, start-1) || !isTermBoundary(text, end) {
+			continue
+		}
+		// Never rewrite inside an existing placeholder
+		if start > 0 && text[start-1] == '[' || end < len(text) && text[end] == '_' {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(replace(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+var (
+	redactionAudit     []models.RedactionAudit
+	redactionAuditLock sync.Mutex
+)
+
+// RecordRedaction adds an audit record for one outbound call. Nothing is
+// recorded when the redactor found nothing.
+func RecordRedaction(r *Redactor, purpose string) {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return
+	}
+
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.Kind]++
+	}
+	fmt.Printf("[REDACT] %s: %v\n", purpose, counts)
+
+	redactionAuditLock.Lock()
+	defer redactionAuditLock.Unlock()
+
+	redactionAudit = append(redactionAudit, models.RedactionAudit{
+		Time:    time.Now(),
+		Purpose: purpose,
+		Counts:  counts,
+		Entries: entries,
+	})
+	if len(redactionAudit) > maxRedactionAudit {
+		redactionAudit = redactionAudit[len(redactionAudit)-maxRedactionAudit:]
+	}
+}
+
+// RecentRedactions returns up to limit audit records, newest first
+func RecentRedactions(limit int) []models.RedactionAudit {
+	redactionAuditLock.Lock()
+	defer redactionAuditLock.Unlock()
+
+	result := []models.RedactionAudit{}
+	for i := len(redactionAudit) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, redactionAudit[i])
+	}
+	return result
+}
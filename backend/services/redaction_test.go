@@ -0,0 +1,182 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"nexus-ai/models"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "Reach me at jane.doe@example.com.", "Reach me at [EMAIL_1]."},
+		{"phone", "Call +1 (415) 555-0100 today", "Call [PHONE_1] today"},
+		{"address", "I live at 42 Market Street, Apt 5 now", "I live at [ADDRESS_1] now"},
+		{"year range kept", "At Acme from 2019-2021", "At Acme from 2019-2021"},
+		{"short number kept", "Cut costs by 12345 dollars", "Cut costs by 12345 dollars"},
+		{"same value same placeholder", "a@b.io then b@c.io then a@b.io", "[EMAIL_1] then [EMAIL_2] then [EMAIL_1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedactorWithOptions(RedactionOptions{})
+			got := r.Redact(tt.in)
+			if got != tt.want {
+				t.Fatalf("Redact = %q, want %q", got, tt.want)
+			}
+			if back := r.Rehydrate(got); back != tt.in {
+				t.Fatalf("Rehydrate = %q, want %q", back, tt.in)
+			}
+		})
+	}
+}
+
+func TestRedactorProfileTerms(t *testing.T) {
+	profile := &models.UserProfile{
+		Name:       "Jane Doe",
+		Experience: []models.Experience{{Company: "Globex"}, {Company: "Initech"}},
+	}
+	// Terms are matched whole-word and case-insensitively, longest first
+	text := "Jane Doe led the platform team at Globex. Jane then joined INITECH; Janet did not."
+
+	tests := []struct {
+		name string
+		opts RedactionOptions
+		want string
+	}{
+		{"neither", RedactionOptions{}, text},
+		{"names", RedactionOptions{Names: true}, "[NAME_1] led the platform team at Globex. [NAME_2] then joined INITECH; Janet did not."},
+		{"employers", RedactionOptions{Employers: true}, "Jane Doe led the platform team at [EMPLOYER_2]. Jane then joined [EMPLOYER_1]; Janet did not."},
+		{
+			"both",
+			RedactionOptions{Names: true, Employers: true},
+			"[NAME_1] led the platform team at [EMPLOYER_2]. [NAME_2] then joined [EMPLOYER_1]; Janet did not.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedactorWithOptions(tt.opts)
+			r.AddProfile(profile)
+			got := r.Redact(text)
+			if got != tt.want {
+				t.Fatalf("Redact =\n%q\nwant\n%q", got, tt.want)
+			}
+			if back := r.Rehydrate(got); back != text {
+				t.Fatalf("Rehydrate = %q", back)
+			}
+			if n := len(r.Entries()); n != strings.Count(got, "[") {
+				t.Errorf("%d audit entries for %d placeholders", n, strings.Count(got, "["))
+			}
+		})
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	text := "jane@example.com"
+	r.AddProfile(&models.UserProfile{Name: "Jane Doe"})
+	if r.Redact(text) != text || r.Rehydrate(text) != text || r.Entries() != nil {
+		t.Fatal("nil Redactor changed text")
+	}
+	s := r.NewStreamRehydrator()
+	if got := s.Write("[EMAIL_") + s.Write("1] and [x") + s.Flush(); got != "[EMAIL_1] and [x" {
+		t.Fatalf("nil Redactor stream = %q", got)
+	}
+}
+
+func TestMessageRequestDefaultRedaction(t *testing.T) {
+	// Without a Redactor of its own a request gets the default one, which
+	// covers emails, phones and addresses but not names
+	req := MessageRequest{
+		Model:    "test",
+		System:   "Candidate Jane Doe, jane@example.com",
+		Messages: []MessageInput{{Role: "user", Content: "Call me on 415-555-0100"}},
+	}
+	redacted, r := req.redact()
+	if r == nil {
+		t.Skip("PII_REDACTION is off")
+	}
+	if redacted.System != "Candidate Jane Doe, [EMAIL_1]" || redacted.Messages[0].Content != "Call me on [PHONE_1]" {
+		t.Fatalf("redacted = %q, %q", redacted.System, redacted.Messages[0].Content)
+	}
+	if req.Messages[0].Content != "Call me on 415-555-0100" {
+		t.Fatal("redact changed the caller's messages")
+	}
+	if got := r.Rehydrate("Email [EMAIL_1] or call [PHONE_1]"); got != "Email jane@example.com or call 415-555-0100" {
+		t.Fatalf("Rehydrate = %q", got)
+	}
+
+	// A profile redactor passed in is used instead
+	own := NewRedactorWithOptions(RedactionOptions{Names: true})
+	own.AddProfile(&models.UserProfile{Name: "Jane Doe"})
+	req.Redactor = own
+	if redacted, r := req.redact(); r != own || redacted.System != "Candidate [NAME_1], [EMAIL_1]" {
+		t.Fatalf("redacted with own redactor = %q", redacted.System)
+	}
+}
+
+func TestStreamRehydratorSplitPlaceholders(t *testing.T) {
+	r := NewRedactorWithOptions(RedactionOptions{Names: true, Employers: true})
+	r.AddProfile(&models.UserProfile{Name: "Jane Doe", Experience: []models.Experience{{Company: "Globex"}}})
+	r.Redact("Jane Doe, jane@example.com, Globex")
+
+	answer := "As [NAME_1] I led [EMPLOYER_1]'s migration [see notes]; mail [EMAIL_1] or [UNKNOWN_9]."
+	want := "As Jane Doe I led Globex's migration [see notes]; mail jane@example.com or [UNKNOWN_9]."
+
+	stream := func(chunks []string) string {
+		s := r.NewStreamRehydrator()
+		var out strings.Builder
+		for _, c := range chunks {
+			emitted := s.Write(c)
+			if placeholderPattern.MatchString(emitted) {
+				t.Fatalf("emitted a known placeholder: %q", emitted)
+			}
+			out.WriteString(emitted)
+		}
+		out.WriteString(s.Flush())
+		return out.String()
+	}
+
+	// Every way of cutting the answer in two
+	for i := 0; i <= len(answer); i++ {
+		if got := stream([]string{answer[:i], answer[i:]}); got != want {
+			t.Fatalf("split at %d: %q", i, got)
+		}
+	}
+
+	// One byte at a time
+	chunks := make([]string, len(answer))
+	for i := range answer {
+		chunks[i] = answer[i : i+1]
+	}
+	if got := stream(chunks); got != want {
+		t.Fatalf("byte by byte: %q", got)
+	}
+}
+
+func TestStreamRehydratorReleasesNonPlaceholders(t *testing.T) {
+	r := NewRedactorWithOptions(RedactionOptions{})
+	r.Redact("jane@example.com")
+	s := r.NewStreamRehydrator()
+
+	// A bracket that cannot open a placeholder is not held back for long
+	long := "[" + strings.Repeat("x", maxPlaceholderLen)
+	if got := s.Write(long); got != long {
+		t.Fatalf("Write = %q, want the text released", got)
+	}
+	if got := s.Write("list [1"); got != "list " {
+		t.Fatalf("Write = %q, want the bracket held", got)
+	}
+	if got := s.Write("] done"); got != "[1] done" {
+		t.Fatalf("Write = %q", got)
+	}
+	if got := s.Write(" [EMAIL"); got != " " {
+		t.Fatalf("Write = %q", got)
+	}
+	if got := s.Flush(); got != "[EMAIL" {
+		t.Fatalf("Flush = %q, want the held text", got)
+	}
+}
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"nexus-ai/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Supported profile export formats
+const (
+	ExportFormatJSONResume = "json"
+	ExportFormatMarkdown   = "markdown"
+	ExportFormatText       = "text"
+	ExportFormatPDF        = "pdf"
+)
+
+// ExportedResume is a rendered profile ready to send to the client
+type ExportedResume struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+}
+
+// NormalizeExportFormat maps user-supplied format names onto the supported set
+func NormalizeExportFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json", "jsonresume", "json-resume":
+		return ExportFormatJSONResume, true
+	case "markdown", "md":
+		return ExportFormatMarkdown, true
+	case "text", "txt", "plain":
+		return ExportFormatText, true
+	case "pdf":
+		return ExportFormatPDF, true
+	default:
+		return "", false
+	}
+}
+
+// ExportProfile renders a profile in the requested format
+func ExportProfile(profile *models.UserProfile, format string) (*ExportedResume, error) {
+	normalized, ok := NormalizeExportFormat(format)
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	switch normalized {
+	case ExportFormatMarkdown:
+		return &ExportedResume{
+			Data:        []byte(RenderMarkdown(profile)),
+			ContentType: "text/markdown; charset=utf-8",
+			Extension:   ".md",
+		}, nil
+	case ExportFormatText:
+		return &ExportedResume{
+			Data:        []byte(RenderPlainText(profile)),
+			ContentType: "text/plain; charset=utf-8",
+			Extension:   ".txt",
+		}, nil
+	case ExportFormatPDF:
+		data, err := RenderPDF(profile)
+		if err != nil {
+			return nil, err
+		}
+		return &ExportedResume{Data: data, ContentType: "application/pdf", Extension: ".pdf"}, nil
+	default:
+		data, err := RenderJSONResume(profile)
+		if err != nil {
+			return nil, err
+		}
+		return &ExportedResume{Data: data, ContentType: "application/json", Extension: ".json"}, nil
+	}
+}
+
+// jsonResume mirrors the subset of the JSON Resume schema (jsonresume.org)
+// that UserProfile can populate
+type jsonResume struct {
+	Schema    string              `json:"$schema"`
+	Basics    jsonResumeBasics    `json:"basics"`
+	Work      []jsonResumeWork    `json:"work"`
+	Education []jsonResumeEdu     `json:"education"`
+	Skills    []jsonResumeSkill   `json:"skills"`
+	Projects  []jsonResumeProject `json:"projects"`
+	Awards    []jsonResumeAward   `json:"awards"`
+}
+
+type jsonResumeBasics struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+type jsonResumeWork struct {
+	Name       string   `json:"name,omitempty"`
+	Position   string   `json:"position,omitempty"`
+	Duration   string   `json:"x-duration,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+type jsonResumeEdu struct {
+	Institution string `json:"institution,omitempty"`
+	Area        string `json:"area,omitempty"`
+	StudyType   string `json:"studyType,omitempty"`
+	EndDate     string `json:"endDate,omitempty"`
+}
+
+type jsonResumeSkill struct {
+	Name string `json:"name"`
+}
+
+type jsonResumeProject struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+}
+
+type jsonResumeAward struct {
+	Title string `json:"title"`
+}
+
+// RenderJSONResume renders a profile as a JSON Resume document
+func RenderJSONResume(profile *models.UserProfile) ([]byte, error) {
+	doc := jsonResume{
+		Schema: "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json",
+		Basics: jsonResumeBasics{
+			Name:    profile.Name,
+			Email:   profile.Email,
+			Phone:   profile.Phone,
+			Summary: profile.Summary,
+		},
+		Work:      []jsonResumeWork{},
+		Education: []jsonResumeEdu{},
+		Skills:    []jsonResumeSkill{},
+		Projects:  []jsonResumeProject{},
+		Awards:    []jsonResumeAward{},
+	}
+
+	for _, exp := range profile.Experience {
+		doc.Work = append(doc.Work, jsonResumeWork{
+			Name:       exp.Company,
+			Position:   exp.Title,
+			Duration:   exp.Duration,
+			Summary:    exp.Description,
+			Highlights: exp.Achievements,
+		})
+	}
+	for _, edu := range profile.Education {
+		doc.Education = append(doc.Education, jsonResumeEdu{
+			Institution: edu.Institution,
+			Area:        edu.Field,
+			StudyType:   edu.Degree,
+			EndDate:     edu.Year,
+		})
+	}
+	for _, skill := range profile.Skills {
+		doc.Skills = append(doc.Skills, jsonResumeSkill{Name: skill})
+	}
+	for _, proj := range profile.Projects {
+		doc.Projects = append(doc.Projects, jsonResumeProject{
+			Name:        proj.Name,
+			Description: proj.Description,
+			Keywords:    proj.Technologies,
+		})
+	}
+	for _, a := range profile.Achievements {
+		doc.Awards = append(doc.Awards, jsonResumeAward{Title: a})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// RenderMarkdown renders a profile as a Markdown resume
+func RenderMarkdown(profile *models.UserProfile) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", fallback(profile.Name, "Resume"))
+	if contact := contactLine(profile); contact != "" {
+		fmt.Fprintf(&b, "%s\n\n", contact)
+	}
+	if profile.Summary != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n\n", profile.Summary)
+	}
+	if len(profile.Skills) > 0 {
+		fmt.Fprintf(&b, "## Skills\n\n%s\n\n", strings.Join(profile.Skills, ", "))
+	}
+	if len(profile.Experience) > 0 {
+		b.WriteString("## Experience\n\n")
+		for _, exp := range profile.Experience {
+			fmt.Fprintf(&b, "### %s\n\n", experienceHeading(exp))
+			if exp.Duration != "" {
+				fmt.Fprintf(&b, "*%s*\n\n", exp.Duration)
+			}
+			if exp.Description != "" {
+				fmt.Fprintf(&b, "%s\n\n", exp.Description)
+			}
+			for _, a := range exp.Achievements {
+				fmt.Fprintf(&b, "- %s\n", a)
+			}
+			if len(exp.Achievements) > 0 {
+				b.WriteString("\n")
+			}
+		}
+	}
+	if len(profile.Projects) > 0 {
+		b.WriteString("## Projects\n\n")
+		for _, proj := range profile.Projects {
+			fmt.Fprintf(&b, "- **%s**", proj.Name)
+			if proj.Description != "" {
+				fmt.Fprintf(&b, ": %s", proj.Description)
+			}
+			if len(proj.Technologies) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(proj.Technologies, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(profile.Education) > 0 {
+		b.WriteString("## Education\n\n")
+		for _, edu := range profile.Education {
+			fmt.Fprintf(&b, "- %s\n", educationLine(edu))
+		}
+		b.WriteString("\n")
+	}
+	if len(profile.Achievements) > 0 {
+		b.WriteString("## Achievements\n\n")
+		for _, a := range profile.Achievements {
+			fmt.Fprintf(&b, "- %s\n", a)
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// RenderPlainText renders a profile as a plain-text resume
+func RenderPlainText(profile *models.UserProfile) string {
+	var b strings.Builder
+
+	name := fallback(profile.Name, "Resume")
+	fmt.Fprintf(&b, "%s\n%s\n", strings.ToUpper(name), strings.Repeat("=", len(name)))
+	if contact := contactLine(profile); contact != "" {
+		fmt.Fprintf(&b, "%s\n", contact)
+	}
+	b.WriteString("\n")
+
+	section := func(title string) {
+		fmt.Fprintf(&b, "%s\n%s\n", strings.ToUpper(title), strings.Repeat("-", len(title)))
+	}
+
+	if profile.Summary != "" {
+		section("Summary")
+		fmt.Fprintf(&b, "%s\n\n", profile.Summary)
+	}
+	if len(profile.Skills) > 0 {
+		section("Skills")
+		fmt.Fprintf(&b, "%s\n\n", strings.Join(profile.Skills, ", "))
+	}
+	if len(profile.Experience) > 0 {
+		section("Experience")
+		for _, exp := range profile.Experience {
+			b.WriteString(experienceHeading(exp))
+			if exp.Duration != "" {
+				fmt.Fprintf(&b, " (%s)", exp.Duration)
+			}
+			b.WriteString("\n")
+			if exp.Description != "" {
+				fmt.Fprintf(&b, "  %s\n", exp.Description)
+			}
+			for _, a := range exp.Achievements {
+				fmt.Fprintf(&b, "  * %s\n", a)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(profile.Projects) > 0 {
+		section("Projects")
+		for _, proj := range profile.Projects {
+			fmt.Fprintf(&b, "* %s", proj.Name)
+			if proj.Description != "" {
+				fmt.Fprintf(&b, " - %s", proj.Description)
+			}
+			if len(proj.Technologies) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(proj.Technologies, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(profile.Education) > 0 {
+		section("Education")
+		for _, edu := range profile.Education {
+			fmt.Fprintf(&b, "* %s\n", educationLine(edu))
+		}
+		b.WriteString("\n")
+	}
+	if len(profile.Achievements) > 0 {
+		section("Achievements")
+		for _, a := range profile.Achievements {
+			fmt.Fprintf(&b, "* %s\n", a)
+		}
+	}
+
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// RenderPDF renders a profile as a single-page Letter PDF. Content that does
+// not fit on the page is dropped, lowest-priority sections first by virtue
+// of the render order.
+func RenderPDF(profile *models.UserProfile) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, pageH := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	width := pageW - left - right
+	maxY := pageH - bottom
+
+	// fits reports whether n lines of the given height still fit on the page
+	fits := func(n int, lineH float64) bool {
+		return pdf.GetY()+float64(n)*lineH <= maxY
+	}
+
+	// paragraph writes wrapped text if it fits, returning false once the page is full
+	paragraph := func(text string, style string, size float64, lineH float64, indent float64) bool {
+		pdf.SetFont("Helvetica", style, size)
+		lines := pdf.SplitLines([]byte(tr(text)), width-indent)
+		if !fits(len(lines), lineH) {
+			return false
+		}
+		for _, line := range lines {
+			pdf.SetX(left + indent)
+			pdf.CellFormat(width-indent, lineH, string(line), "", 1, "L", false, 0, "")
+		}
+		return true
+	}
+
+	heading := func(title string) bool {
+		if !fits(3, 5) {
+			return false
+		}
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(width, 5, tr(strings.ToUpper(title)), "", 1, "L", false, 0, "")
+		y := pdf.GetY()
+		pdf.SetDrawColor(160, 160, 160)
+		pdf.Line(left, y, left+width, y)
+		pdf.Ln(1)
+		return true
+	}
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(width, 9, tr(fallback(profile.Name, "Resume")), "", 1, "L", false, 0, "")
+	if contact := contactLine(profile); contact != "" {
+		paragraph(contact, "", 9, 4.5, 0)
+	}
+
+	render := func() {
+		if profile.Summary != "" {
+			if !heading("Summary") || !paragraph(profile.Summary, "", 9.5, 4.5, 0) {
+				return
+			}
+		}
+		if len(profile.Skills) > 0 {
+			if !heading("Skills") || !paragraph(strings.Join(profile.Skills, ", "), "", 9.5, 4.5, 0) {
+				return
+			}
+		}
+		if len(profile.Experience) > 0 {
+			if !heading("Experience") {
+				return
+			}
+			for _, exp := range profile.Experience {
+				title := experienceHeading(exp)
+				if exp.Duration != "" {
+					title += "  |  " + exp.Duration
+				}
+				if !paragraph(title, "B", 10, 5, 0) {
+					return
+				}
+				if exp.Description != "" && !paragraph(exp.Description, "", 9.5, 4.5, 0) {
+					return
+				}
+				for _, a := range exp.Achievements {
+					if !paragraph("- "+a, "", 9.5, 4.5, 3) {
+						return
+					}
+				}
+			}
+		}
+		if len(profile.Projects) > 0 {
+			if !heading("Projects") {
+				return
+			}
+			for _, proj := range profile.Projects {
+				line := proj.Name
+				if proj.Description != "" {
+					line += ": " + proj.Description
+				}
+				if len(proj.Technologies) > 0 {
+					line += " (" + strings.Join(proj.Technologies, ", ") + ")"
+				}
+				if !paragraph("- "+line, "", 9.5, 4.5, 0) {
+					return
+				}
+			}
+		}
+		if len(profile.Education) > 0 {
+			if !heading("Education") {
+				return
+			}
+			for _, edu := range profile.Education {
+				if !paragraph(educationLine(edu), "", 9.5, 4.5, 0) {
+					return
+				}
+			}
+		}
+		if len(profile.Achievements) > 0 {
+			if !heading("Achievements") {
+				return
+			}
+			for _, a := range profile.Achievements {
+				if !paragraph("- "+a, "", 9.5, 4.5, 0) {
+					return
+				}
+			}
+		}
+	}
+	render()
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("PDF render error: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func contactLine(profile *models.UserProfile) string {
+	parts := []string{}
+	if profile.Email != "" {
+		parts = append(parts, profile.Email)
+	}
+	if profile.Phone != "" {
+		parts = append(parts, profile.Phone)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func experienceHeading(exp models.Experience) string {
+	switch {
+	case exp.Title != "" && exp.Company != "":
+		return fmt.Sprintf("%s, %s", exp.Title, exp.Company)
+	case exp.Title != "":
+		return exp.Title
+	default:
+		return exp.Company
+	}
+}
+
+func educationLine(edu models.Education) string {
+	parts := []string{}
+	degree := strings.TrimSpace(strings.Join([]string{edu.Degree, edu.Field}, " "))
+	if edu.Degree != "" && edu.Field != "" {
+		degree = fmt.Sprintf("%s in %s", edu.Degree, edu.Field)
+	}
+	if degree != "" {
+		parts = append(parts, degree)
+	}
+	if edu.Institution != "" {
+		parts = append(parts, edu.Institution)
+	}
+	if edu.Year != "" {
+		parts = append(parts, edu.Year)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func fallback(value, def string) string {
+	if strings.TrimSpace(value) == "" {
+		return def
+	}
+	return value
+}
@@ -3,28 +3,55 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"nexus-ai/models"
 
 	"github.com/ledongthuc/pdf"
 )
 
+// ErrEmptyResume is returned when no usable text could be extracted
+var ErrEmptyResume = errors.New("no readable text found in the uploaded resume")
+
+// minExtractableChars is the number of letters or digits below which a text
+// layer is treated as empty (page numbers and stray glyphs only)
+const minExtractableChars = 40
+
 type ResumeParser struct {
 	client *AnthropicClient
+	ocr    *OCRService
 	model  string
 }
 
 func NewResumeParser() *ResumeParser {
 	return &ResumeParser{
 		client: NewAnthropicClient(),
+		ocr:    NewOCRService(),
 		model:  "claude-3-5-haiku-20241022",
 	}
 }
 
-// ExtractTextFromPDF extracts text from PDF content
+// HasExtractableText reports whether text contains enough letters or digits
+// to be worth parsing
+func HasExtractableText(text string) bool {
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+			if count >= minExtractableChars {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExtractTextFromPDF extracts text from PDF content, falling back to OCR
+// when the PDF has no usable text layer (scanned resumes)
 func (p *ResumeParser) ExtractTextFromPDF(content []byte) (string, error) {
 	reader := bytes.NewReader(content)
 
@@ -49,7 +76,22 @@ func (p *ResumeParser) ExtractTextFromPDF(content []byte) (string, error) {
 		text.WriteString("\n")
 	}
 
-	return strings.TrimSpace(text.String()), nil
+	extracted := strings.TrimSpace(text.String())
+	if HasExtractableText(extracted) {
+		return extracted, nil
+	}
+
+	fmt.Printf("[PDF] No text layer found in %d page(s), trying OCR\n", numPages)
+
+	ocrText, err := p.ocr.ExtractTextFromPDF(content, numPages)
+	if err != nil {
+		return "", err
+	}
+	if !HasExtractableText(ocrText) {
+		return "", ErrEmptyResume
+	}
+
+	return ocrText, nil
 }
 
 // ExtractTextFromDOCX extracts text from DOCX content
@@ -80,6 +122,10 @@ func (p *ResumeParser) ExtractText(content []byte, filename string) (string, err
 
 // ParseResume uses Claude to parse resume text into structured data
 func (p *ResumeParser) ParseResume(resumeText string) (*models.UserProfile, error) {
+	if !HasExtractableText(resumeText) {
+		return nil, ErrEmptyResume
+	}
+
 	systemPrompt := `You are an expert resume parser. Extract structured information from the resume text.
 
 Return a JSON object with these fields:
@@ -104,6 +150,7 @@ Be thorough and extract all relevant information. If a field is not found, use a
 		Messages: []MessageInput{
 			{Role: "user", Content: userMessage},
 		},
+		Purpose: "resume_parse",
 	})
 
 	if err != nil {
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"nexus-ai/models"
+)
+
+type ResumeTailor struct {
+	client *AnthropicClient
+	model  string
+}
+
+func NewResumeTailor() *ResumeTailor {
+	return &ResumeTailor{
+		client: NewAnthropicClient(),
+		model:  "claude-3-5-haiku-20241022",
+	}
+}
+
+// TailorProfile returns a copy of the profile tuned for a job description.
+// Skills, projects and experience bullets are first reordered by keyword
+// overlap with the JD; Claude then rewords bullets and the summary. If the
+// rewording call fails the reordered copy is still returned.
+func (t *ResumeTailor) TailorProfile(profile *models.UserProfile, jobDescription string) (*models.UserProfile, error) {
+	tailored, err := CloneProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := extractKeywords(jobDescription)
+	reorderByRelevance(tailored, keywords)
+
+	if err := t.reword(tailored, jobDescription); err != nil {
+		fmt.Printf("[TAILOR] Rewording skipped: %v\n", err)
+	}
+
+	return tailored, nil
+}
+
+// CloneProfile deep-copies a profile
+func CloneProfile(profile *models.UserProfile) (*models.UserProfile, error) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy profile: %w", err)
+	}
+	var clone models.UserProfile
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy profile: %w", err)
+	}
+	return &clone, nil
+}
+
+// reorderByRelevance stable-sorts skills, projects and each experience's
+// achievements so the entries sharing the most JD keywords come first.
+// Experience entries themselves keep their chronological order.
+func reorderByRelevance(profile *models.UserProfile, keywords map[string]int) {
+	sortByScore(profile.Skills, func(s string) int { return keywordScore(s, keywords) })
+
+	for i := range profile.Experience {
+		sortByScore(profile.Experience[i].Achievements, func(s string) int { return keywordScore(s, keywords) })
+	}
+
+	sort.SliceStable(profile.Projects, func(i, j int) bool {
+		return projectScore(profile.Projects[i], keywords) > projectScore(profile.Projects[j], keywords)
+	})
+}
+
+func projectScore(p models.Project, keywords map[string]int) int {
+	return keywordScore(p.Name+" "+p.Description+" "+strings.Join(p.Technologies, " "), keywords)
+}
+
+func sortByScore(items []string, score func(string) int) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return score(items[i]) > score(items[j])
+	})
+}
+
+// reword asks Claude to rephrase bullets and the summary for the target
+// role. Only existing entries are replaced; nothing new is added.
+func (t *ResumeTailor) reword(profile *models.UserProfile, jobDescription string) error {
+	type experienceInput struct {
+		Index        int      `json:"index"`
+		Title        string   `json:"title"`
+		Company      string   `json:"company"`
+		Achievements []string `json:"achievements"`
+	}
+
+	input := struct {
+		Summary    string            `json:"summary"`
+		Skills     []string          `json:"skills"`
+		Experience []experienceInput `json:"experience"`
+	}{
+		Summary: profile.Summary,
+		Skills:  profile.Skills,
+	}
+	for i, exp := range profile.Experience {
+		input.Experience = append(input.Experience, experienceInput{
+			Index:        i,
+			Title:        exp.Title,
+			Company:      exp.Company,
+			Achievements: exp.Achievements,
+		})
+	}
+	inputJSON, _ := json.MarshalIndent(input, "", "  ")
+
+	jd := truncateUTF8(jobDescription, 4000)
+
+	systemPrompt := `You are an expert resume writer tailoring a resume to a specific job.
+
+Rules:
+- Reword existing bullets to use the job description's terminology where it is truthful
+- Lead each bullet with impact and keep any numbers exactly as given
+- Never invent employers, titles, metrics, technologies or achievements
+- Keep the same number of bullets per experience entry
+- Order skills by relevance to the job; only use skills from the input list
+
+Respond in JSON format with these fields:
+- summary: A 2-3 sentence professional summary aimed at this role
+- skills: The input skills, reordered by relevance
+- experience: Array of objects with index and achievements (rewritten bullets)`
+
+	userMessage := fmt.Sprintf("JOB DESCRIPTION:\n%s\n\nRESUME CONTENT:\n%s", jd, string(inputJSON))
+
+	resp, err := t.client.CreateMessage(MessageRequest{
+		Model:     t.model,
+		MaxTokens: 3000,
+		System:    systemPrompt,
+		Messages: []MessageInput{
+			{Role: "user", Content: userMessage},
+		},
+		Redactor: NewProfileRedactor(profile),
+		Purpose:  "resume_tailor",
+	})
+	if err != nil {
+		return fmt.Errorf("claude API error: %w", err)
+	}
+
+	responseText := resp.GetText()
+	start := strings.Index(responseText, "{")
+	end := strings.LastIndex(responseText, "}") + 1
+	if start == -1 || end <= start {
+		return fmt.Errorf("no JSON in response")
+	}
+
+	var parsed struct {
+		Summary    string   `json:"summary"`
+		Skills     []string `json:"skills"`
+		Experience []struct {
+			Index        int      `json:"index"`
+			Achievements []string `json:"achievements"`
+		} `json:"experience"`
+	}
+	if err := json.Unmarshal([]byte(responseText[start:end]), &parsed); err != nil {
+		return fmt.Errorf("invalid JSON in response: %w", err)
+	}
+
+	if parsed.Summary != "" {
+		profile.Summary = parsed.Summary
+	}
+
+	// Accept the new skill order only if it is a permutation of the original
+	if len(parsed.Skills) == len(profile.Skills) {
+		original := make(map[string]int)
+		for _, s := range profile.Skills {
+			original[strings.ToLower(s)]++
+		}
+		valid := true
+		for _, s := range parsed.Skills {
+			key := strings.ToLower(s)
+			if original[key] == 0 {
+				valid = false
+				break
+			}
+			original[key]--
+		}
+		if valid {
+			profile.Skills = parsed.Skills
+		}
+	}
+
+	for _, exp := range parsed.Experience {
+		if exp.Index < 0 || exp.Index >= len(profile.Experience) {
+			continue
+		}
+		if len(exp.Achievements) != len(profile.Experience[exp.Index].Achievements) {
+			continue
+		}
+		profile.Experience[exp.Index].Achievements = exp.Achievements
+	}
+
+	return nil
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"nexus-ai/config"
+	"nexus-ai/models"
+)
+
+// Skill categories used by the bundled taxonomy
+const (
+	SkillCategoryLanguage  = "language"
+	SkillCategoryFramework = "framework"
+	SkillCategoryCloud     = "cloud"
+	SkillCategoryDatabase  = "database"
+	SkillCategoryTool      = "tool"
+	SkillCategoryPractice  = "practice"
+	SkillCategoryOther     = "other"
+)
+
+//go:embed data/skills_taxonomy.json
+var bundledSkillTaxonomy []byte
+
+// SkillEntry is one canonical skill in the taxonomy
+type SkillEntry struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases,omitempty"`
+	// Strict entries are short or ambiguous names ("Go", "R") whose
+	// canonical name only matches case-sensitively in free text
+	Strict bool `json:"strict,omitempty"`
+}
+
+// SkillTaxonomy maps free-form skill strings onto canonical skills
+type SkillTaxonomy struct {
+	mu       sync.RWMutex
+	entries  map[string]*SkillEntry // lowercase canonical name -> entry
+	aliases  map[string]string      // lowercase alias or name -> lowercase canonical name
+	mentions *regexp.Regexp         // lazily built free-text matcher
+}
+
+var (
+	taxonomyInstance *SkillTaxonomy
+	taxonomyOnce     sync.Once
+)
+
+// DefaultSkillTaxonomy returns the bundled taxonomy, extended with the file
+// at SKILLS_TAXONOMY_FILE when one is configured
+func DefaultSkillTaxonomy() *SkillTaxonomy {
+	taxonomyOnce.Do(func() {
+		taxonomyInstance = NewSkillTaxonomy()
+		if err := taxonomyInstance.LoadJSON(bundledSkillTaxonomy); err != nil {
+			fmt.Printf("[SKILLS] Bundled taxonomy invalid: %v\n", err)
+		}
+
+		if path := config.GetConfig().SkillsTaxonomyFile; path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Printf("[SKILLS] Could not read %s: %v\n", path, err)
+				return
+			}
+			if err := taxonomyInstance.LoadJSON(data); err != nil {
+				fmt.Printf("[SKILLS] Could not load %s: %v\n", path, err)
+			}
+		}
+	})
+	return taxonomyInstance
+}
+
+func NewSkillTaxonomy() *SkillTaxonomy {
+	return &SkillTaxonomy{
+		entries: make(map[string]*SkillEntry),
+		aliases: make(map[string]string),
+	}
+}
+
+// LoadJSON merges a {"skills": [...]} document into the taxonomy. Entries
+// with an existing name replace the old category and extend its aliases.
+func (t *SkillTaxonomy) LoadJSON(data []byte) error {
+	var doc struct {
+		Skills []SkillEntry `json:"skills"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for _, entry := range doc.Skills {
+		t.Register(entry)
+	}
+	return nil
+}
+
+// Register adds or extends a skill entry
+func (t *SkillTaxonomy) Register(entry SkillEntry) {
+	name := strings.TrimSpace(entry.Name)
+	if name == "" {
+		return
+	}
+	key := strings.ToLower(name)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing, ok := t.entries[key]
+	if !ok {
+		existing = &SkillEntry{Name: name}
+		t.entries[key] = existing
+	}
+	if entry.Category != "" {
+		existing.Category = entry.Category
+	}
+	existing.Strict = existing.Strict || entry.Strict
+	existing.Aliases = append(existing.Aliases, entry.Aliases...)
+
+	t.aliases[key] = key
+	for _, alias := range entry.Aliases {
+		t.aliases[strings.ToLower(strings.TrimSpace(alias))] = key
+	}
+	t.mentions = nil
+}
+
+// Lookup returns the entry for a canonical name or alias
+func (t *SkillTaxonomy) Lookup(name string) (*SkillEntry, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	key, ok := t.aliases[normalizeSkillKey(name)]
+	if !ok {
+		return nil, false
+	}
+	return t.entries[key], true
+}
+
+// Category returns the category of a skill, or "other" when unknown
+func (t *SkillTaxonomy) Category(name string) string {
+	if entry, ok := t.Lookup(name); ok && entry.Category != "" {
+		return entry.Category
+	}
+	return SkillCategoryOther
+}
+
+var skillListSeparators = regexp.MustCompile(`\s*[,;|]\s*`)
+var skillQualifier = regexp.MustCompile(`\(([^)]*)\)`)
+
+// Canonicalize turns one free-form skill string into canonical skill names.
+// "kubernetes (EKS)" yields Kubernetes and Amazon EKS; "Python, Go" is split;
+// unknown skills are kept as written, trimmed.
+func (t *SkillTaxonomy) Canonicalize(raw string) []string {
+	names := []string{}
+	for _, part := range skillListSeparators.Split(raw, -1) {
+		qualifiers := skillQualifier.FindAllStringSubmatch(part, -1)
+		base := strings.TrimSpace(skillQualifier.ReplaceAllString(part, ""))
+
+		if base != "" {
+			if entry, ok := t.Lookup(base); ok {
+				names = append(names, entry.Name)
+			} else {
+				names = append(names, base)
+			}
+		}
+
+		// Only keep qualifiers that are skills in their own right; "(basic)"
+		// or "(5 years)" are dropped
+		for _, q := range qualifiers {
+			for _, inner := range skillListSeparators.Split(q[1], -1) {
+				if entry, ok := t.Lookup(inner); ok {
+					names = append(names, entry.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// NormalizeSkills canonicalizes and de-duplicates a skill list, keeping the
+// order in which skills first appear
+func (t *SkillTaxonomy) NormalizeSkills(skills []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, raw := range skills {
+		for _, name := range t.Canonicalize(raw) {
+			key := strings.ToLower(name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// FindMentions returns the canonical skills mentioned anywhere in free text,
+// in order of first mention
+func (t *SkillTaxonomy) FindMentions(text string) []string {
+	re := t.mentionPattern()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	found := []string{}
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if !isTermBoundary(text, start-1) || !isTermBoundary(text, end) {
+			continue
+		}
+		match := text[start:end]
+		key, ok := t.aliases[strings.ToLower(match)]
+		if !ok {
+			continue
+		}
+		entry := t.entries[key]
+		if entry.Strict && strings.EqualFold(match, entry.Name) && match != entry.Name {
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			found = append(found, entry.Name)
+		}
+	}
+	return found
+}
+
+// ReplaceAliases rewrites alias mentions in free text to canonical names so
+// keyword matching treats "k8s" and "Kubernetes" as the same term
+func (t *SkillTaxonomy) ReplaceAliases(text string) string {
+	re := t.mentionPattern()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if !isTermBoundary(text, start-1) || !isTermBoundary(text, end) {
+			continue
+		}
+		match := text[start:end]
+		entry := t.entries[t.aliases[strings.ToLower(match)]]
+		if entry == nil || (entry.Strict && strings.EqualFold(match, entry.Name) && match != entry.Name) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(entry.Name)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// mentionPattern builds (once per taxonomy change) a case-insensitive
+// alternation of every name and alias, longest first so "google cloud
+// platform" wins over "google cloud"
+func (t *SkillTaxonomy) mentionPattern() *regexp.Regexp {
+	t.mu.RLock()
+	re := t.mentions
+	t.mu.RUnlock()
+	if re != nil {
+		return re
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	terms := make([]string, 0, len(t.aliases))
+	for alias := range t.aliases {
+		terms = append(terms, alias)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	for i, term := range terms {
+		terms[i] = regexp.QuoteMeta(term)
+	}
+
+	t.mentions = regexp.MustCompile(`(?i)(?:` + strings.Join(terms, "|") + `)`)
+	return t.mentions
+}
+
+// isTermBoundary reports whether the byte at i separates terms. Characters
+// that belong to tech names (c++, c#, node.js) do not count as boundaries.
+func isTermBoundary(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return true
+	}
+	r := rune(text[i])
+	if r >= 0x80 {
+		return false
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return false
+	}
+	switch r {
+	case '+', '#', '_':
+		return false
+	case '.':
+		// a trailing full stop ends a sentence; an inner dot is part of a name
+		return i+1 >= len(text) || text[i+1] == ' ' || text[i+1] == '\n'
+	}
+	return true
+}
+
+func normalizeSkillKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// proficiencyForYears maps years of use onto a coarse proficiency level
+func proficiencyForYears(years float64) string {
+	switch {
+	case years <= 0:
+		return ""
+	case years < 1:
+		return "beginner"
+	case years < 3:
+		return "intermediate"
+	case years < 6:
+		return "advanced"
+	default:
+		return "expert"
+	}
+}
+
+// NormalizeProfileSkills canonicalizes profile.Skills and rebuilds
+// profile.SkillDetails with categories and, where skillYears has an entry
+// (see BuildTimeline), years of use and a derived proficiency. Proficiency
+// set explicitly by the user is preserved.
+func NormalizeProfileSkills(profile *models.UserProfile, skillYears map[string]float64) {
+	taxonomy := DefaultSkillTaxonomy()
+
+	profile.Skills = taxonomy.NormalizeSkills(profile.Skills)
+	for i := range profile.Projects {
+		profile.Projects[i].Technologies = taxonomy.NormalizeSkills(profile.Projects[i].Technologies)
+	}
+
+	userProficiency := make(map[string]string)
+	for _, d := range profile.SkillDetails {
+		if d.Proficiency != "" && d.ProficiencySource == "user" {
+			for _, name := range taxonomy.Canonicalize(d.Name) {
+				userProficiency[strings.ToLower(name)] = d.Proficiency
+			}
+		}
+	}
+
+	years := make(map[string]float64)
+	for name, y := range skillYears {
+		years[strings.ToLower(name)] = y
+	}
+
+	details := make([]models.Skill, 0, len(profile.Skills))
+	for _, name := range profile.Skills {
+		key := strings.ToLower(name)
+		detail := models.Skill{
+			Name:     name,
+			Category: taxonomy.Category(name),
+			Years:    years[key],
+		}
+		if p, ok := userProficiency[key]; ok {
+			detail.Proficiency = p
+			detail.ProficiencySource = "user"
+		} else if p := proficiencyForYears(detail.Years); p != "" {
+			detail.Proficiency = p
+			detail.ProficiencySource = "derived"
+		}
+		details = append(details, detail)
+	}
+	profile.SkillDetails = details
+}
+
+// Keywords that signal which skill category a question is about
+var categoryHints = map[string][]string{
+	SkillCategoryDatabase: {"database", "databases", "sql", "query", "queries", "schema", "index", "replication", "sharding", "data store", "cache"},
+	SkillCategoryCloud:    {"cloud", "infrastructure", "cluster", "clusters", "container", "containers", "deploy", "deployment", "scaling", "autoscaling", "serverless", "network"},
+	SkillCategoryLanguage: {"language", "languages", "code", "coding", "programming", "write", "algorithm", "concurrency"},
+	SkillCategoryTool:     {"pipeline", "pipelines", "tool", "tools", "tooling", "automation", "monitoring", "alerting", "provision", "provisioning"},
+	SkillCategoryPractice: {"process", "team", "incident", "outage", "on-call", "agile", "testing", "review", "design", "architecture", "mentor", "lead"},
+}
+
+// SelectRelevantSkills picks the skills most relevant to a question and,
+// optionally, a target job. Skills named in the question rank first, then
+// JD requirements, then skills in a category the question is about; ties
+// keep the profile's original order.
+func SelectRelevantSkills(skills []string, question string, jd *models.JobDescription, limit int) []string {
+	taxonomy := DefaultSkillTaxonomy()
+	normalized := taxonomy.NormalizeSkills(skills)
+
+	score := make(map[string]int)
+	for _, name := range taxonomy.FindMentions(question) {
+		score[strings.ToLower(name)] += 10
+	}
+	if jd != nil {
+		for _, name := range taxonomy.FindMentions(strings.Join(jd.RequiredSkills, ", ")) {
+			score[strings.ToLower(name)] += 5
+		}
+		for _, name := range taxonomy.FindMentions(strings.Join(jd.NiceToHaveSkills, ", ")) {
+			score[strings.ToLower(name)] += 3
+		}
+	}
+
+	questionTokens := make(map[string]bool)
+	for _, tok := range tokenize(question) {
+		questionTokens[tok] = true
+	}
+	lowerQuestion := strings.ToLower(question)
+	for _, name := range normalized {
+		category := taxonomy.Category(name)
+		for _, hint := range categoryHints[category] {
+			if questionTokens[hint] || (strings.Contains(hint, " ") && strings.Contains(lowerQuestion, hint)) {
+				score[strings.ToLower(name)] += 2
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return score[strings.ToLower(normalized[i])] > score[strings.ToLower(normalized[j])]
+	})
+
+	if limit > 0 && len(normalized) > limit {
+		normalized = normalized[:limit]
+	}
+	return normalized
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nexus-ai/models"
+)
+
+// minGapMonths is the shortest break between roles reported as a gap
+const minGapMonths = 3
+
+// monthIndex counts months since year 0 (year*12 + month-1) so spans can be
+// compared and subtracted as plain integers
+type monthIndex int
+
+func newMonthIndex(year, month int) monthIndex {
+	return monthIndex(year*12 + month - 1)
+}
+
+func monthIndexOf(t time.Time) monthIndex {
+	return newMonthIndex(t.Year(), int(t.Month()))
+}
+
+func (m monthIndex) year() int  { return int(m) / 12 }
+func (m monthIndex) month() int { return int(m)%12 + 1 }
+
+// String formats the index as YYYY-MM
+func (m monthIndex) String() string {
+	return fmt.Sprintf("%04d-%02d", m.year(), m.month())
+}
+
+func parseMonthIndex(s string) (monthIndex, bool) {
+	var y, mo int
+	if _, err := fmt.Sscanf(s, "%d-%d", &y, &mo); err != nil || mo < 1 || mo > 12 {
+		return 0, false
+	}
+	return newMonthIndex(y, mo), true
+}
+
+// DateRange is a parsed duration. Ranges are half-open in months: End is the
+// first month after the period.
+type DateRange struct {
+	Start     monthIndex
+	End       monthIndex
+	IsCurrent bool
+}
+
+// Months returns the length of the range in months
+func (r DateRange) Months() int {
+	return int(r.End - r.Start)
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "sept": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// dateToken matches, in order of preference: "Jan 2020", "01/2020",
+// "2020-01", a bare year, or a word meaning the role is ongoing
+var dateToken = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sept?|oct|nov|dec)[a-z]*\.?,?\s+((?:19|20)\d{2})\b` +
+	`|\b(\d{1,2})[/.]((?:19|20)\d{2})\b` +
+	`|\b((?:19|20)\d{2})[-/.](\d{1,2})\b` +
+	`|\b((?:19|20)\d{2})\b` +
+	`|\b(present|current|currently|now|today|ongoing|date)\b`)
+
+// dateValue is one parsed token: a month index plus whether only the year
+// was given, or a marker for "present"
+type dateValue struct {
+	index    monthIndex
+	yearOnly bool
+	present  bool
+}
+
+func parseDateTokens(s string) []dateValue {
+	values := []dateValue{}
+	for _, m := range dateToken.FindAllStringSubmatch(s, -1) {
+		switch {
+		case m[1] != "":
+			key := strings.ToLower(m[1])
+			if len(key) > 3 && key != "sept" {
+				key = key[:3]
+			}
+			y, _ := strconv.Atoi(m[2])
+			values = append(values, dateValue{index: newMonthIndex(y, monthNames[key])})
+		case m[3] != "":
+			mo, _ := strconv.Atoi(m[3])
+			y, _ := strconv.Atoi(m[4])
+			if mo < 1 || mo > 12 {
+				continue
+			}
+			values = append(values, dateValue{index: newMonthIndex(y, mo)})
+		case m[5] != "":
+			y, _ := strconv.Atoi(m[5])
+			mo, _ := strconv.Atoi(m[6])
+			if mo < 1 || mo > 12 {
+				// "2019-2021" style ranges are two years, not year-month
+				values = append(values, dateValue{index: newMonthIndex(y, 1), yearOnly: true})
+				continue
+			}
+			values = append(values, dateValue{index: newMonthIndex(y, mo)})
+		case m[7] != "":
+			y, _ := strconv.Atoi(m[7])
+			values = append(values, dateValue{index: newMonthIndex(y, 1), yearOnly: true})
+		case m[8] != "":
+			values = append(values, dateValue{present: true})
+		}
+	}
+	return values
+}
+
+// ParseDateRange parses free-text durations such as "Jan 2020 - Present",
+// "03/2018 – 11/2019", "2016-2018", "Since 2021" or "2019". A year-only end
+// date counts up to the start of that year, so "2019 - 2021" is two years;
+// a month end date includes that month.
+func ParseDateRange(s string, now time.Time) (DateRange, bool) {
+	values := parseDateTokens(s)
+	if len(values) == 0 || values[0].present {
+		return DateRange{}, false
+	}
+
+	start := values[0]
+	current := monthIndexOf(now) + 1
+	lower := strings.ToLower(s)
+
+	var r DateRange
+	r.Start = start.index
+
+	switch {
+	case len(values) >= 2 && values[1].present:
+		r.End, r.IsCurrent = current, true
+	case len(values) >= 2:
+		end := values[1]
+		r.End = end.index + 1
+		if end.yearOnly {
+			r.End = end.index
+			if r.End <= r.Start {
+				// "Mar 2020 - 2020": the role ended within that year
+				r.End = newMonthIndex(end.index.year()+1, 1)
+			}
+		}
+	case strings.Contains(lower, "since") || strings.Contains(lower, "present"):
+		r.End, r.IsCurrent = current, true
+	case start.yearOnly:
+		r.End = start.index + 12
+	default:
+		r.End = start.index + 1
+	}
+
+	if r.End > current {
+		r.End = current
+	}
+	if r.End <= r.Start {
+		return DateRange{}, false
+	}
+
+	return r, true
+}
+
+// formatMonth renders a month index as "Jan 2020"
+func formatMonth(m monthIndex) string {
+	return time.Date(m.year(), time.Month(m.month()), 1, 0, 0, 0, 0, time.UTC).Format("Jan 2006")
+}
+
+// NormalizeProfileDates keeps the structured StartDate/EndDate/IsCurrent of
+// experience and education entries in step with their free-text Duration and
+// Year. Empty structured dates are parsed from the free text. Once set they
+// win: free text that is missing or says otherwise, as after an edit of the
+// structured dates alone, is generated from them.
+func NormalizeProfileDates(profile *models.UserProfile, now time.Time) {
+	for i := range profile.Experience {
+		exp := &profile.Experience[i]
+		r, parsed := ParseDateRange(exp.Duration, now)
+		start, ok := parseMonthIndex(exp.StartDate)
+		switch {
+		case !ok && parsed:
+			exp.StartDate = r.Start.String()
+			exp.IsCurrent = r.IsCurrent
+			exp.EndDate = ""
+			if !r.IsCurrent {
+				exp.EndDate = (r.End - 1).String()
+			}
+		case !ok:
+		case exp.Duration == "" || (parsed && !sameExperienceDates(*exp, r)):
+			end := "Present"
+			if e, ok := parseMonthIndex(exp.EndDate); ok && !exp.IsCurrent {
+				end = formatMonth(e)
+			}
+			exp.Duration = formatMonth(start) + " - " + end
+		}
+	}
+
+	for i := range profile.Education {
+		edu := &profile.Education[i]
+		r, parsed := ParseDateRange(edu.Year, now)
+		start, ok := parseMonthIndex(edu.StartDate)
+		switch {
+		case !ok && parsed:
+			edu.StartDate = r.Start.String()
+			edu.EndDate = (r.End - 1).String()
+		case !ok:
+		case edu.Year == "" || (parsed && (r.Start != start || (r.End-1).String() != edu.EndDate)):
+			edu.Year = formatMonth(start)
+			if end, ok := parseMonthIndex(edu.EndDate); ok && end > start {
+				edu.Year += " - " + formatMonth(end)
+			}
+		}
+	}
+}
+
+// sameExperienceDates reports whether an entry's structured dates are those
+// parsed from its free text
+func sameExperienceDates(exp models.Experience, r DateRange) bool {
+	if r.Start.String() != exp.StartDate || r.IsCurrent != exp.IsCurrent {
+		return false
+	}
+	return r.IsCurrent || (r.End-1).String() == exp.EndDate
+}
+
+// experienceRange returns the parsed range of an experience entry, preferring
+// the structured dates and falling back to the free-text duration
+func experienceRange(exp models.Experience, now time.Time) (DateRange, bool) {
+	current := monthIndexOf(now) + 1
+	if start, ok := parseMonthIndex(exp.StartDate); ok {
+		if exp.IsCurrent {
+			return DateRange{Start: start, End: current, IsCurrent: true}, current > start
+		}
+		if end, ok := parseMonthIndex(exp.EndDate); ok && end >= start {
+			return DateRange{Start: start, End: end + 1}, true
+		}
+	}
+	return ParseDateRange(exp.Duration, now)
+}
+
+// BuildTimeline computes the career timeline of a profile: dated entries,
+// total years (overlapping roles counted once), years per skill, gaps of
+// minGapMonths or more between roles, and overlapping roles
+func BuildTimeline(profile *models.UserProfile, now time.Time) *models.ProfileTimeline {
+	timeline := &models.ProfileTimeline{
+		Entries:    []models.TimelineEntry{},
+		SkillYears: map[string]float64{},
+		Gaps:       []models.TimelinePeriod{},
+		Overlaps:   []models.TimelineOverlap{},
+	}
+
+	type dated struct {
+		label string
+		r     DateRange
+	}
+	roles := []dated{}
+	skillSpans := map[string][]DateRange{}
+	taxonomy := DefaultSkillTaxonomy()
+
+	for i, exp := range profile.Experience {
+		label := experienceHeading(exp)
+		r, ok := experienceRange(exp, now)
+		if !ok {
+			if exp.Duration != "" || label != "" {
+				timeline.Unparsed = append(timeline.Unparsed, strings.TrimSpace(label+" "+exp.Duration))
+			}
+			continue
+		}
+
+		timeline.Entries = append(timeline.Entries, timelineEntry("experience", i, label, r))
+		roles = append(roles, dated{label, r})
+
+		text := exp.Title + " " + exp.Description + " " + strings.Join(exp.Achievements, " ")
+		for _, skill := range taxonomy.FindMentions(text) {
+			skillSpans[skill] = append(skillSpans[skill], r)
+		}
+	}
+
+	for i, edu := range profile.Education {
+		r, ok := ParseDateRange(edu.Year, now)
+		if !ok {
+			continue
+		}
+		label := strings.TrimSpace(strings.Join([]string{edu.Degree, edu.Institution}, ", "))
+		timeline.Entries = append(timeline.Entries, timelineEntry("education", i, strings.Trim(label, ", "), r))
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].Start < timeline.Entries[j].Start
+	})
+
+	ranges := make([]DateRange, 0, len(roles))
+	for _, role := range roles {
+		ranges = append(ranges, role.r)
+	}
+	merged := mergeRanges(ranges)
+	timeline.TotalYears = monthsToYears(totalMonths(merged))
+
+	for skill, spans := range skillSpans {
+		timeline.SkillYears[skill] = monthsToYears(totalMonths(mergeRanges(spans)))
+	}
+
+	for i := 1; i < len(merged); i++ {
+		gap := int(merged[i].Start - merged[i-1].End)
+		if gap >= minGapMonths {
+			timeline.Gaps = append(timeline.Gaps, models.TimelinePeriod{
+				Start:  merged[i-1].End.String(),
+				End:    (merged[i].Start - 1).String(),
+				Months: gap,
+			})
+		}
+	}
+	// A break since the last role ended is a gap too
+	if n := len(merged); n > 0 {
+		current := monthIndexOf(now) + 1
+		if gap := int(current - merged[n-1].End); gap >= minGapMonths {
+			timeline.Gaps = append(timeline.Gaps, models.TimelinePeriod{
+				Start:  merged[n-1].End.String(),
+				End:    (current - 1).String(),
+				Months: gap,
+			})
+		}
+	}
+
+	for i := 0; i < len(roles); i++ {
+		for j := i + 1; j < len(roles); j++ {
+			start := roles[i].r.Start
+			if roles[j].r.Start > start {
+				start = roles[j].r.Start
+			}
+			end := roles[i].r.End
+			if roles[j].r.End < end {
+				end = roles[j].r.End
+			}
+			if end > start {
+				timeline.Overlaps = append(timeline.Overlaps, models.TimelineOverlap{
+					Start:   start.String(),
+					End:     (end - 1).String(),
+					Months:  int(end - start),
+					Entries: []string{roles[i].label, roles[j].label},
+				})
+			}
+		}
+	}
+
+	return timeline
+}
+
+func timelineEntry(kind string, index int, label string, r DateRange) models.TimelineEntry {
+	entry := models.TimelineEntry{
+		Kind:      kind,
+		Index:     index,
+		Label:     label,
+		Start:     r.Start.String(),
+		IsCurrent: r.IsCurrent,
+		Months:    r.Months(),
+	}
+	if !r.IsCurrent {
+		entry.End = (r.End - 1).String()
+	}
+	return entry
+}
+
+// mergeRanges unions overlapping or touching ranges
+func mergeRanges(ranges []DateRange) []DateRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]DateRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := []DateRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			last.IsCurrent = last.IsCurrent || r.IsCurrent
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func totalMonths(ranges []DateRange) int {
+	total := 0
+	for _, r := range ranges {
+		total += r.Months()
+	}
+	return total
+}
+
+func monthsToYears(months int) float64 {
+	return math.Round(float64(months)/12*10) / 10
+}
+
+var seniorityQuestion = regexp.MustCompile(`(?i)\b(how many years|how long have you|years of experience|seniority|senior|junior|level|how experienced|experience level|been (doing|working))\b`)
+
+// SeniorityContext summarizes years of experience for prompts. It returns
+// an empty string unless the question or the target JD is about seniority.
+func SeniorityContext(profile *models.UserProfile, question string, jd *models.JobDescription) string {
+	if profile == nil {
+		return ""
+	}
+	if !seniorityQuestion.MatchString(question) && (jd == nil || jd.Seniority == "") {
+		return ""
+	}
+
+	timeline := BuildTimeline(profile, time.Now())
+	if timeline.TotalYears == 0 {
+		return ""
+	}
+
+	parts := []string{fmt.Sprintf("%.1f years total", timeline.TotalYears)}
+	for _, skill := range SelectRelevantSkills(profile.Skills, question, jd, 5) {
+		if years := timeline.SkillYears[skill]; years > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %.1f years", skill, years))
+		}
+	}
+
+	summary := "Experience: " + strings.Join(parts, "; ")
+	if jd != nil && jd.Seniority != "" {
+		summary += fmt.Sprintf("\nTarget seniority: %s", jd.Seniority)
+	}
+	return summary
+}
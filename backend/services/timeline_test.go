@@ -0,0 +1,150 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"nexus-ai/models"
+)
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in        string
+		start     string
+		end       string // first month after the range
+		months    int
+		isCurrent bool
+	}{
+		{"Jan 2020 - Present", "2020-01", "2024-07", 54, true},
+		{"January 2020 – Current", "2020-01", "2024-07", 54, true},
+		{"03/2018 – 11/2019", "2018-03", "2019-12", 21, false},
+		{"2018-03 to 2019-11", "2018-03", "2019-12", 21, false},
+		{"Sept. 2015 - Jun 2016", "2015-09", "2016-07", 10, false},
+		{"2016-2018", "2016-01", "2018-01", 24, false},
+		{"2019 - 2021", "2019-01", "2021-01", 24, false},
+		{"Mar 2020 - 2020", "2020-03", "2021-01", 10, false},
+		{"Since 2021", "2021-01", "2024-07", 42, true},
+		{"2019", "2019-01", "2020-01", 12, false},
+		{"May 2022", "2022-05", "2022-06", 1, false},
+		// Future end dates are capped at the current month
+		{"Jan 2024 - Dec 2025", "2024-01", "2024-07", 6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, ok := ParseDateRange(tt.in, now)
+			if !ok {
+				t.Fatal("not parsed")
+			}
+			if r.Start.String() != tt.start || r.End.String() != tt.end {
+				t.Errorf("range = %s to %s, want %s to %s", r.Start, r.End, tt.start, tt.end)
+			}
+			if r.Months() != tt.months {
+				t.Errorf("Months() = %d, want %d", r.Months(), tt.months)
+			}
+			if r.IsCurrent != tt.isCurrent {
+				t.Errorf("IsCurrent = %v, want %v", r.IsCurrent, tt.isCurrent)
+			}
+		})
+	}
+}
+
+func TestParseDateRangeRejects(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, in := range []string{
+		"",
+		"a few years",
+		"Present",
+		"13/2020",
+		"Dec 2021 - Jan 2020",
+		"2030",
+	} {
+		t.Run(in, func(t *testing.T) {
+			if r, ok := ParseDateRange(in, now); ok {
+				t.Fatalf("parsed as %s to %s", r.Start, r.End)
+			}
+		})
+	}
+}
+
+func TestNormalizeProfileDates(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   models.Experience
+		want models.Experience
+	}{
+		{
+			"dates parsed from the duration",
+			models.Experience{Duration: "Jan 2020 - Mar 2022"},
+			models.Experience{Duration: "Jan 2020 - Mar 2022", StartDate: "2020-01", EndDate: "2022-03"},
+		},
+		{
+			"current role",
+			models.Experience{Duration: "2021 - Present"},
+			models.Experience{Duration: "2021 - Present", StartDate: "2021-01", IsCurrent: true},
+		},
+		{
+			"duration generated from the dates",
+			models.Experience{StartDate: "2019-04", EndDate: "2020-02"},
+			models.Experience{Duration: "Apr 2019 - Feb 2020", StartDate: "2019-04", EndDate: "2020-02"},
+		},
+		{
+			"matching dates left alone",
+			models.Experience{Duration: "03/2018 – 11/2019", StartDate: "2018-03", EndDate: "2019-11"},
+			models.Experience{Duration: "03/2018 – 11/2019", StartDate: "2018-03", EndDate: "2019-11"},
+		},
+		{
+			"edited end date kept",
+			models.Experience{Duration: "Jan 2020 - Mar 2022", StartDate: "2020-01", EndDate: "2022-09"},
+			models.Experience{Duration: "Jan 2020 - Sep 2022", StartDate: "2020-01", EndDate: "2022-09"},
+		},
+		{
+			"edited to current kept",
+			models.Experience{Duration: "Jan 2020 - Mar 2022", StartDate: "2020-01", IsCurrent: true},
+			models.Experience{Duration: "Jan 2020 - Present", StartDate: "2020-01", IsCurrent: true},
+		},
+		{
+			"free text that does not parse kept",
+			models.Experience{Duration: "a few years", StartDate: "2020-01", EndDate: "2022-03"},
+			models.Experience{Duration: "a few years", StartDate: "2020-01", EndDate: "2022-03"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := &models.UserProfile{Experience: []models.Experience{tt.in}}
+			NormalizeProfileDates(profile, now)
+			if got := profile.Experience[0]; got.Duration != tt.want.Duration || got.StartDate != tt.want.StartDate ||
+				got.EndDate != tt.want.EndDate || got.IsCurrent != tt.want.IsCurrent {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEducationDates(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   models.Education
+		want models.Education
+	}{
+		{"dates parsed from the year", models.Education{Year: "2016-2020"}, models.Education{Year: "2016-2020", StartDate: "2016-01", EndDate: "2019-12"}},
+		{"matching dates left alone", models.Education{Year: "2019", StartDate: "2019-01", EndDate: "2019-12"}, models.Education{Year: "2019", StartDate: "2019-01", EndDate: "2019-12"}},
+		{"edited dates kept", models.Education{Year: "2016-2020", StartDate: "2016-09", EndDate: "2020-06"}, models.Education{Year: "Sep 2016 - Jun 2020", StartDate: "2016-09", EndDate: "2020-06"}},
+		{"year generated from the dates", models.Education{StartDate: "2018-09"}, models.Education{Year: "Sep 2018", StartDate: "2018-09"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := &models.UserProfile{Education: []models.Education{tt.in}}
+			NormalizeProfileDates(profile, now)
+			if got := profile.Education[0]; got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
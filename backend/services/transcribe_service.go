@@ -1,14 +1,13 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
-	"time"
+
+	"nexus-ai/models"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -17,6 +16,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/transcribestreaming/types"
 )
 
+// AWSTranscribeService transcribes with Amazon Transcribe streaming
 type AWSTranscribeService struct {
 	accessKey string
 	secretKey string
@@ -31,50 +31,183 @@ func NewAWSTranscribeService() *AWSTranscribeService {
 	}
 }
 
+func (s *AWSTranscribeService) Name() string { return "aws" }
+
 func (s *AWSTranscribeService) IsConfigured() bool {
 	return s.accessKey != "" && s.secretKey != ""
 }
 
-func (s *AWSTranscribeService) TranscribeAudio(audioData []byte) (string, error) {
-	if !s.IsConfigured() {
-		return "", fmt.Errorf("AWS credentials not configured")
+// Transcribe sends a whole recording through one stream and returns the
+// final results joined together
+func (s *AWSTranscribeService) Transcribe(ctx context.Context, pcmData []byte, opts TranscribeOptions) (*Transcription, error) {
+	stream, err := s.StartStream(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read events while sending so the stream never backs up
+	var finalTranscript strings.Builder
+	var eventCount int
+	var detected models.Language
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range stream.Events() {
+			eventCount++
+			if event.Type == models.TranscriptEventFinal {
+				finalTranscript.WriteString(event.Text)
+				finalTranscript.WriteString(" ")
+				if detected == "" && opts.Language == "" {
+					detected = event.Language
+				}
+			}
+		}
+	}()
+
+	if err := stream.Send(pcmData); err != nil {
+		stream.Close()
+		<-done
+		return nil, err
 	}
+	fmt.Printf("[AWS] Sent %d bytes\n", len(pcmData))
+
+	// Close to signal end of audio, then wait for the remaining results
+	stream.Close()
+	<-done
 
-	if len(audioData) < 1000 {
-		return "", fmt.Errorf("audio too short")
+	if err := stream.Err(); err != nil {
+		fmt.Printf("[AWS] Stream error: %v\n", err)
+		return nil, err
 	}
 
-	// Convert webm to PCM WAV (16kHz, mono, 16-bit)
-	pcmData, err := s.convertToPCM(audioData)
+	transcript := strings.TrimSpace(finalTranscript.String())
+	fmt.Printf("[AWS] Events: %d, Result: '%s'\n", eventCount, transcript)
+
+	return &Transcription{Text: transcript, Language: detected}, nil
+}
+
+// StartStream opens a long-lived Transcribe stream that reports partial
+// and final results as audio is sent
+func (s *AWSTranscribeService) StartStream(ctx context.Context, opts TranscribeOptions) (TranscriptStream, error) {
+	stream, err := s.open(ctx, opts, false)
 	if err != nil {
-		return "", fmt.Errorf("conversion failed: %w", err)
+		return nil, err
 	}
 
-	if len(pcmData) < 3200 {
-		return "", fmt.Errorf("no audio after conversion")
+	st := &awsStream{
+		ctx:    ctx,
+		stream: stream,
+		events: make(chan models.TranscriptEvent, 32),
+	}
+	// Start reading events BEFORE sending audio
+	go st.read()
+	return st, nil
+}
+
+// TranscribeSpeakers sends a whole recording through one stream with
+// speaker partitioning and groups the final words by speaker
+func (s *AWSTranscribeService) TranscribeSpeakers(ctx context.Context, pcm []byte, opts TranscribeOptions) ([]models.SpeakerSegment, error) {
+	stream, err := s.open(ctx, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []types.Item
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range stream.Events() {
+			v, ok := event.(*types.TranscriptResultStreamMemberTranscriptEvent)
+			if !ok || v.Value.Transcript == nil {
+				continue
+			}
+			for _, result := range v.Value.Transcript.Results {
+				if !result.IsPartial && len(result.Alternatives) > 0 {
+					items = append(items, result.Alternatives[0].Items...)
+				}
+			}
+		}
+	}()
+
+	err = sendAudio(ctx, stream, pcm)
+	stream.Close()
+	<-done
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return speakerSegments(items), nil
+}
+
+// speakerSegments groups words into segments by speaker. Punctuation joins
+// the word before it.
+func speakerSegments(items []types.Item) []models.SpeakerSegment {
+	segments := []models.SpeakerSegment{}
+	for _, item := range items {
+		word := aws.ToString(item.Content)
+		n := len(segments)
+		if item.Type == types.ItemTypePunctuation {
+			if n > 0 {
+				segments[n-1].Text += word
+			}
+			continue
+		}
+
+		speaker := aws.ToString(item.Speaker)
+		if !strings.HasPrefix(speaker, "spk_") {
+			speaker = "spk_" + speaker
+		}
+		if n > 0 && segments[n-1].Speaker == speaker {
+			segments[n-1].Text += " " + word
+			segments[n-1].End = item.EndTime
+			continue
+		}
+		segments = append(segments, models.SpeakerSegment{
+			Speaker: speaker,
+			Text:    word,
+			Start:   item.StartTime,
+			End:     item.EndTime,
+		})
 	}
+	return segments
+}
 
-	// Save for debugging
-	os.WriteFile("/tmp/last_audio.webm", audioData, 0644)
-	os.WriteFile("/tmp/last_pcm.raw", pcmData, 0644)
+// open starts a Transcribe stream, optionally labelling speakers. Without
+// a language, Transcribe identifies it among the candidates. Streaming only
+// takes vocabularies created in advance, so a request's vocabulary is left
+// to the chain's corrector.
+func (s *AWSTranscribeService) open(ctx context.Context, opts TranscribeOptions, speakerLabels bool) (*transcribestreaming.StartStreamTranscriptionEventStream, error) {
+	if !s.IsConfigured() {
+		return nil, fmt.Errorf("AWS credentials not configured")
+	}
 
-	// Check if audio has actual content
-	hasSound := false
-	for i := 0; i < len(pcmData)-1; i += 2 {
-		sample := int16(pcmData[i]) | int16(pcmData[i+1])<<8
-		if sample > 1000 || sample < -1000 {
-			hasSound = true
-			break
+	input := &transcribestreaming.StartStreamTranscriptionInput{
+		MediaEncoding:        types.MediaEncodingPcm,
+		MediaSampleRateHertz: aws.Int32(pcmSampleRate),
+		ShowSpeakerLabel:     speakerLabels,
+	}
+	if opts.Language != "" {
+		code, err := providerLanguage(s.Name(), opts.Language, awsCode)
+		if err != nil {
+			return nil, err
 		}
+		input.LanguageCode = types.LanguageCode(code)
+	} else if candidates := detectCandidates(opts, awsCode); len(candidates) == 1 {
+		// Identification needs at least two options
+		input.LanguageCode = types.LanguageCode(candidates[0])
+	} else {
+		input.IdentifyLanguage = true
+		input.LanguageOptions = aws.String(strings.Join(candidates, ","))
 	}
-	fmt.Printf("[AWS] PCM: %d bytes, hasSound: %v\n", len(pcmData), hasSound)
 
 	region := s.region
 	if region == "" {
 		region = "us-east-1"
 	}
 
-	cfg, err := config.LoadDefaultConfig(context.Background(),
+	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
 		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			s.accessKey,
@@ -83,107 +216,94 @@ func (s *AWSTranscribeService) TranscribeAudio(audioData []byte) (string, error)
 		)),
 	)
 	if err != nil {
-		return "", fmt.Errorf("AWS config error: %w", err)
+		return nil, fmt.Errorf("AWS config error: %w", err)
 	}
 
 	client := transcribestreaming.NewFromConfig(cfg)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	resp, err := client.StartStreamTranscription(ctx, &transcribestreaming.StartStreamTranscriptionInput{
-		LanguageCode:         types.LanguageCodeEnUs,
-		MediaEncoding:        types.MediaEncodingPcm,
-		MediaSampleRateHertz: aws.Int32(16000),
-	})
+	resp, err := client.StartStreamTranscription(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("start stream error: %w", err)
+		return nil, fmt.Errorf("start stream error: %w", err)
 	}
+	return resp.GetStream(), nil
+}
 
-	stream := resp.GetStream()
+// awsStream adapts a Transcribe event stream to TranscriptStream
+type awsStream struct {
+	ctx    context.Context
+	stream *transcribestreaming.StartStreamTranscriptionEventStream
+	events chan models.TranscriptEvent
 
-	// Start reading events BEFORE sending audio
-	var wg sync.WaitGroup
-	var finalTranscript strings.Builder
-	var eventCount int
-	var streamErr error
+	mu  sync.Mutex
+	err error
+}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for event := range stream.Events() {
-			eventCount++
-			switch v := event.(type) {
-			case *types.TranscriptResultStreamMemberTranscriptEvent:
-				for _, result := range v.Value.Transcript.Results {
-					if len(result.Alternatives) > 0 {
-						text := aws.ToString(result.Alternatives[0].Transcript)
-						fmt.Printf("[AWS] Event %d: '%s' (partial=%v)\n", eventCount, text, result.IsPartial)
-						if !result.IsPartial && text != "" {
-							finalTranscript.WriteString(text)
-							finalTranscript.WriteString(" ")
-						}
-					}
-				}
+func (s *awsStream) read() {
+	defer close(s.events)
+	for event := range s.stream.Events() {
+		v, ok := event.(*types.TranscriptResultStreamMemberTranscriptEvent)
+		if !ok || v.Value.Transcript == nil {
+			continue
+		}
+		for _, result := range v.Value.Transcript.Results {
+			if len(result.Alternatives) == 0 {
+				continue
+			}
+			text := aws.ToString(result.Alternatives[0].Transcript)
+			if text == "" {
+				continue
+			}
+			eventType := models.TranscriptEventFinal
+			if result.IsPartial {
+				eventType = models.TranscriptEventPartial
+			}
+			s.events <- models.TranscriptEvent{
+				Type:     eventType,
+				Text:     text,
+				Start:    result.StartTime,
+				End:      result.EndTime,
+				Language: languageFrom(awsCode, string(result.LanguageCode)),
 			}
 		}
-		streamErr = stream.Err()
-	}()
+	}
+
+	s.mu.Lock()
+	s.err = s.stream.Err()
+	s.mu.Unlock()
+}
+
+func (s *awsStream) Send(pcm []byte) error {
+	return sendAudio(s.ctx, s.stream, pcm)
+}
 
-	// Send audio in chunks
-	chunkSize := 8000
-	for i := 0; i < len(pcmData); i += chunkSize {
+// sendAudio splits audio into the small events Transcribe expects
+func sendAudio(ctx context.Context, stream *transcribestreaming.StartStreamTranscriptionEventStream, pcm []byte) error {
+	const chunkSize = 8000
+	for i := 0; i < len(pcm); i += chunkSize {
 		end := i + chunkSize
-		if end > len(pcmData) {
-			end = len(pcmData)
+		if end > len(pcm) {
+			end = len(pcm)
 		}
-		err = stream.Send(ctx, &types.AudioStreamMemberAudioEvent{
-			Value: types.AudioEvent{AudioChunk: pcmData[i:end]},
+		err := stream.Send(ctx, &types.AudioStreamMemberAudioEvent{
+			Value: types.AudioEvent{AudioChunk: pcm[i:end]},
 		})
 		if err != nil {
-			stream.Close()
-			return "", fmt.Errorf("send error: %w", err)
+			return fmt.Errorf("send error: %w", err)
 		}
 	}
-
-	fmt.Printf("[AWS] Sent %d bytes\n", len(pcmData))
-
-	// Close to signal end of audio
-	stream.Close()
-
-	// Wait for event reading to complete
-	wg.Wait()
-
-	if streamErr != nil {
-		fmt.Printf("[AWS] Stream error: %v\n", streamErr)
-		return "", streamErr
-	}
-
-	transcript := strings.TrimSpace(finalTranscript.String())
-	fmt.Printf("[AWS] Events: %d, Result: '%s'\n", eventCount, transcript)
-
-	return transcript, nil
+	return nil
 }
 
-func (s *AWSTranscribeService) convertToPCM(audioData []byte) ([]byte, error) {
-	cmd := exec.Command("ffmpeg",
-		"-i", "pipe:0",
-		"-ar", "16000",
-		"-ac", "1",
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-y",
-		"pipe:1",
-	)
-	cmd.Stdin = bytes.NewReader(audioData)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+func (s *awsStream) Events() <-chan models.TranscriptEvent {
+	return s.events
+}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ffmpeg: %v - %s", err, stderr.String())
-	}
+func (s *awsStream) Close() error {
+	return s.stream.Close()
+}
 
-	return stdout.Bytes(), nil
+func (s *awsStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
 }
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"nexus-ai/audio"
+	"nexus-ai/models"
+)
+
+// Live streams are either relayed to a provider's own streaming API, with
+// partial results, or cut into windows and sent to the batch providers.
+const (
+	StreamModeStreaming = "streaming"
+	StreamModeChunked   = "chunked"
+)
+
+// The chunked mode gathers at least chunkWindow of audio per batch call,
+// then waits for a pause at least chunkMinCut in to cut at, up to
+// chunkMaxWindow. Without a VAD it cuts at chunkWindow.
+const (
+	chunkWindow    = 5 * pcmSampleRate * 2  // 5s
+	chunkMaxWindow = 10 * pcmSampleRate * 2 // 10s
+	chunkMinCut    = 2.0                    // seconds
+)
+
+// TranscriptStream is one long-lived transcription of continuous audio
+type TranscriptStream interface {
+	// Send queues 16 kHz mono PCM
+	Send(pcm []byte) error
+	// Events delivers partial and final results and is closed once the
+	// stream has ended
+	Events() <-chan models.TranscriptEvent
+	// Close signals the end of the audio. Remaining results are still
+	// delivered on Events.
+	Close() error
+	// Err returns what ended the stream early, once Events is closed
+	Err() error
+}
+
+// StreamingTranscriber is a Transcriber that can also transcribe audio as it
+// arrives
+type StreamingTranscriber interface {
+	Transcriber
+	StartStream(ctx context.Context, opts TranscribeOptions) (TranscriptStream, error)
+}
+
+// LiveStream is a started stream with the provider and mode serving it
+type LiveStream struct {
+	TranscriptStream
+	Provider string
+	Mode     string
+}
+
+// StartStream opens a stream with the first configured provider that
+// supports streaming. Without one, audio is transcribed in windows by the
+// batch providers, which yields final results only.
+func (c *TranscriberChain) StartStream(ctx context.Context) (*LiveStream, error) {
+	configured := c.Configured()
+	if len(configured) == 0 {
+		return nil, ErrNoTranscriber
+	}
+
+	for _, t := range c.providers {
+		st, ok := t.(StreamingTranscriber)
+		if !ok || !t.IsConfigured() {
+			continue
+		}
+		stream, err := st.StartStream(ctx, c.opts)
+		if err != nil {
+			fmt.Printf("[TRANSCRIBE] %s stream failed to start: %v\n", t.Name(), err)
+			continue
+		}
+		if c.corrector != nil {
+			stream = newCorrectedStream(stream, c.corrector)
+		}
+		return &LiveStream{TranscriptStream: c.preprocessed(stream), Provider: t.Name(), Mode: StreamModeStreaming}, nil
+	}
+
+	return &LiveStream{
+		TranscriptStream: c.preprocessed(newChunkedStream(ctx, c)),
+		Provider:         strings.Join(configured, ","),
+		Mode:             StreamModeChunked,
+	}, nil
+}
+
+// correctedStream applies a corrector to a provider stream's events. The
+// chunked stream needs none, as the chain corrects each window.
+type correctedStream struct {
+	TranscriptStream
+	events chan models.TranscriptEvent
+}
+
+func newCorrectedStream(stream TranscriptStream, corrector *TranscriptCorrector) *correctedStream {
+	s := &correctedStream{TranscriptStream: stream, events: make(chan models.TranscriptEvent, 16)}
+	go func() {
+		defer close(s.events)
+		for event := range stream.Events() {
+			event.Text = corrector.Correct(event.Text)
+			s.events <- event
+		}
+	}()
+	return s
+}
+
+func (s *correctedStream) Events() <-chan models.TranscriptEvent {
+	return s.events
+}
+
+// preprocessed conditions the audio sent to a stream when the chain
+// preprocesses audio
+func (c *TranscriberChain) preprocessed(stream TranscriptStream) TranscriptStream {
+	if pre := c.newPreprocessor(); pre != nil {
+		return newPreprocessedStream(stream, pre)
+	}
+	return stream
+}
+
+// preprocessedStream conditions audio before passing it on, and reports
+// each quality warning as a warning event the first time it is raised.
+// Send is called from one goroutine at a time, as for any stream.
+type preprocessedStream struct {
+	TranscriptStream
+	pre      *audio.Preprocessor
+	warned   int
+	warnings chan string
+	events   chan models.TranscriptEvent
+}
+
+func newPreprocessedStream(stream TranscriptStream, pre *audio.Preprocessor) *preprocessedStream {
+	s := &preprocessedStream{
+		TranscriptStream: stream,
+		pre:              pre,
+		warnings:         make(chan string, 4),
+		events:           make(chan models.TranscriptEvent, 16),
+	}
+	go func() {
+		defer close(s.events)
+		results := stream.Events()
+		for {
+			select {
+			case warning := <-s.warnings:
+				s.events <- models.TranscriptEvent{Type: models.TranscriptEventWarning, Message: warning}
+			case event, ok := <-results:
+				if !ok {
+					for {
+						select {
+						case warning := <-s.warnings:
+							s.events <- models.TranscriptEvent{Type: models.TranscriptEventWarning, Message: warning}
+						default:
+							return
+						}
+					}
+				}
+				s.events <- event
+			}
+		}
+	}()
+	return s
+}
+
+func (s *preprocessedStream) Send(pcm []byte) error {
+	pcm = s.pre.Process(pcm)
+	warnings := s.pre.Warnings()
+	for _, warning := range warnings[s.warned:] {
+		select {
+		case s.warnings <- warning:
+		default:
+		}
+	}
+	s.warned = len(warnings)
+	return s.TranscriptStream.Send(pcm)
+}
+
+func (s *preprocessedStream) Events() <-chan models.TranscriptEvent {
+	return s.events
+}
+
+// chunkedStream gathers audio into windows and transcribes each with the
+// chain's batch fallback, one window at a time. Windows are cut at pauses
+// and silent ones are skipped when the chain has a VAD. A window that fails
+// is reported as an error event and the stream carries on.
+type chunkedStream struct {
+	ctx    context.Context
+	chain  *TranscriberChain
+	events chan models.TranscriptEvent
+	work   chan []byte
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+func newChunkedStream(ctx context.Context, chain *TranscriberChain) *chunkedStream {
+	s := &chunkedStream{
+		ctx:    ctx,
+		chain:  chain,
+		events: make(chan models.TranscriptEvent, 16),
+		work:   make(chan []byte, 4),
+	}
+	go s.run()
+	return s
+}
+
+func (s *chunkedStream) run() {
+	defer close(s.events)
+
+	var offset float64
+	for window := range s.work {
+		start, end := offset, offset+audio.Seconds(window)
+		offset = end
+
+		if s.chain.vad != nil {
+			speech := audio.Trim(window, s.chain.vad.Segments(window))
+			if speech == nil {
+				continue
+			}
+			window = speech.PCM
+			start += speech.Start
+			end = start + audio.Seconds(window)
+		}
+
+		result, err := s.chain.TranscribePCM(s.ctx, window)
+		if err != nil {
+			s.events <- models.TranscriptEvent{Type: models.TranscriptEventError, Message: err.Error(), Start: start, End: end}
+			continue
+		}
+		if result.Text == "" {
+			continue
+		}
+		s.events <- models.TranscriptEvent{
+			Type:     models.TranscriptEventFinal,
+			Text:     result.Text,
+			Start:    start,
+			End:      end,
+			Language: result.Language,
+		}
+	}
+}
+
+func (s *chunkedStream) Send(pcm []byte) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("stream closed")
+	}
+	s.buf = append(s.buf, pcm...)
+	var full []byte
+	if cut := s.cutPoint(); cut > 0 {
+		full = s.buf[:cut:cut]
+		s.buf = append([]byte(nil), s.buf[cut:]...)
+	}
+	s.mu.Unlock()
+
+	if full != nil {
+		select {
+		case s.work <- full:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// cutPoint returns where to end the next window in the buffer, or 0 to
+// wait for more audio. Callers hold s.mu.
+func (s *chunkedStream) cutPoint() int {
+	if len(s.buf) < chunkWindow {
+		return 0
+	}
+	if s.chain.vad == nil {
+		return len(s.buf)
+	}
+	if pause := audio.LastPause(s.chain.vad.Segments(s.buf), chunkMinCut); pause > 0 {
+		return audio.Offset(pause)
+	}
+	if len(s.buf) >= chunkMaxWindow {
+		return len(s.buf)
+	}
+	return 0
+}
+
+func (s *chunkedStream) Events() <-chan models.TranscriptEvent {
+	return s.events
+}
+
+func (s *chunkedStream) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	rest := s.buf
+	s.buf = nil
+	s.mu.Unlock()
+
+	if len(rest) >= pcmSampleRate/10*2 { // 100ms
+		s.work <- rest
+	}
+	close(s.work)
+	return nil
+}
+
+func (s *chunkedStream) Err() error {
+	return nil
+}
+
+// TranscribeLong transcribes a recording of any length as it is read from r,
+// decoding it on the fly and transcribing it window by window, cut at
+// pauses, so memory use does not grow with its length. Each window is passed
+// to emit in order, as a final event with its timing or an error event when
+// every provider failed on it. The error returned is for the recording as a
+// whole, such as audio that cannot be decoded.
+func (c *TranscriberChain) TranscribeLong(ctx context.Context, r io.Reader, emit func(models.TranscriptEvent)) error {
+	if len(c.Configured()) == 0 {
+		return ErrNoTranscriber
+	}
+
+	stream := c.preprocessed(newChunkedStream(ctx, c))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range stream.Events() {
+			emit(event)
+		}
+	}()
+
+	decoder, err := NewStreamDecoder(ctx, "", 0, stream.Send)
+	if err != nil {
+		stream.Close()
+		<-done
+		return err
+	}
+	_, copyErr := io.Copy(decoder, r)
+	// A decoder that gave up on the input breaks the pipe, so its own error
+	// says more than the copy's
+	decodeErr := decoder.Close()
+	stream.Close()
+	<-done
+
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return copyErr
+}
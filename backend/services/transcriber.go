@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nexus-ai/audio"
+	"nexus-ai/config"
+	"nexus-ai/models"
+)
+
+// Transcribers all receive audio as 16 kHz mono 16-bit little-endian PCM,
+// decoded once by the chain whatever the upload format was.
+const (
+	pcmSampleRate = 16000
+	pcmChannels   = 1
+)
+
+// ErrNoTranscriber is returned when none of the configured providers can run
+var ErrNoTranscriber = errors.New("no transcription provider is configured; set TRANSCRIBE_PROVIDERS and the matching credentials, model or server URL")
+
+// TranscribeOptions are the per-request settings every provider receives
+type TranscribeOptions struct {
+	// Language is the spoken language, or empty to have the provider
+	// identify it
+	Language models.Language
+	// DetectLanguages narrows identification to these candidates, for
+	// providers that need a list; empty means every supported language
+	DetectLanguages []models.Language
+	// Vocabulary is technical terms to bias recognition towards, for
+	// providers that support it
+	Vocabulary []string
+	// FillerWords keeps hesitations such as "um" and "uh" in the
+	// transcript, which providers tidy away by default
+	FillerWords bool
+}
+
+// Transcriber turns 16 kHz mono PCM into text
+type Transcriber interface {
+	// Name identifies the provider in TRANSCRIBE_PROVIDERS and responses
+	Name() string
+	// IsConfigured reports whether the provider has what it needs to run
+	IsConfigured() bool
+	// Transcribe returns the text and, when the language was identified
+	// rather than given, the language detected
+	Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error)
+}
+
+// Transcription is the text a provider returned, which provider it was, the
+// language it detected and, when voice activity detection ran, the speech
+// and silence in the audio. Warnings are what preprocessing found wrong
+// with the audio, such as clipping.
+type Transcription struct {
+	Text     string
+	Provider string
+	Language models.Language
+	Segments []audio.Segment
+	Warnings []string
+}
+
+// TranscriberChain tries its providers in order, falling back to the next
+// one when a provider is not configured or fails. With preprocessing, audio
+// is normalized and noise gated first. With a VAD, silence is trimmed, long
+// recordings are split at pauses and silent audio is never sent to a
+// provider. With a corrector, known mishearings of technical terms are fixed
+// in every transcript.
+type TranscriberChain struct {
+	providers  []Transcriber
+	opts       TranscribeOptions
+	timeout    time.Duration
+	preprocess *audio.PreprocessConfig
+	vad        *audio.VAD
+	maxChunk   float64 // seconds
+	corrector  *TranscriptCorrector
+}
+
+// NewTranscriberChain builds the chain named by TRANSCRIBE_PROVIDERS.
+// Unknown names are logged and skipped.
+func NewTranscriberChain() *TranscriberChain {
+	cfg := config.GetConfig()
+	chain := &TranscriberChain{timeout: cfg.TranscribeTimeout, maxChunk: cfg.TranscribeMaxChunk.Seconds()}
+	for _, code := range cfg.DetectLanguages {
+		if lang, err := ParseLanguage(code); err == nil && lang != "" {
+			chain.opts.DetectLanguages = append(chain.opts.DetectLanguages, lang)
+		} else {
+			fmt.Printf("[TRANSCRIBE] Unknown language %q in TRANSCRIBE_DETECT_LANGUAGES\n", code)
+		}
+	}
+	if cfg.AudioPreprocess {
+		chain.WithPreprocessing(audio.DefaultPreprocessConfig())
+	}
+	if cfg.VADEnabled {
+		chain.vad = audio.NewVAD(audio.DefaultVADConfig())
+	}
+	if cfg.CorrectTranscripts {
+		chain.corrector = DefaultTranscriptCorrector()
+	}
+	for _, name := range cfg.Transcribers {
+		t := newTranscriber(name, cfg)
+		if t == nil {
+			fmt.Printf("[TRANSCRIBE] Unknown provider %q in TRANSCRIBE_PROVIDERS\n", name)
+			continue
+		}
+		chain.providers = append(chain.providers, t)
+	}
+	return chain
+}
+
+func newTranscriber(name string, cfg *config.Config) Transcriber {
+	switch name {
+	case "aws":
+		return NewAWSTranscribeService()
+	case "deepgram":
+		return NewDeepgramTranscriber(cfg.DeepgramAPIKey, cfg.DeepgramModel)
+	case "whisper-server":
+		return NewWhisperServerTranscriber(cfg.WhisperServerURL)
+	case "whisper-cli":
+		return NewWhisperCLITranscriber(cfg.WhisperCommand, cfg.WhisperModel)
+	case "fake":
+		return NewFakeTranscriber(cfg.FakeTranscript)
+	default:
+		return nil
+	}
+}
+
+// NewTranscriberChainOf builds a chain from explicit providers, without
+// preprocessing, voice activity detection or correction unless
+// WithPreprocessing, WithVAD or WithCorrector is called
+func NewTranscriberChainOf(timeout time.Duration, providers ...Transcriber) *TranscriberChain {
+	return &TranscriberChain{providers: providers, timeout: timeout}
+}
+
+// WithPreprocessing conditions audio before it is transcribed: DC offset
+// removal, normalization and a noise gate, with quality warnings
+func (c *TranscriberChain) WithPreprocessing(cfg audio.PreprocessConfig) *TranscriberChain {
+	c.preprocess = &cfg
+	return c
+}
+
+// newPreprocessor returns a preprocessor for one recording or stream, or nil
+// without preprocessing
+func (c *TranscriberChain) newPreprocessor() *audio.Preprocessor {
+	if c.preprocess == nil {
+		return nil
+	}
+	return audio.NewPreprocessor(*c.preprocess)
+}
+
+// WithVAD enables voice activity detection, splitting speech into chunks of
+// at most maxChunk
+func (c *TranscriberChain) WithVAD(vad *audio.VAD, maxChunk time.Duration) *TranscriberChain {
+	c.vad = vad
+	c.maxChunk = maxChunk.Seconds()
+	return c
+}
+
+// WithLanguage sets the spoken language; empty asks providers to identify it
+func (c *TranscriberChain) WithLanguage(lang models.Language) *TranscriberChain {
+	c.opts.Language = lang
+	return c
+}
+
+// WithVocabulary biases providers towards a vocabulary, such as one from
+// BuildVocabulary, and teaches the corrector how its terms are misheard
+func (c *TranscriberChain) WithVocabulary(terms []string) *TranscriberChain {
+	c.opts.Vocabulary = terms
+	if c.corrector != nil {
+		c.corrector = c.corrector.WithTerms(terms)
+	}
+	return c
+}
+
+// WithFillerWords keeps hesitations in transcripts, for delivery analysis
+func (c *TranscriberChain) WithFillerWords() *TranscriberChain {
+	c.opts.FillerWords = true
+	return c
+}
+
+// WithCorrector fixes transcripts with corrector; set it before
+// WithVocabulary so the vocabulary's spoken forms are included
+func (c *TranscriberChain) WithCorrector(corrector *TranscriptCorrector) *TranscriberChain {
+	c.corrector = corrector
+	return c
+}
+
+// Configured returns the names of the providers that can run, in order
+func (c *TranscriberChain) Configured() []string {
+	names := []string{}
+	for _, t := range c.providers {
+		if t.IsConfigured() {
+			names = append(names, t.Name())
+		}
+	}
+	return names
+}
+
+// Transcribe decodes audio to PCM and hands it to each configured provider
+// in turn until one succeeds. An empty transcript counts as success: the
+// audio was silent, and asking another provider would only cost time.
+func (c *TranscriberChain) Transcribe(ctx context.Context, data []byte) (*Transcription, error) {
+	if len(c.Configured()) == 0 {
+		return nil, ErrNoTranscriber
+	}
+
+	pcm, err := DecodeToPCM(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("conversion failed: %w", err)
+	}
+	if len(pcm) < pcmSampleRate/10*2 { // 100ms
+		return nil, fmt.Errorf("no audio after conversion")
+	}
+	return c.TranscribeSpeech(ctx, pcm)
+}
+
+// TranscribeSpeech is Transcribe for audio already decoded to 16 kHz mono
+// PCM, preprocessed, then trimmed and split at pauses when the chain has a
+// VAD
+func (c *TranscriberChain) TranscribeSpeech(ctx context.Context, pcm []byte) (*Transcription, error) {
+	pre := c.newPreprocessor()
+	pcm = pre.Process(pcm)
+	warnings := pre.Warnings()
+	if len(warnings) > 0 {
+		fmt.Printf("[TRANSCRIBE] Audio quality: %s\n", strings.Join(warnings, ", "))
+	}
+
+	if c.vad == nil {
+		result, err := c.TranscribePCM(ctx, pcm)
+		if err != nil {
+			return nil, err
+		}
+		result.Warnings = warnings
+		return result, nil
+	}
+
+	segments := c.vad.Segments(pcm)
+	chunks := audio.Split(pcm, segments, c.maxChunk)
+	if len(chunks) == 0 {
+		fmt.Printf("[TRANSCRIBE] No speech in %.1fs of audio, skipped\n", audio.Seconds(pcm))
+		return &Transcription{Segments: segments, Warnings: warnings}, nil
+	}
+
+	result := &Transcription{Segments: segments, Warnings: warnings}
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		part, err := c.TranscribePCM(ctx, chunk.PCM)
+		if err != nil {
+			return nil, err
+		}
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+		result.Provider = part.Provider
+		if result.Language == "" {
+			result.Language = part.Language
+		}
+	}
+	result.Text = strings.Join(texts, " ")
+	return result, nil
+}
+
+// TranscribePCM hands audio already decoded to 16 kHz mono PCM to each
+// provider in turn as it is, without voice activity detection
+func (c *TranscriberChain) TranscribePCM(ctx context.Context, pcm []byte) (*Transcription, error) {
+	var errs []string
+	for _, t := range c.providers {
+		if !t.IsConfigured() {
+			continue
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, c.timeout)
+		start := time.Now()
+		result, err := t.Transcribe(tctx, pcm, c.opts)
+		cancel()
+		if err != nil {
+			fmt.Printf("[TRANSCRIBE] %s failed after %v: %v\n", t.Name(), time.Since(start).Round(time.Millisecond), err)
+			errs = append(errs, t.Name()+": "+err.Error())
+			continue
+		}
+
+		result.Text = c.corrector.Correct(strings.TrimSpace(result.Text))
+		result.Provider = t.Name()
+		fmt.Printf("[TRANSCRIBE] %s: %d chars in %v\n", t.Name(), len(result.Text), time.Since(start).Round(time.Millisecond))
+		return result, nil
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoTranscriber
+	}
+	return nil, fmt.Errorf("all transcription providers failed: %s", strings.Join(errs, "; "))
+}
+
+// pcmToWAV wraps 16 kHz mono PCM in a WAV header for providers that want a
+// container
+func pcmToWAV(pcm []byte) []byte {
+	const bitsPerSample = 16
+	blockAlign := pcmChannels * bitsPerSample / 8
+
+	var buf bytes.Buffer
+	buf.Grow(44 + len(pcm))
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(pcmChannels))
+	binary.Write(&buf, binary.LittleEndian, uint32(pcmSampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(pcmSampleRate*blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+// FakeTranscriber returns a fixed transcript without looking at the audio,
+// for tests and for exercising the UI offline
+type FakeTranscriber struct {
+	text string
+}
+
+func NewFakeTranscriber(text string) *FakeTranscriber {
+	return &FakeTranscriber{text: text}
+}
+
+func (t *FakeTranscriber) Name() string { return "fake" }
+
+func (t *FakeTranscriber) IsConfigured() bool { return true }
+
+func (t *FakeTranscriber) Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error) {
+	return &Transcription{Text: t.text}, ctx.Err()
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-ai/models"
+)
+
+// stubTranscriber is a provider whose answer a test decides
+type stubTranscriber struct {
+	name       string
+	configured bool
+	text       string
+	err        error
+	block      bool // wait for the context to end instead of answering
+	calls      int
+	opts       TranscribeOptions
+}
+
+func (t *stubTranscriber) Name() string { return t.name }
+
+func (t *stubTranscriber) IsConfigured() bool { return t.configured }
+
+func (t *stubTranscriber) Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error) {
+	t.calls++
+	t.opts = opts
+	if t.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &Transcription{Text: t.text}, nil
+}
+
+func TestTranscriberChainFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*stubTranscriber
+		want      string // provider that answers, empty when all fail
+		text      string
+		called    []int // calls per provider
+		noneReady bool
+	}{
+		{
+			name:      "first provider answers",
+			providers: []*stubTranscriber{{name: "aws", configured: true, text: "from aws"}, {name: "deepgram", configured: true, text: "from deepgram"}},
+			want:      "aws",
+			text:      "from aws",
+			called:    []int{1, 0},
+		},
+		{
+			name:      "falls back after an error",
+			providers: []*stubTranscriber{{name: "aws", configured: true, err: errors.New("throttled")}, {name: "deepgram", configured: true, text: "from deepgram"}},
+			want:      "deepgram",
+			text:      "from deepgram",
+			called:    []int{1, 1},
+		},
+		{
+			name:      "skips unconfigured providers",
+			providers: []*stubTranscriber{{name: "aws"}, {name: "deepgram", configured: true, text: "from deepgram"}},
+			want:      "deepgram",
+			text:      "from deepgram",
+			called:    []int{0, 1},
+		},
+		{
+			name:      "falls back after a timeout",
+			providers: []*stubTranscriber{{name: "whisper-server", configured: true, block: true}, {name: "deepgram", configured: true, text: "from deepgram"}},
+			want:      "deepgram",
+			text:      "from deepgram",
+			called:    []int{1, 1},
+		},
+		{
+			name:      "empty transcript is an answer",
+			providers: []*stubTranscriber{{name: "aws", configured: true}, {name: "deepgram", configured: true, text: "from deepgram"}},
+			want:      "aws",
+			text:      "",
+			called:    []int{1, 0},
+		},
+		{
+			name:      "all fail",
+			providers: []*stubTranscriber{{name: "aws", configured: true, err: errors.New("throttled")}, {name: "deepgram", configured: true, err: errors.New("unauthorized")}},
+			called:    []int{1, 1},
+		},
+		{
+			name:      "none configured",
+			providers: []*stubTranscriber{{name: "aws"}, {name: "deepgram"}},
+			called:    []int{0, 0},
+			noneReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]Transcriber, len(tt.providers))
+			for i, p := range tt.providers {
+				providers[i] = p
+			}
+			chain := NewTranscriberChainOf(20*time.Millisecond, providers...)
+
+			result, err := chain.TranscribePCM(context.Background(), make([]byte, pcmSampleRate*2))
+			for i, p := range tt.providers {
+				if p.calls != tt.called[i] {
+					t.Errorf("%s called %d times, want %d", p.name, p.calls, tt.called[i])
+				}
+			}
+			switch {
+			case tt.noneReady:
+				if !errors.Is(err, ErrNoTranscriber) {
+					t.Fatalf("error = %v, want ErrNoTranscriber", err)
+				}
+			case tt.want == "":
+				if err == nil || errors.Is(err, ErrNoTranscriber) {
+					t.Fatalf("error = %v, want every provider's failure", err)
+				}
+				for _, p := range tt.providers {
+					if !strings.Contains(err.Error(), p.name+": "+p.err.Error()) {
+						t.Errorf("error %q does not mention %s", err, p.name)
+					}
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Provider != tt.want || result.Text != tt.text {
+					t.Fatalf("result = %s %q, want %s %q", result.Provider, result.Text, tt.want, tt.text)
+				}
+			}
+		})
+	}
+}
+
+func TestTranscriberChainWithFake(t *testing.T) {
+	failing := &stubTranscriber{name: "aws", configured: true, err: errors.New("no credentials")}
+	chain := NewTranscriberChainOf(time.Second, failing, NewFakeTranscriber("This is a practice transcript.")).
+		WithLanguage(models.Language("es"))
+
+	result, err := chain.TranscribePCM(context.Background(), make([]byte, pcmSampleRate*2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Provider != "fake" || result.Text != "This is a practice transcript." {
+		t.Fatalf("result = %s %q", result.Provider, result.Text)
+	}
+	if failing.opts.Language != "es" {
+		t.Errorf("provider got language %q, want es", failing.opts.Language)
+	}
+	if got := chain.Configured(); strings.Join(got, ",") != "aws,fake" {
+		t.Errorf("Configured() = %v", got)
+	}
+}
+
+func TestTranscriberChainDecodesUploads(t *testing.T) {
+	chain := NewTranscriberChainOf(time.Second, NewFakeTranscriber("hello"))
+
+	result, err := chain.Transcribe(context.Background(), pcmToWAV(make([]byte, pcmSampleRate*2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Provider != "fake" || result.Text != "hello" {
+		t.Fatalf("result = %s %q", result.Provider, result.Text)
+	}
+
+	if _, err := chain.Transcribe(context.Background(), pcmToWAV(nil)); err == nil {
+		t.Fatal("empty recording was transcribed")
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"nexus-ai/models"
+)
+
+// Transcript export formats
+const (
+	TranscriptFormatText = "txt"
+	TranscriptFormatSRT  = "srt"
+	TranscriptFormatVTT  = "vtt"
+)
+
+// Subtitle cues hold at most captionLines lines of captionLine characters,
+// the usual limit for readable captions. A longer word gets a line to
+// itself.
+const (
+	captionLine  = 42
+	captionLines = 2
+)
+
+// ExportTranscript renders timed segments as plain text, one segment per
+// line, or as SRT or WebVTT subtitles. Segments too long for one cue are
+// split at word boundaries, sharing their time out by length.
+func ExportTranscript(segments []models.TranscriptSegment, format string) (string, error) {
+	var b strings.Builder
+	switch format {
+	case TranscriptFormatText:
+		for _, s := range segments {
+			if text := strings.TrimSpace(s.Text); text != "" {
+				b.WriteString(text + "\n")
+			}
+		}
+	case TranscriptFormatSRT:
+		for i, cue := range captionCues(segments) {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, cueTime(cue.Start, ","), cueTime(cue.End, ","), cue.Text)
+		}
+	case TranscriptFormatVTT:
+		b.WriteString("WEBVTT\n\n")
+		for _, cue := range captionCues(segments) {
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", cueTime(cue.Start, "."), cueTime(cue.End, "."), cue.Text)
+		}
+	default:
+		return "", fmt.Errorf("unsupported format %q (use %s, %s or %s)", format, TranscriptFormatText, TranscriptFormatSRT, TranscriptFormatVTT)
+	}
+	return b.String(), nil
+}
+
+// captionCues splits segments into cues that fit on screen: words fill
+// lines of up to captionLine characters, and every captionLines lines make
+// a cue
+func captionCues(segments []models.TranscriptSegment) []models.TranscriptSegment {
+	cues := []models.TranscriptSegment{}
+	for _, s := range segments {
+		var lines []string
+		line := ""
+		for _, word := range strings.Fields(s.Text) {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > captionLine {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		var groups [][]string
+		total := 0
+		for i, l := range lines {
+			if i%captionLines == 0 {
+				groups = append(groups, nil)
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], l)
+			total += utf8.RuneCountInString(l)
+		}
+
+		start := s.Start
+		for i, g := range groups {
+			text := strings.Join(g, "\n")
+			end := start + (s.End-s.Start)*float64(utf8.RuneCountInString(text)-len(g)+1)/float64(total)
+			if i == len(groups)-1 {
+				end = s.End
+			}
+			cues = append(cues, models.TranscriptSegment{Start: start, End: end, Text: text})
+			start = end
+		}
+	}
+	return cues
+}
+
+// cueTime formats seconds as HH:MM:SS with milliseconds after sep, which is
+// a comma in SRT and a dot in WebVTT
+func cueTime(seconds float64, sep string) string {
+	ms := int64(math.Round(math.Max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
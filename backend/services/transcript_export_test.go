@@ -0,0 +1,122 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"nexus-ai/models"
+)
+
+func TestCueTime(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		sep     string
+		want    string
+	}{
+		{0, ",", "00:00:00,000"},
+		{1.25, ",", "00:00:01,250"},
+		{61.5, ".", "00:01:01.500"},
+		{3725.004, ",", "01:02:05,004"},
+		{59.9996, ".", "00:01:00.000"},
+		{-2, ",", "00:00:00,000"},
+		{36000, ".", "10:00:00.000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := cueTime(tt.seconds, tt.sep); got != tt.want {
+				t.Fatalf("cueTime(%v) = %s, want %s", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportTranscript(t *testing.T) {
+	segments := []models.TranscriptSegment{
+		{Start: 0.5, End: 2.25, Text: "Tell me about yourself."},
+		{Start: 2.25, End: 3, Text: "  "},
+		{Start: 3.1, End: 65.4, Text: "I build platforms."},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{TranscriptFormatText, "Tell me about yourself.\nI build platforms.\n"},
+		{TranscriptFormatSRT, "1\n00:00:00,500 --> 00:00:02,250\nTell me about yourself.\n\n" +
+			"2\n00:00:03,100 --> 00:01:05,400\nI build platforms.\n\n"},
+		{TranscriptFormatVTT, "WEBVTT\n\n" +
+			"00:00:00.500 --> 00:00:02.250\nTell me about yourself.\n\n" +
+			"00:00:03.100 --> 00:01:05.400\nI build platforms.\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := ExportTranscript(segments, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ExportTranscript =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ExportTranscript(segments, "docx"); err == nil {
+		t.Fatal("unsupported format accepted")
+	}
+}
+
+func TestCaptionCuesSplitLongSegments(t *testing.T) {
+	words := strings.Fields(strings.Repeat("deployment pipelines with canary releases ", 8))
+	segment := models.TranscriptSegment{Start: 10, End: 40, Text: strings.Join(words, " ")}
+
+	cues := captionCues([]models.TranscriptSegment{segment})
+	if len(cues) < 2 {
+		t.Fatalf("got %d cues, want the segment split", len(cues))
+	}
+
+	total := 0
+	for _, cue := range cues {
+		total += utf8.RuneCountInString(strings.ReplaceAll(cue.Text, "\n", ""))
+	}
+
+	start := segment.Start
+	var text []string
+	for i, cue := range cues {
+		lines := strings.Split(cue.Text, "\n")
+		if len(lines) > captionLines {
+			t.Errorf("cue %d has %d lines", i, len(lines))
+		}
+		for _, l := range lines {
+			if utf8.RuneCountInString(l) > captionLine {
+				t.Errorf("cue %d line %q is longer than %d", i, l, captionLine)
+			}
+		}
+		// Cues follow on from each other, timed by their share of the text
+		if math.Abs(cue.Start-start) > 1e-9 {
+			t.Errorf("cue %d starts at %v, want %v", i, cue.Start, start)
+		}
+		share := float64(utf8.RuneCountInString(strings.ReplaceAll(cue.Text, "\n", ""))) / float64(total)
+		if want := share * (segment.End - segment.Start); math.Abs(cue.End-cue.Start-want) > 1e-9 {
+			t.Errorf("cue %d lasts %v, want %v", i, cue.End-cue.Start, want)
+		}
+		start = cue.End
+		text = append(text, strings.Fields(cue.Text)...)
+	}
+	if start != segment.End {
+		t.Errorf("last cue ends at %v, want %v", start, segment.End)
+	}
+	if strings.Join(text, " ") != segment.Text {
+		t.Error("cues do not hold the segment's words in order")
+	}
+}
+
+func TestCaptionCuesLongWord(t *testing.T) {
+	word := strings.Repeat("x", captionLine+10)
+	cues := captionCues([]models.TranscriptSegment{{Start: 0, End: 1, Text: "a " + word + " b"}})
+	// The long word gets a line to itself; three lines make two cues
+	if len(cues) != 2 || cues[0].Text != "a\n"+word || cues[1].Text != "b" {
+		t.Fatalf("cues = %+v", cues)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"nexus-ai/config"
+	"nexus-ai/models"
+)
+
+//go:embed data/transcript_corrections.json
+var bundledCorrections []byte
+
+const (
+	// maxVocabulary caps the terms sent to a provider; long lists dilute
+	// the bias
+	maxVocabulary = 100
+	// maxVocabularyTerm drops skills written as sentences rather than names
+	maxVocabularyTerm = 40
+	// maxWhisperPrompt keeps the glossary well inside whisper's prompt
+	// window of 224 tokens
+	maxWhisperPrompt = 600
+)
+
+// BuildVocabulary collects the technical terms an interview is likely to
+// contain: any extra terms given, the JD's required skills, the candidate's
+// skills, the JD's nice-to-have skills and the skills its responsibilities
+// mention, in that order, canonicalized and de-duplicated. Profile and JD
+// may be nil.
+func BuildVocabulary(profile *models.UserProfile, jd *models.JobDescription, extra ...string) []string {
+	taxonomy := DefaultSkillTaxonomy()
+
+	raw := append([]string{}, extra...)
+	if jd != nil {
+		raw = append(raw, jd.RequiredSkills...)
+	}
+	if profile != nil {
+		raw = append(raw, profile.Skills...)
+	}
+	if jd != nil {
+		raw = append(raw, jd.NiceToHaveSkills...)
+		raw = append(raw, taxonomy.FindMentions(strings.Join(jd.Responsibilities, "\n"))...)
+	}
+
+	terms := []string{}
+	for _, term := range taxonomy.NormalizeSkills(raw) {
+		if len(term) > maxVocabularyTerm {
+			continue
+		}
+		terms = append(terms, term)
+		if len(terms) == maxVocabulary {
+			break
+		}
+	}
+	return terms
+}
+
+// whisperFillerPrompt is written the way people talk; whisper follows the
+// style of its prompt, so it keeps hesitations instead of smoothing them out
+const whisperFillerPrompt = "Umm, let me think, like, hmm... Okay, so, uh, here's what I, you know, think."
+
+// whisperPrompt turns a vocabulary into an initial prompt, which biases
+// whisper towards spelling the terms as written. With filler words wanted
+// the glossary follows a disfluent sentence.
+func whisperPrompt(opts TranscribeOptions) string {
+	prompt := ""
+	if opts.FillerWords {
+		prompt = whisperFillerPrompt
+	}
+	vocabulary := opts.Vocabulary
+	if len(vocabulary) == 0 {
+		return prompt
+	}
+	if prompt != "" {
+		prompt += " "
+	}
+	prompt += "Glossary: " + vocabulary[0]
+	for _, term := range vocabulary[1:] {
+		if len(prompt)+len(term)+2 > maxWhisperPrompt {
+			break
+		}
+		prompt += ", " + term
+	}
+	return prompt + "."
+}
+
+// Correction maps the ways speech-to-text mishears a term back to it
+type Correction struct {
+	Term  string   `json:"term"`
+	Heard []string `json:"heard"`
+}
+
+// TranscriptCorrector rewrites known mishearings of technical terms, such
+// as "cube control" for kubectl. It is deterministic: matching is by whole
+// words, case-insensitive, longest phrase first, and the same text always
+// gets the same result.
+type TranscriptCorrector struct {
+	corrections []Correction
+	rules       map[string]string // normalized heard phrase -> term
+	pattern     *regexp.Regexp
+}
+
+var (
+	correctorInstance *TranscriptCorrector
+	correctorOnce     sync.Once
+)
+
+// DefaultTranscriptCorrector returns the bundled corrections, extended with
+// the file at TRANSCRIPT_CORRECTIONS_FILE when one is configured
+func DefaultTranscriptCorrector() *TranscriptCorrector {
+	correctorOnce.Do(func() {
+		corrections, err := ParseCorrections(bundledCorrections)
+		if err != nil {
+			fmt.Printf("[TRANSCRIBE] Bundled corrections invalid: %v\n", err)
+		}
+
+		if path := config.GetConfig().CorrectionsFile; path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Printf("[TRANSCRIBE] Could not read %s: %v\n", path, err)
+			} else if extra, err := ParseCorrections(data); err != nil {
+				fmt.Printf("[TRANSCRIBE] Could not load %s: %v\n", path, err)
+			} else {
+				corrections = append(corrections, extra...)
+			}
+		}
+		correctorInstance = NewTranscriptCorrector(corrections...)
+	})
+	return correctorInstance
+}
+
+// ParseCorrections reads a {"corrections": [...]} document
+func ParseCorrections(data []byte) ([]Correction, error) {
+	var doc struct {
+		Corrections []Correction `json:"corrections"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Corrections, nil
+}
+
+// NewTranscriptCorrector compiles corrections. When two name the same
+// heard phrase, the later one wins.
+func NewTranscriptCorrector(corrections ...Correction) *TranscriptCorrector {
+	c := &TranscriptCorrector{corrections: corrections, rules: make(map[string]string)}
+	for _, corr := range corrections {
+		term := strings.TrimSpace(corr.Term)
+		for _, heard := range corr.Heard {
+			if key := normalizeHeard(heard); key != "" && term != "" {
+				c.rules[key] = term
+			}
+		}
+	}
+	if len(c.rules) == 0 {
+		return c
+	}
+
+	phrases := make([]string, 0, len(c.rules))
+	for key := range c.rules {
+		phrases = append(phrases, key)
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if len(phrases[i]) != len(phrases[j]) {
+			return len(phrases[i]) > len(phrases[j])
+		}
+		return phrases[i] < phrases[j]
+	})
+	for i, phrase := range phrases {
+		words := strings.Fields(phrase)
+		for j, w := range words {
+			words[j] = regexp.QuoteMeta(w)
+		}
+		phrases[i] = strings.Join(words, `[\s-]+`)
+	}
+	c.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(phrases, "|") + `)\b`)
+	return c
+}
+
+// WithTerms returns a corrector that also knows how a vocabulary is likely
+// to be spoken: split into words ("argo cd"), with acronyms spelled out
+// ("e k s"), or in lower case. The corrector's own rules take precedence.
+func (c *TranscriptCorrector) WithTerms(terms []string) *TranscriptCorrector {
+	if len(terms) == 0 {
+		return c
+	}
+	corrections := []Correction{}
+	for _, term := range terms {
+		if heard := spokenForms(term); len(heard) > 0 {
+			corrections = append(corrections, Correction{Term: term, Heard: heard})
+		}
+	}
+	return NewTranscriptCorrector(append(corrections, c.corrections...)...)
+}
+
+// Correct rewrites every known mishearing in text
+func (c *TranscriptCorrector) Correct(text string) string {
+	if c == nil || c.pattern == nil {
+		return text
+	}
+	return c.pattern.ReplaceAllStringFunc(text, func(match string) string {
+		if term, ok := c.rules[normalizeHeard(match)]; ok {
+			return term
+		}
+		return match
+	})
+}
+
+// normalizeHeard lowercases a phrase and collapses spaces and hyphens
+func normalizeHeard(phrase string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(phrase), "-", " ")), " ")
+}
+
+// spokenTerm matches the terms spoken forms are generated for; names with
+// symbols such as C++ or CI/CD need explicit corrections
+var spokenTerm = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 .\-]*[A-Za-z0-9]$`)
+
+// spokenForms returns how speech-to-text is likely to write a term. Plain
+// words ("Terraform", "Spring") get none, as their lower-case forms are
+// ordinary speech; neither do strict taxonomy names such as Go.
+func spokenForms(term string) []string {
+	if !spokenTerm.MatchString(term) {
+		return nil
+	}
+	if entry, ok := DefaultSkillTaxonomy().Lookup(term); ok && entry.Strict {
+		return nil
+	}
+
+	forms := []string{}
+	words := splitTerm(term)
+	if split := strings.Join(words, " "); normalizeHeard(split) != normalizeHeard(term) {
+		forms = append(forms, split)
+	}
+
+	// Spell out acronyms, as in "argo c d" or "e k s"
+	spelled := make([]string, len(words))
+	changed := false
+	for i, w := range words {
+		spelled[i] = w
+		if len(w) >= 2 && len(w) <= 5 && strings.ToUpper(w) == w && !strings.ContainsAny(w, "0123456789") {
+			spelled[i] = strings.Join(strings.Split(w, ""), " ")
+			changed = true
+		}
+	}
+	if changed && (len(words) > 1 || len(words[0]) >= 3) {
+		forms = append(forms, strings.Join(spelled, " "))
+	}
+
+	// Words with inner capitals and names with digits or dots are never
+	// ordinary words, so their lower-case form can be fixed too. All-caps
+	// acronyms can be: "arm", "ant".
+	if (term != strings.ToUpper(term) && hasInnerUpper(term)) || strings.ContainsAny(term, ".0123456789") {
+		forms = append(forms, term)
+	}
+	return forms
+}
+
+// splitTerm splits a name into the words it would be spoken as: at spaces,
+// dots and hyphens, between a lower-case and an upper-case letter
+// ("GitHub"), at the end of an acronym ("HTTPServer") and between letters
+// and digits ("EC2")
+func splitTerm(term string) []string {
+	runes := []rune(term)
+	words := []string{}
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			words = append(words, string(runes[start:end]))
+		}
+	}
+	for i, r := range runes {
+		if r == ' ' || r == '.' || r == '-' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsLower(prev) && unicode.IsUpper(r),
+			unicode.IsLetter(prev) && unicode.IsDigit(r),
+			unicode.IsDigit(prev) && unicode.IsLetter(r),
+			unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// hasInnerUpper reports whether a capital follows a letter, as in GitHub
+func hasInnerUpper(term string) bool {
+	runes := []rune(term)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLetter(runes[i-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// WhisperCLITranscriber runs a local whisper.cpp binary. Audio is passed as
+// WAV on stdin so no recording is written to disk.
+type WhisperCLITranscriber struct {
+	command string
+	model   string
+}
+
+func NewWhisperCLITranscriber(command, model string) *WhisperCLITranscriber {
+	return &WhisperCLITranscriber{command: command, model: model}
+}
+
+func (t *WhisperCLITranscriber) Name() string { return "whisper-cli" }
+
+// IsConfigured reports whether the binary is on PATH and the model exists
+func (t *WhisperCLITranscriber) IsConfigured() bool {
+	if t.model == "" {
+		return false
+	}
+	if _, err := exec.LookPath(t.command); err != nil {
+		return false
+	}
+	_, err := os.Stat(t.model)
+	return err == nil
+}
+
+// whisperDetected matches the line whisper.cpp logs after identifying the
+// language
+var whisperDetected = regexp.MustCompile(`auto-detected language: (\w+)`)
+
+// Transcribe runs whisper with the given language, or with -l auto to have
+// it identify the language. Detection is read from whisper's log, so the
+// log is only silenced when the language is known.
+func (t *WhisperCLITranscriber) Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error) {
+	lang := "auto"
+	if opts.Language != "" {
+		if _, err := providerLanguage(t.Name(), opts.Language, whisperName); err != nil {
+			return nil, err
+		}
+		lang = string(opts.Language)
+	}
+
+	args := []string{
+		"-m", t.model,
+		"-f", "-",
+		"-l", lang,
+		"-nt", // no timestamps
+	}
+	if opts.Language != "" {
+		args = append(args, "-np") // no progress or system info
+	}
+	if prompt := whisperPrompt(opts); prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	cmd := exec.CommandContext(ctx, t.command, args...)
+	cmd.Stdin = bytes.NewReader(pcmToWAV(pcm))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %v - %s", t.command, err, stderr.String())
+	}
+
+	// Each segment is printed on its own line
+	result := &Transcription{Text: strings.Join(strings.Fields(stdout.String()), " ")}
+	if m := whisperDetected.FindStringSubmatch(stderr.String()); m != nil && opts.Language == "" {
+		result.Language = languageFrom(whisperName, m[1])
+	}
+	return result, nil
+}
+
+// WhisperServerTranscriber posts audio to a whisper.cpp server's
+// /inference endpoint
+type WhisperServerTranscriber struct {
+	url    string
+	client *http.Client
+}
+
+func NewWhisperServerTranscriber(url string) *WhisperServerTranscriber {
+	return &WhisperServerTranscriber{url: strings.TrimRight(url, "/"), client: &http.Client{}}
+}
+
+func (t *WhisperServerTranscriber) Name() string { return "whisper-server" }
+
+func (t *WhisperServerTranscriber) IsConfigured() bool {
+	return t.url != ""
+}
+
+// Transcribe posts the audio with the given language, or with language
+// auto and a verbose response, which names the language detected
+func (t *WhisperServerTranscriber) Transcribe(ctx context.Context, pcm []byte, opts TranscribeOptions) (*Transcription, error) {
+	lang, format := "auto", "verbose_json"
+	if opts.Language != "" {
+		if _, err := providerLanguage(t.Name(), opts.Language, whisperName); err != nil {
+			return nil, err
+		}
+		lang, format = string(opts.Language), "json"
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(pcmToWAV(pcm)); err != nil {
+		return nil, err
+	}
+	form.WriteField("language", lang)
+	form.WriteField("response_format", format)
+	if prompt := whisperPrompt(opts); prompt != "" {
+		form.WriteField("prompt", prompt)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url+"/inference", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("whisper server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("whisper server error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse whisper server response: %w", err)
+	}
+	return &Transcription{
+		Text:     strings.Join(strings.Fields(result.Text), " "),
+		Language: languageFrom(whisperName, result.Language),
+	}, nil
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// EvictReason says why an entry left a Cache
+type EvictReason int
+
+const (
+	// EvictExpired means the entry sat idle for longer than the TTL
+	EvictExpired EvictReason = iota
+	// EvictCapacity means the entry was the least recently used one when
+	// the cache was full
+	EvictCapacity
+)
+
+// CacheStats counts the entries of a Cache and what it has dropped
+type CacheStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Expired uint64 `json:"expired"`
+	Evicted uint64 `json:"evicted"`
+}
+
+// Cache is a string-keyed map with idle expiry and least-recently-used
+// eviction. Reads and writes both count as use. Expired entries are dropped
+// when next read and by Sweep; OnEvict, if set, is called for every entry
+// that is expired or evicted, after the cache's lock has been released.
+type Cache[V any] struct {
+	ttl     time.Duration
+	max     int
+	onEvict func(key string, value V, reason EvictReason)
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // front is most recently used
+	stats CacheStats
+}
+
+type cacheEntry[V any] struct {
+	key     string
+	value   V
+	touched time.Time
+}
+
+type eviction[V any] struct {
+	key    string
+	value  V
+	reason EvictReason
+}
+
+// NewCache returns a cache that drops entries idle for longer than ttl and
+// holds at most max entries. Zero disables either limit.
+func NewCache[V any](ttl time.Duration, max int, onEvict func(key string, value V, reason EvictReason)) *Cache[V] {
+	return &Cache[V]{
+		ttl:     ttl,
+		max:     max,
+		onEvict: onEvict,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value for key and marks it as used
+func (c *Cache[V]) Get(key string) (V, bool) {
+	var evicted []eviction[V]
+	defer func() { c.notify(evicted) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return zero, false
+	}
+	entry := el.Value.(*cacheEntry[V])
+	now := time.Now()
+	if c.expired(entry, now) {
+		evicted = append(evicted, c.remove(el, EvictExpired))
+		c.stats.Misses++
+		return zero, false
+	}
+	entry.touched = now
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entries if
+// the cache is over capacity
+func (c *Cache[V]) Set(key string, value V) {
+	var evicted []eviction[V]
+	defer func() { c.notify(evicted) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*cacheEntry[V])
+		entry.value = value
+		entry.touched = now
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry[V]{key: key, value: value, touched: now})
+	for c.max > 0 && c.order.Len() > c.max {
+		evicted = append(evicted, c.remove(c.order.Back(), EvictCapacity))
+	}
+}
+
+// Delete removes key without calling OnEvict
+func (c *Cache[V]) Delete(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*cacheEntry[V])
+	c.order.Remove(el)
+	delete(c.items, key)
+	return entry.value, true
+}
+
+// Range calls fn for every live entry, most recently used first, without
+// marking them as used
+func (c *Cache[V]) Range(fn func(key string, value V)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		entry := el.Value.(*cacheEntry[V])
+		if !c.expired(entry, now) {
+			fn(entry.key, entry.value)
+		}
+	}
+}
+
+// Sweep drops every entry that has been idle since before now-TTL and
+// returns how many were dropped
+func (c *Cache[V]) Sweep(now time.Time) int {
+	var evicted []eviction[V]
+	defer func() { c.notify(evicted) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Entries are ordered by last use, so expired ones sit at the back
+	for el := c.order.Back(); el != nil; el = c.order.Back() {
+		if !c.expired(el.Value.(*cacheEntry[V]), now) {
+			break
+		}
+		evicted = append(evicted, c.remove(el, EvictExpired))
+	}
+	return len(evicted)
+}
+
+// Len returns the number of entries, including expired ones not yet swept
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *Cache[V]) expired(entry *cacheEntry[V], now time.Time) bool {
+	return c.ttl > 0 && now.Sub(entry.touched) > c.ttl
+}
+
+// remove unlinks an entry and counts it. Callers hold c.mu.
+func (c *Cache[V]) remove(el *list.Element, reason EvictReason) eviction[V] {
+	entry := el.Value.(*cacheEntry[V])
+	c.order.Remove(el)
+	delete(c.items, entry.key)
+	if reason == EvictExpired {
+		c.stats.Expired++
+	} else {
+		c.stats.Evicted++
+	}
+	return eviction[V]{key: entry.key, value: entry.value, reason: reason}
+}
+
+func (c *Cache[V]) notify(evicted []eviction[V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
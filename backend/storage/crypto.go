@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Records are sealed with envelope encryption: each record gets a fresh
+// random data key, the record is encrypted with it, and the data key is
+// wrapped with the keyring's current key. A sealed record is stored as
+//
+//	enc1:<key id>:<wrapped data key>:<ciphertext>
+//
+// with both binary parts base64 encoded and prefixed by their GCM nonce.
+const sealedPrefix = "enc1:"
+
+const (
+	keySize = 32 // AES-256
+
+	// scrypt parameters for passphrase-derived keys
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	keyFile  = "nexus.key"
+	saltFile = "nexus.salt"
+)
+
+var (
+	ErrNoKey         = errors.New("record is encrypted but no encryption key is configured")
+	ErrUnknownKey    = errors.New("record is encrypted with a key that is not in the keyring")
+	ErrCorruptRecord = errors.New("encrypted record is corrupt")
+)
+
+// Keyring holds the keys used to seal records. The current key seals new
+// records; the others are kept so records sealed before a rotation can
+// still be opened until they are re-encrypted.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeyring builds a keyring from 32-byte keys. The first key is current.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+
+	k := &Keyring{keys: make(map[string][]byte)}
+	for i, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key %d is %d bytes, want %d", i+1, len(key), keySize)
+		}
+		id := keyID(key)
+		if i == 0 {
+			k.current = id
+		}
+		k.keys[id] = key
+	}
+	return k, nil
+}
+
+// LoadKeyFile reads base64 keys from path, one per line with the current key
+// first; blank lines and lines starting with # are ignored. A missing file
+// is created with a new random key, readable by the owner only.
+func LoadKeyFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		content := "# NEXUS AI encryption keys, current key first\n" + base64.StdEncoding.EncodeToString(key) + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create key file: %w", err)
+		}
+		fmt.Printf("[STORAGE] Created encryption key file %s; back it up, data cannot be read without it\n", path)
+		return NewKeyring(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var keys [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: invalid base64 key", path)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// NewEphemeralKeyring returns a keyring with a random key held only in
+// memory, for data that does not outlive the process
+func NewEphemeralKeyring() (*Keyring, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return NewKeyring(key)
+}
+
+// DeriveKeyring derives keys from passphrases with scrypt, the current
+// passphrase first. The salt is kept in dataDir and created on first use.
+func DeriveKeyring(dataDir string, passphrases ...string) (*Keyring, error) {
+	salt, err := loadSalt(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]byte, 0, len(passphrases))
+	for _, p := range passphrases {
+		if p == "" {
+			continue
+		}
+		key, err := scrypt.Key([]byte(p), salt, scryptN, scryptR, scryptP, keySize)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+func loadSalt(dataDir string) ([]byte, error) {
+	path := filepath.Join(dataDir, saltFile)
+	salt, err := os.ReadFile(path)
+	if err == nil {
+		return salt, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read salt: %w", err)
+	}
+
+	salt = make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, salt, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write salt: %w", err)
+	}
+	return salt, nil
+}
+
+// LoadKeys returns the keyring for the configured key source. A passphrase
+// takes precedence over a key file; oldPassphrase, if set, stays usable for
+// reading records until they are re-encrypted. With neither set, the key
+// file defaults to one in dataDir.
+func LoadKeys(path, passphrase, oldPassphrase, dataDir string) (*Keyring, error) {
+	if passphrase != "" {
+		return DeriveKeyring(dataDir, passphrase, oldPassphrase)
+	}
+	if path == "" {
+		path = filepath.Join(dataDir, keyFile)
+		fmt.Printf("[STORAGE] No ENCRYPTION_KEY_FILE set, using %s; keep keys away from the data they protect in production\n", path)
+	}
+	return LoadKeyFile(path)
+}
+
+// keyID is a short fingerprint naming a key in sealed records
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// CurrentID returns the id of the key new records are sealed with
+func (k *Keyring) CurrentID() string {
+	return k.current
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped with the current key
+func (k *Keyring) Seal(plaintext []byte) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := gcmSeal(k.keys[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := gcmSeal(dataKey, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + k.current + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a sealed record
+func (k *Keyring) Open(record string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(record, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return nil, ErrCorruptRecord
+	}
+	id := parts[0]
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w (key %s)", ErrUnknownKey, id)
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrCorruptRecord
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrCorruptRecord
+	}
+
+	dataKey, err := gcmOpen(key, wrapped, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(dataKey, ciphertext, nil)
+}
+
+// NeedsRotation reports whether a stored record is plaintext or sealed with
+// a key other than the current one
+func (k *Keyring) NeedsRotation(record string) bool {
+	if !isSealed(record) {
+		return true
+	}
+	return !strings.HasPrefix(record, sealedPrefix+k.current+":")
+}
+
+func isSealed(record string) bool {
+	return strings.HasPrefix(record, sealedPrefix)
+}
+
+// gcmSeal encrypts with AES-GCM, returning the nonce followed by the
+// ciphertext
+func gcmSeal(key, plaintext, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func gcmOpen(key, sealed, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrCorruptRecord
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrCorruptRecord
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
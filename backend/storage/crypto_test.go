@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	k, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"empty", []byte{}},
+		{"json", []byte(`{"name":"Ada Lovelace","email":"ada@example.com"}`)},
+		{"binary", []byte{0, 1, 2, 0xff, ':', 0}},
+		{"large", bytes.Repeat([]byte("resume "), 10000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := k.Seal(tt.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(sealed, sealedPrefix+k.CurrentID()+":") {
+				t.Fatalf("sealed record %.40q does not name the current key", sealed)
+			}
+			if len(tt.plaintext) > 8 && strings.Contains(sealed, string(tt.plaintext[:8])) {
+				t.Fatal("sealed record contains the plaintext")
+			}
+			opened, err := k.Open(sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, tt.plaintext) {
+				t.Fatalf("Open = %q, want %q", opened, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestKeyringSealUsesFreshDataKeys(t *testing.T) {
+	k, _ := NewKeyring(testKey(1))
+	a, _ := k.Seal([]byte("same"))
+	b, _ := k.Seal([]byte("same"))
+	if a == b {
+		t.Fatal("sealing the same plaintext twice gave the same record")
+	}
+}
+
+func TestKeyringOpenErrors(t *testing.T) {
+	k, _ := NewKeyring(testKey(1))
+	sealed, _ := k.Seal([]byte("secret"))
+	parts := strings.Split(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	other, _ := NewKeyring(testKey(2))
+
+	// Flip a character inside the ciphertext, staying valid base64. The last
+	// character may only carry padding bits.
+	mid := len(parts[2]) / 2
+	flipped := byte('A')
+	if parts[2][mid] == 'A' {
+		flipped = 'B'
+	}
+	tampered := sealedPrefix + parts[0] + ":" + parts[1] + ":" + parts[2][:mid] + string(flipped) + parts[2][mid+1:]
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		record  string
+		want    error
+	}{
+		{"unknown key", other, sealed, ErrUnknownKey},
+		{"missing part", k, sealedPrefix + parts[0] + ":" + parts[1], ErrCorruptRecord},
+		{"bad base64", k, sealedPrefix + parts[0] + ":!!!:" + parts[2], ErrCorruptRecord},
+		{"tampered ciphertext", k, tampered, ErrCorruptRecord},
+		{"swapped data key", k, sealedPrefix + parts[0] + ":" + parts[2] + ":" + parts[1], ErrCorruptRecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.keyring.Open(tt.record); !errors.Is(err, tt.want) {
+				t.Fatalf("Open error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKeys, _ := NewKeyring(testKey(1))
+	oldRecord, _ := oldKeys.Seal([]byte("sealed before rotation"))
+
+	// After rotation the new key is current and the old one still opens
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.CurrentID() == oldKeys.CurrentID() {
+		t.Fatal("rotated keyring kept the old current key")
+	}
+	opened, err := rotated.Open(oldRecord)
+	if err != nil || string(opened) != "sealed before rotation" {
+		t.Fatalf("Open old record = %q, %v", opened, err)
+	}
+
+	resealed, _ := rotated.Seal(opened)
+	tests := []struct {
+		name   string
+		record string
+		want   bool
+	}{
+		{"plaintext", `{"name":"Ada"}`, true},
+		{"old key", oldRecord, true},
+		{"current key", resealed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rotated.NeedsRotation(tt.record); got != tt.want {
+				t.Fatalf("NeedsRotation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Once the old key is dropped, only re-encrypted records open
+	newOnly, _ := NewKeyring(testKey(2))
+	if _, err := newOnly.Open(oldRecord); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Open old record without old key: %v, want ErrUnknownKey", err)
+	}
+	if _, err := newOnly.Open(resealed); err != nil {
+		t.Fatalf("Open re-encrypted record: %v", err)
+	}
+}
+
+func TestNewKeyringRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys [][]byte
+	}{
+		{"none", nil},
+		{"short", [][]byte{make([]byte, 16)}},
+		{"short second", [][]byte{testKey(1), make([]byte, 31)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.keys...); err == nil {
+				t.Fatal("NewKeyring accepted invalid keys")
+			}
+		})
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", keyFile)
+
+	created, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+	record, _ := created.Seal([]byte("kept"))
+
+	loaded, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CurrentID() != created.CurrentID() {
+		t.Fatalf("reloaded current key %s, want %s", loaded.CurrentID(), created.CurrentID())
+	}
+	if opened, err := loaded.Open(record); err != nil || string(opened) != "kept" {
+		t.Fatalf("Open with reloaded keys = %q, %v", opened, err)
+	}
+}
+
+func TestMemoryStoreSealsRecordings(t *testing.T) {
+	keys, err := NewEphemeralKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStoreWithOptions(Options{Keys: keys})
+	defer store.Close()
+
+	audio := bytes.Repeat([]byte("plaintext interview audio "), 1000)
+	if _, err := store.Spool.Append("rec", 0, bytes.NewReader(audio)); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := store.Spool.path("rec")
+	onDisk, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(onDisk, []byte("plaintext interview audio")) {
+		t.Fatal("spooled recording is on disk in plaintext")
+	}
+
+	r, err := store.Spool.Open("rec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	read, _ := io.ReadAll(r)
+	if !bytes.Equal(read, audio) {
+		t.Fatal("spooled recording did not read back")
+	}
+}
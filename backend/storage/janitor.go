@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// janitor periodically sweeps a store's live state
+type janitor struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartJanitor sweeps the store every interval in a background goroutine
+// until StopJanitor or Close is called. A non-positive interval does nothing.
+func (s *Store) StartJanitor(interval time.Duration) {
+	if interval <= 0 || s.janitor != nil {
+		return
+	}
+
+	j := &janitor{stop: make(chan struct{}), done: make(chan struct{})}
+	s.janitor = j
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case now := <-ticker.C:
+				if err := s.Sweep(now); err != nil {
+					fmt.Printf("[STORAGE] Sweep failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopJanitor stops the background sweeper and waits for it to exit
+func (s *Store) StopJanitor() {
+	if s.janitor == nil {
+		return
+	}
+	close(s.janitor.stop)
+	<-s.janitor.done
+	s.janitor = nil
+}
+
+// Sweep expires idle sessions and session memory and enforces the caps.
+// Both repositories are swept even if the first fails.
+func (s *Store) Sweep(now time.Time) error {
+	sessionsErr := s.Sessions.Sweep(now)
+	memoryErr := s.Memory.Sweep(now)
+
+	s.sweepLock.Lock()
+	s.lastSweep = now
+	s.sweepLock.Unlock()
+
+	if sessionsErr != nil {
+		return fmt.Errorf("sessions: %w", sessionsErr)
+	}
+	if memoryErr != nil {
+		return fmt.Errorf("memory: %w", memoryErr)
+	}
+	return nil
+}
+
+// LastSweep returns when the store was last swept, or the zero time
+func (s *Store) LastSweep() time.Time {
+	s.sweepLock.Lock()
+	defer s.sweepLock.Unlock()
+	return s.lastSweep
+}
@@ -0,0 +1,563 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"nexus-ai/models"
+
+	"github.com/google/uuid"
+)
+
+// NewMemoryStore returns a store backed by in-process maps, with no limits
+// on live state. Nothing survives a restart; values are copied in and out so
+// callers never share state with the store.
+func NewMemoryStore() *Store {
+	return NewMemoryStoreWithOptions(Options{})
+}
+
+// NewMemoryStoreWithOptions is NewMemoryStore with idle expiry and size caps
+// for sessions and session memory. Ended sessions move to a separate archive
+// capped at opts.MaxArchived.
+func NewMemoryStoreWithOptions(opts Options) *Store {
+	// Uploaded audio is too large to hold in memory, so it goes to a
+	// temporary directory that is removed on Close, sealed under opts.Keys
+	spool := NewSpool(filepath.Join(os.TempDir(), "nexus-recordings-"+uuid.NewString()), opts.Keys)
+	return &Store{
+		Driver:          DriverMemory,
+		Options:         opts,
+		Profiles:        &memoryProfiles{profiles: map[string]*memoryProfile{}},
+		Sessions:        newMemorySessions(opts),
+		Memory:          &memoryMemory{memory: NewCache[*models.SessionMemory](opts.MemoryTTL, opts.MaxMemory, nil)},
+		JobDescriptions: &memoryJobDescriptions{jds: map[string]*models.JobDescription{}},
+		Recordings:      &memoryRecordings{recordings: map[string]*models.Recording{}},
+		Spool:           spool,
+		close:           spool.removeAll,
+	}
+}
+
+// clone deep-copies a value through its JSON form, matching what the SQLite
+// store round-trips
+func clone[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic(err)
+	}
+	return &out
+}
+
+type memoryProfile struct {
+	current   *models.UserProfile
+	versions  []*models.ProfileVersion
+	variants  map[string]*models.ProfileVariant
+	updatedAt time.Time
+}
+
+type memoryProfiles struct {
+	mu       sync.RWMutex
+	profiles map[string]*memoryProfile
+}
+
+func (r *memoryProfiles) Get(id string) (*models.UserProfile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(p.current), nil
+}
+
+func (r *memoryProfiles) GetWithVersion(id string) (*models.UserProfile, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	return clone(p.current), len(p.versions), nil
+}
+
+func (r *memoryProfiles) List() ([]ProfileSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]ProfileSummary, 0, len(r.profiles))
+	for id, p := range r.profiles {
+		list = append(list, ProfileSummary{
+			ID:        id,
+			Name:      p.current.Name,
+			Version:   len(p.versions),
+			UpdatedAt: p.updatedAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list, nil
+}
+
+func (r *memoryProfiles) Save(version *models.ProfileVersion, expected int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[version.ProfileID]
+	latest := 0
+	if ok {
+		latest = len(p.versions)
+	}
+	if expected != 0 && latest != expected {
+		return ErrVersionConflict
+	}
+	if !ok {
+		p = &memoryProfile{variants: map[string]*models.ProfileVariant{}}
+		r.profiles[version.ProfileID] = p
+	}
+
+	version.Version = latest + 1
+	p.versions = append(p.versions, clone(version))
+	p.current = clone(version.Profile)
+	p.updatedAt = version.CreatedAt
+	return nil
+}
+
+func (r *memoryProfiles) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.profiles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.profiles, id)
+	return nil
+}
+
+func (r *memoryProfiles) LatestVersion(id string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return len(p.versions), nil
+}
+
+func (r *memoryProfiles) Versions(id string) ([]*models.ProfileVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	list := make([]*models.ProfileVersion, len(p.versions))
+	for i, v := range p.versions {
+		list[i] = clone(v)
+	}
+	return list, nil
+}
+
+func (r *memoryProfiles) Version(id string, version int) (*models.ProfileVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok || version < 1 || version > len(p.versions) {
+		return nil, ErrNotFound
+	}
+	return clone(p.versions[version-1]), nil
+}
+
+func (r *memoryProfiles) SaveVariant(profileID string, variant *models.ProfileVariant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[profileID]
+	if !ok {
+		return ErrNotFound
+	}
+	p.variants[variant.Name] = clone(variant)
+	return nil
+}
+
+func (r *memoryProfiles) Variants(profileID string) ([]*models.ProfileVariant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[profileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	list := make([]*models.ProfileVariant, 0, len(p.variants))
+	for _, v := range p.variants {
+		list = append(list, clone(v))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *memoryProfiles) Variant(profileID, name string) (*models.ProfileVariant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[profileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	v, ok := p.variants[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(v), nil
+}
+
+// memorySessions keeps active sessions in an expiring cache. Sessions that
+// end, go idle or are pushed out by newer ones move to the archive. All cache
+// calls are made with mu held, which also covers the eviction callback.
+type memorySessions struct {
+	mu       sync.Mutex
+	active   *Cache[*models.InterviewSession]
+	archived *Cache[*models.InterviewSession]
+}
+
+func newMemorySessions(opts Options) *memorySessions {
+	r := &memorySessions{
+		archived: NewCache[*models.InterviewSession](0, opts.MaxArchived, nil),
+	}
+	r.active = NewCache(opts.SessionTTL, opts.MaxSessions, r.archive)
+	return r
+}
+
+// archive ends a session evicted from the active cache and keeps it in the
+// archive
+func (r *memorySessions) archive(id string, s *models.InterviewSession, reason EvictReason) {
+	markEnded(s, time.Now())
+	r.archived.Set(id, s)
+}
+
+// lookup finds a session, active or archived. Callers hold r.mu.
+func (r *memorySessions) lookup(id string) (*models.InterviewSession, bool) {
+	if s, ok := r.active.Get(id); ok {
+		return s, true
+	}
+	return r.archived.Get(id)
+}
+
+func (r *memorySessions) Get(id string) (*models.InterviewSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.lookup(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(s), nil
+}
+
+func (r *memorySessions) GetMeta(id string) (*models.InterviewSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.lookup(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *s
+	c.Messages = nil
+	return clone(&c), nil
+}
+
+func (r *memorySessions) Save(session *models.InterviewSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := clone(session)
+	saved.LastActivity = time.Now()
+	if existing, ok := r.lookup(session.SessionID); ok {
+		saved.Messages = existing.Messages
+	} else {
+		saved.Messages = numberMessages(saved.Messages, 0)
+	}
+
+	if saved.IsActive {
+		r.archived.Delete(saved.SessionID)
+		r.active.Set(saved.SessionID, saved)
+	} else {
+		r.active.Delete(saved.SessionID)
+		r.archived.Set(saved.SessionID, saved)
+	}
+	return nil
+}
+
+func (r *memorySessions) List() ([]*models.InterviewSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := []*models.InterviewSession{}
+	collect := func(_ string, s *models.InterviewSession) {
+		c := *s
+		c.Messages = nil
+		list = append(list, clone(&c))
+	}
+	r.active.Range(collect)
+	r.archived.Range(collect)
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.After(list[j].StartedAt)
+	})
+	return list, nil
+}
+
+func (r *memorySessions) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, active := r.active.Delete(id)
+	_, archived := r.archived.Delete(id)
+	if !active && !archived {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memorySessions) AppendMessages(id string, messages ...models.InterviewMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.lookup(id)
+	if !ok {
+		return ErrNotFound
+	}
+	s.Messages = append(s.Messages, numberMessages(messages, len(s.Messages))...)
+	s.LastActivity = time.Now()
+	return nil
+}
+
+func (r *memorySessions) Messages(id string, offset, limit int) ([]models.InterviewMessage, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.lookup(id)
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	total := len(s.Messages)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	page := make([]models.InterviewMessage, end-offset)
+	copy(page, s.Messages[offset:end])
+	return page, total, nil
+}
+
+func (r *memorySessions) Sweep(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.active.Sweep(now)
+	return nil
+}
+
+func (r *memorySessions) Stats() (LiveStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active, archived := r.active.Stats(), r.archived.Stats()
+	return LiveStats{
+		Live:     active.Entries,
+		Archived: archived.Entries,
+		Expired:  active.Expired,
+		Evicted:  active.Evicted + archived.Evicted,
+	}, nil
+}
+
+// markEnded closes a session that was still active
+func markEnded(s *models.InterviewSession, now time.Time) {
+	if !s.IsActive {
+		return
+	}
+	s.IsActive = false
+	s.EndedAt = &now
+}
+
+// numberMessages returns a copy of messages with Seq continuing after last
+func numberMessages(messages []models.InterviewMessage, last int) []models.InterviewMessage {
+	numbered := make([]models.InterviewMessage, len(messages))
+	for i, m := range messages {
+		m.Seq = last + i + 1
+		numbered[i] = m
+	}
+	return numbered
+}
+
+// memoryMemory keeps session memory in an expiring cache
+type memoryMemory struct {
+	mu     sync.Mutex
+	memory *Cache[*models.SessionMemory]
+}
+
+func (r *memoryMemory) Get(sessionID string) (*models.SessionMemory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.memory.Get(sessionID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(m), nil
+}
+
+func (r *memoryMemory) Append(sessionID string, pair models.QAPair, limit int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.memory.Get(sessionID)
+	if !ok {
+		m = &models.SessionMemory{QA: []models.QAPair{}}
+	}
+	m.QA = append(m.QA, pair)
+	if limit > 0 && len(m.QA) > limit {
+		m.QA = m.QA[len(m.QA)-limit:]
+	}
+	r.memory.Set(sessionID, m)
+	return nil
+}
+
+func (r *memoryMemory) Delete(sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.memory.Delete(sessionID)
+	return nil
+}
+
+func (r *memoryMemory) Sweep(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.memory.Sweep(now)
+	return nil
+}
+
+func (r *memoryMemory) Stats() (LiveStats, error) {
+	stats := r.memory.Stats()
+	return LiveStats{Live: stats.Entries, Expired: stats.Expired, Evicted: stats.Evicted}, nil
+}
+
+type memoryJobDescriptions struct {
+	mu  sync.RWMutex
+	jds map[string]*models.JobDescription
+}
+
+func (r *memoryJobDescriptions) Get(id string) (*models.JobDescription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jd, ok := r.jds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(jd), nil
+}
+
+func (r *memoryJobDescriptions) Save(jd *models.JobDescription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jds[jd.ID] = clone(jd)
+	return nil
+}
+
+func (r *memoryJobDescriptions) List() ([]*models.JobDescription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*models.JobDescription, 0, len(r.jds))
+	for _, jd := range r.jds {
+		list = append(list, clone(jd))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *memoryJobDescriptions) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jds[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.jds, id)
+	return nil
+}
+
+type memoryRecordings struct {
+	mu         sync.RWMutex
+	recordings map[string]*models.Recording
+}
+
+func (r *memoryRecordings) Get(id string) (*models.Recording, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.recordings[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(rec), nil
+}
+
+func (r *memoryRecordings) Save(rec *models.Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recordings[rec.ID] = clone(rec)
+	return nil
+}
+
+func (r *memoryRecordings) List() ([]*models.Recording, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*models.Recording, 0, len(r.recordings))
+	for _, rec := range r.recordings {
+		list = append(list, clone(rec))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *memoryRecordings) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.recordings[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.recordings, id)
+	return nil
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are applied in order, each in its own transaction. Never edit
+// a migration that has shipped; append a new one instead.
+var migrations = []string{
+	// 1: initial schema. Entities are stored as JSON documents with the
+	// columns needed for lookups and listings pulled out alongside.
+	`CREATE TABLE profiles (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL DEFAULT '',
+		version    INTEGER NOT NULL,
+		data       TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE TABLE profile_versions (
+		profile_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+		version    INTEGER NOT NULL,
+		source     TEXT NOT NULL DEFAULT '',
+		note       TEXT NOT NULL DEFAULT '',
+		data       TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (profile_id, version)
+	);
+	CREATE TABLE profile_variants (
+		profile_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+		name       TEXT NOT NULL,
+		data       TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (profile_id, name)
+	);
+	CREATE TABLE sessions (
+		id         TEXT PRIMARY KEY,
+		profile_id TEXT NOT NULL DEFAULT '',
+		is_active  INTEGER NOT NULL DEFAULT 1,
+		data       TEXT NOT NULL,
+		started_at TEXT NOT NULL
+	);
+	CREATE INDEX sessions_profile_id ON sessions(profile_id);
+	CREATE TABLE session_memory (
+		session_id TEXT PRIMARY KEY,
+		data       TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE TABLE job_descriptions (
+		id         TEXT PRIMARY KEY,
+		role       TEXT NOT NULL DEFAULT '',
+		company    TEXT NOT NULL DEFAULT '',
+		data       TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	);`,
+
+	// 2: session transcripts, one row per message
+	`CREATE TABLE session_messages (
+		session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		seq        INTEGER NOT NULL,
+		data       TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (session_id, seq)
+	);`,
+
+	// 3: last activity of sessions, for idle expiry
+	`ALTER TABLE sessions ADD COLUMN last_activity TEXT NOT NULL DEFAULT '';
+	UPDATE sessions SET last_activity = started_at;
+	CREATE INDEX sessions_activity ON sessions(is_active, last_activity);`,
+
+	// 4: long recordings uploaded for background transcription
+	`CREATE TABLE recordings (
+		id         TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		data       TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE INDEX recordings_created ON recordings(created_at);`,
+}
+
+// migrate brings the schema up to date, recording each applied migration in
+// schema_migrations
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build (%d)", current, len(migrations))
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, formatTime(time.Now())); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		fmt.Printf("[STORAGE] Applied migration %d\n", version)
+	}
+	return nil
+}
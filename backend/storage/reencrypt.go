@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// sealedTables are the tables whose data column holds sealed records
+var sealedTables = []string{
+	"profiles",
+	"profile_versions",
+	"profile_variants",
+	"sessions",
+	"session_messages",
+	"session_memory",
+	"job_descriptions",
+	"recordings",
+}
+
+// reencryptBatch is how many rows are rewritten per transaction, so the
+// single connection is never held for long
+const reencryptBatch = 100
+
+// reencryption is a background pass that re-seals records under the current
+// key
+type reencryption struct {
+	quit chan struct{}
+	done chan struct{}
+}
+
+// stop asks the pass to finish after its current batch and waits for it
+func (r *reencryption) stop() {
+	if r == nil {
+		return
+	}
+	close(r.quit)
+	<-r.done
+}
+
+// startReencrypt re-seals, in the background, every record that is still
+// plaintext or sealed with a retired key. Nothing is started without a
+// keyring.
+func (db *sqliteDB) startReencrypt() *reencryption {
+	if db.keys == nil {
+		return nil
+	}
+
+	r := &reencryption{quit: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+
+		total := 0
+		for _, table := range sealedTables {
+			n, err := db.reencryptTable(table, r.quit)
+			total += n
+			if err != nil {
+				fmt.Printf("[STORAGE] Re-encrypting %s failed: %v\n", table, err)
+				return
+			}
+		}
+		if _, err := db.Exec(`UPDATE profiles SET name = '' WHERE name != ''`); err != nil {
+			fmt.Printf("[STORAGE] Clearing profile names failed: %v\n", err)
+			return
+		}
+		if total > 0 {
+			// Push rewritten pages out of the WAL so old copies are gone
+			if _, err := db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+				fmt.Printf("[STORAGE] Checkpoint after re-encryption failed: %v\n", err)
+			}
+			fmt.Printf("[STORAGE] Re-encrypted %d records with key %s\n", total, db.keys.CurrentID())
+		}
+	}()
+	return r
+}
+
+// reencryptTable walks a table by rowid and re-seals stale records, one
+// batch per transaction, until done or quit is closed
+func (db *sqliteDB) reencryptTable(table string, quit <-chan struct{}) (int, error) {
+	rewritten := 0
+	var after int64
+	for {
+		select {
+		case <-quit:
+			return rewritten, nil
+		default:
+		}
+
+		n, last, err := db.reencryptBatch(table, after)
+		rewritten += n
+		if err != nil || last == after {
+			return rewritten, err
+		}
+		after = last
+	}
+}
+
+// reencryptBatch re-seals the stale records among the next batch of rows
+// after rowid and returns how many it rewrote and the last rowid it saw
+func (db *sqliteDB) reencryptBatch(table string, after int64) (int, int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, after, err
+	}
+	defer tx.Rollback()
+
+	type record struct {
+		rowid int64
+		data  string
+	}
+	rows, err := tx.Query(`SELECT rowid, data FROM `+table+` WHERE rowid > ? ORDER BY rowid LIMIT ?`, after, reencryptBatch)
+	if err != nil {
+		return 0, after, err
+	}
+	var batch []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.rowid, &r.data); err != nil {
+			rows.Close()
+			return 0, after, err
+		}
+		batch = append(batch, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, after, err
+	}
+
+	rewritten := 0
+	for _, r := range batch {
+		after = r.rowid
+		if !db.keys.NeedsRotation(r.data) {
+			continue
+		}
+		plain, err := db.open(r.data)
+		if err != nil {
+			return 0, after, fmt.Errorf("row %d: %w", r.rowid, err)
+		}
+		sealed, err := db.seal(plain)
+		if err != nil {
+			return 0, after, err
+		}
+		if _, err := tx.Exec(`UPDATE `+table+` SET data = ? WHERE rowid = ?`, sealed, r.rowid); err != nil {
+			return 0, after, err
+		}
+		rewritten++
+	}
+	return rewritten, after, tx.Commit()
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// spoolFrame is the most audio sealed as one frame. Frames are what a
+// resumed upload falls back to: a frame cut short by a crash is dropped.
+const spoolFrame = 1 << 20
+
+// Each frame starts with a flag (1 when sealed), the length of the audio it
+// holds and the length stored
+const spoolHeader = 9
+
+var (
+	ErrOffsetMismatch = errors.New("upload offset does not match the bytes received")
+	ErrUploadBusy     = errors.New("another upload to this recording is in progress")
+)
+
+// Spool keeps uploaded audio on disk until it has been transcribed, in one
+// append-only file per upload, so an interrupted upload can carry on where
+// it stopped, even across restarts. With a keyring, audio is sealed frame by
+// frame as it is written.
+type Spool struct {
+	dir  string
+	keys *Keyring
+
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+// NewSpool returns a spool in dir, which is created on first use
+func NewSpool(dir string, keys *Keyring) *Spool {
+	return &Spool{dir: dir, keys: keys, busy: map[string]bool{}}
+}
+
+func (s *Spool) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid recording id %q", id)
+	}
+	return filepath.Join(s.dir, id+".audio"), nil
+}
+
+// Append writes what r yields to the end of an upload and returns how many
+// bytes the upload now holds. offset must be the number held before, so a
+// client resuming after an error cannot leave a gap or write twice; when it
+// is not, ErrOffsetMismatch is returned with the current size. Whatever was
+// read before r failed is kept.
+func (s *Spool) Append(id string, offset int64, r io.Reader) (int64, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	if s.busy[id] {
+		s.mu.Unlock()
+		return 0, ErrUploadBusy
+	}
+	s.busy[id] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.busy, id)
+		s.mu.Unlock()
+	}()
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	size, end, err := scanFrames(f)
+	if err != nil {
+		return 0, err
+	}
+	if offset != size {
+		return size, ErrOffsetMismatch
+	}
+	// Drop a frame left half-written by a crash
+	if err := f.Truncate(end); err != nil {
+		return size, err
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		return size, err
+	}
+
+	buf := make([]byte, spoolFrame)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := s.writeFrame(f, buf[:n]); err != nil {
+				return size, err
+			}
+			size += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			return size, f.Sync()
+		}
+		if readErr != nil {
+			f.Sync()
+			return size, readErr
+		}
+	}
+}
+
+func (s *Spool) writeFrame(f *os.File, data []byte) error {
+	stored, flag := data, byte(0)
+	if s.keys != nil {
+		sealed, err := s.keys.Seal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt: %w", err)
+		}
+		stored, flag = []byte(sealed), 1
+	}
+
+	frame := make([]byte, spoolHeader, spoolHeader+len(stored))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(data)))
+	binary.BigEndian.PutUint32(frame[5:9], uint32(len(stored)))
+	_, err := f.Write(append(frame, stored...))
+	return err
+}
+
+// Size returns how many bytes of an upload have been received, 0 when none
+func (s *Spool) Size(id string) (int64, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	size, _, err := scanFrames(f)
+	return size, err
+}
+
+// scanFrames walks the frame headers of a spool file and returns the audio
+// bytes held and where the last complete frame ends
+func scanFrames(f *os.File) (size, end int64, err error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	header := make([]byte, spoolHeader)
+	for {
+		if _, err := f.ReadAt(header, end); err != nil {
+			if err == io.EOF {
+				return size, end, nil
+			}
+			return 0, 0, err
+		}
+		next := end + spoolHeader + int64(binary.BigEndian.Uint32(header[5:9]))
+		if next > info.Size() {
+			return size, end, nil
+		}
+		size += int64(binary.BigEndian.Uint32(header[1:5]))
+		end = next
+	}
+}
+
+// Open returns a reader over an upload's audio, decrypted
+func (s *Spool) Open(id string) (io.ReadCloser, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &spoolReader{f: f, keys: s.keys}, nil
+}
+
+// Remove deletes an upload's audio, if there is any
+func (s *Spool) Remove(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// removeAll deletes the spool directory with everything in it
+func (s *Spool) removeAll() error {
+	return os.RemoveAll(s.dir)
+}
+
+// spoolReader reads the frames of a spool file in order. A frame cut short
+// by a crash ends the audio.
+type spoolReader struct {
+	f       *os.File
+	keys    *Keyring
+	pending []byte
+}
+
+func (r *spoolReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *spoolReader) next() error {
+	header := make([]byte, spoolHeader)
+	if _, err := io.ReadFull(r.f, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return io.EOF
+		}
+		return err
+	}
+	stored := make([]byte, binary.BigEndian.Uint32(header[5:9]))
+	if _, err := io.ReadFull(r.f, stored); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return io.EOF
+		}
+		return err
+	}
+
+	if header[0] == 0 {
+		r.pending = stored
+		return nil
+	}
+	if r.keys == nil {
+		return ErrNoKey
+	}
+	plain, err := r.keys.Open(string(stored))
+	if err != nil {
+		return err
+	}
+	r.pending = plain
+	return nil
+}
+
+func (r *spoolReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,849 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"nexus-ai/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteFile is the database file name inside the data directory
+const sqliteFile = "nexus.db"
+
+// OpenSQLite opens (creating if needed) the database in dataDir and applies
+// any pending migrations. Live-state limits in opts are enforced when the
+// store is swept.
+func OpenSQLite(dataDir string, opts Options) (*Store, error) {
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	path := filepath.Join(dataDir, sqliteFile)
+	// secure_delete zeroes freed pages, so records replaced by their
+	// encrypted form do not linger in the file
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=secure_delete(1)"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// One connection serializes writes, which keeps the version checks in
+	// ProfileRepository.Save atomic without retry loops
+	conn.SetMaxOpenConns(1)
+
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	db := &sqliteDB{DB: conn, keys: opts.Keys}
+	if db.keys != nil {
+		fmt.Printf("[STORAGE] Using SQLite database at %s, encrypted with key %s\n", path, db.keys.CurrentID())
+	} else {
+		fmt.Printf("[STORAGE] Using SQLite database at %s, unencrypted\n", path)
+	}
+	rotation := db.startReencrypt()
+
+	return &Store{
+		Driver:          DriverSQLite,
+		Options:         opts,
+		Profiles:        &sqliteProfiles{db: db},
+		Sessions:        &sqliteSessions{db: db, opts: opts},
+		Memory:          &sqliteMemory{db: db, opts: opts},
+		JobDescriptions: &sqliteJobDescriptions{db: db},
+		Recordings:      &sqliteRecordings{db: db},
+		Spool:           NewSpool(filepath.Join(dataDir, "recordings"), opts.Keys),
+		close: func() error {
+			rotation.stop()
+			return conn.Close()
+		},
+	}, nil
+}
+
+// timeLayout is RFC 3339 with fixed-width nanoseconds, so stored times sort
+// correctly as text
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// sqliteDB is the database shared by the SQLite repositories, with the
+// keyring their records are sealed with. A nil keyring stores plaintext.
+type sqliteDB struct {
+	*sql.DB
+	keys *Keyring
+}
+
+// encode marshals v and seals it when a keyring is configured
+func (db *sqliteDB) encode(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode: %w", err)
+	}
+	return db.seal(data)
+}
+
+// decode opens a stored record and unmarshals it into v. Plaintext records
+// written before encryption was enabled are read as they are.
+func (db *sqliteDB) decode(data string, v any) error {
+	plain, err := db.open(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(plain, v); err != nil {
+		return fmt.Errorf("failed to decode: %w", err)
+	}
+	return nil
+}
+
+func (db *sqliteDB) seal(plain []byte) (string, error) {
+	if db.keys == nil {
+		return string(plain), nil
+	}
+	sealed, err := db.keys.Seal(plain)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+	return sealed, nil
+}
+
+func (db *sqliteDB) open(data string) ([]byte, error) {
+	if !isSealed(data) {
+		return []byte(data), nil
+	}
+	if db.keys == nil {
+		return nil, ErrNoKey
+	}
+	return db.keys.Open(data)
+}
+
+// plainColumn returns value for a lookup column that would otherwise hold
+// personal data in the clear, or "" when records are encrypted
+func (db *sqliteDB) plainColumn(value string) string {
+	if db.keys != nil {
+		return ""
+	}
+	return value
+}
+
+// queryIDs runs a query selecting a single text column. Rows are read in
+// full before returning: with one connection, nothing else can run while
+// they are open.
+func queryIDs(db *sqliteDB, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// notFound maps sql.ErrNoRows onto ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type sqliteProfiles struct {
+	db *sqliteDB
+}
+
+func (r *sqliteProfiles) Get(id string) (*models.UserProfile, error) {
+	var data string
+	if err := r.db.QueryRow(`SELECT data FROM profiles WHERE id = ?`, id).Scan(&data); err != nil {
+		return nil, notFound(err)
+	}
+	var profile models.UserProfile
+	if err := r.db.decode(data, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *sqliteProfiles) GetWithVersion(id string) (*models.UserProfile, int, error) {
+	var data string
+	var version int
+	if err := r.db.QueryRow(`SELECT data, version FROM profiles WHERE id = ?`, id).Scan(&data, &version); err != nil {
+		return nil, 0, notFound(err)
+	}
+	var profile models.UserProfile
+	if err := r.db.decode(data, &profile); err != nil {
+		return nil, 0, err
+	}
+	return &profile, version, nil
+}
+
+func (r *sqliteProfiles) List() ([]ProfileSummary, error) {
+	rows, err := r.db.Query(`SELECT id, version, data, updated_at FROM profiles ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []ProfileSummary{}
+	for rows.Next() {
+		var s ProfileSummary
+		var data, updated string
+		if err := rows.Scan(&s.ID, &s.Version, &data, &updated); err != nil {
+			return nil, err
+		}
+		// The name column is blank when profiles are encrypted
+		var profile struct {
+			Name string `json:"name"`
+		}
+		if err := r.db.decode(data, &profile); err != nil {
+			return nil, err
+		}
+		s.Name = profile.Name
+		s.UpdatedAt = parseTime(updated)
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteProfiles) Save(version *models.ProfileVersion, expected int) error {
+	data, err := r.db.encode(version.Profile)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	latest := 0
+	err = tx.QueryRow(`SELECT version FROM profiles WHERE id = ?`, version.ProfileID).Scan(&latest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if expected != 0 && latest != expected {
+		return ErrVersionConflict
+	}
+
+	version.Version = latest + 1
+	created := formatTime(version.CreatedAt)
+
+	if _, err := tx.Exec(`INSERT INTO profiles (id, name, version, data, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, version = excluded.version, data = excluded.data, updated_at = excluded.updated_at`,
+		version.ProfileID, r.db.plainColumn(version.Profile.Name), version.Version, data, created); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO profile_versions (profile_id, version, source, note, data, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		version.ProfileID, version.Version, version.Source, version.Note, data, created); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqliteProfiles) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM profiles WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteProfiles) LatestVersion(id string) (int, error) {
+	var version int
+	if err := r.db.QueryRow(`SELECT version FROM profiles WHERE id = ?`, id).Scan(&version); err != nil {
+		return 0, notFound(err)
+	}
+	return version, nil
+}
+
+func (r *sqliteProfiles) Versions(id string) ([]*models.ProfileVersion, error) {
+	if _, err := r.LatestVersion(id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT version, source, note, data, created_at FROM profile_versions
+		WHERE profile_id = ? ORDER BY version`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.ProfileVersion{}
+	for rows.Next() {
+		v, err := scanProfileVersion(r.db, id, rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteProfiles) Version(id string, version int) (*models.ProfileVersion, error) {
+	row := r.db.QueryRow(`SELECT version, source, note, data, created_at FROM profile_versions
+		WHERE profile_id = ? AND version = ?`, id, version)
+	v, err := scanProfileVersion(r.db, id, row)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return v, nil
+}
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProfileVersion(db *sqliteDB, profileID string, row scanner) (*models.ProfileVersion, error) {
+	v := &models.ProfileVersion{ProfileID: profileID, Profile: &models.UserProfile{}}
+	var data, created string
+	if err := row.Scan(&v.Version, &v.Source, &v.Note, &data, &created); err != nil {
+		return nil, err
+	}
+	v.CreatedAt = parseTime(created)
+	if err := db.decode(data, v.Profile); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *sqliteProfiles) SaveVariant(profileID string, variant *models.ProfileVariant) error {
+	if _, err := r.LatestVersion(profileID); err != nil {
+		return err
+	}
+	data, err := r.db.encode(variant)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO profile_variants (profile_id, name, data, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(profile_id, name) DO UPDATE SET data = excluded.data, created_at = excluded.created_at`,
+		profileID, variant.Name, data, formatTime(variant.CreatedAt))
+	return err
+}
+
+func (r *sqliteProfiles) Variants(profileID string) ([]*models.ProfileVariant, error) {
+	if _, err := r.LatestVersion(profileID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT data FROM profile_variants WHERE profile_id = ? ORDER BY created_at`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.ProfileVariant{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var v models.ProfileVariant
+		if err := r.db.decode(data, &v); err != nil {
+			return nil, err
+		}
+		list = append(list, &v)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteProfiles) Variant(profileID, name string) (*models.ProfileVariant, error) {
+	var data string
+	if err := r.db.QueryRow(`SELECT data FROM profile_variants WHERE profile_id = ? AND name = ?`, profileID, name).Scan(&data); err != nil {
+		return nil, notFound(err)
+	}
+	var v models.ProfileVariant
+	if err := r.db.decode(data, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+type sqliteSessions struct {
+	expired uint64 // first for 64-bit alignment of atomics
+	evicted uint64
+	db      *sqliteDB
+	opts    Options
+}
+
+func (r *sqliteSessions) Get(id string) (*models.InterviewSession, error) {
+	session, err := r.GetMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	messages, _, err := r.Messages(id, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	session.Messages = messages
+	return session, nil
+}
+
+func (r *sqliteSessions) GetMeta(id string) (*models.InterviewSession, error) {
+	var data, lastActivity string
+	if err := r.db.QueryRow(`SELECT data, last_activity FROM sessions WHERE id = ?`, id).Scan(&data, &lastActivity); err != nil {
+		return nil, notFound(err)
+	}
+	var session models.InterviewSession
+	if err := r.db.decode(data, &session); err != nil {
+		return nil, err
+	}
+	session.LastActivity = parseTime(lastActivity)
+	return &session, nil
+}
+
+func (r *sqliteSessions) Save(session *models.InterviewSession) error {
+	// Messages live in session_messages
+	doc := *session
+	doc.Messages = nil
+	doc.LastActivity = time.Now()
+	data, err := r.db.encode(&doc)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM sessions WHERE id = ?`, session.SessionID).Scan(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO sessions (id, profile_id, is_active, data, started_at, last_activity) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET profile_id = excluded.profile_id, is_active = excluded.is_active,
+			data = excluded.data, last_activity = excluded.last_activity`,
+		session.SessionID, session.ProfileID, session.IsActive, data, formatTime(session.StartedAt), formatTime(doc.LastActivity)); err != nil {
+		return err
+	}
+	if exists == 0 {
+		if err := appendMessages(r.db, tx, session.SessionID, session.Messages); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteSessions) List() ([]*models.InterviewSession, error) {
+	rows, err := r.db.Query(`SELECT data FROM sessions ORDER BY started_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.InterviewSession{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var s models.InterviewSession
+		if err := r.db.decode(data, &s); err != nil {
+			return nil, err
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteSessions) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteSessions) AppendMessages(id string, messages ...models.InterviewMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE sessions SET last_activity = ? WHERE id = ?`, formatTime(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	if err := appendMessages(r.db, tx, id, messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appendMessages numbers messages after the session's last one and inserts
+// them within tx
+func appendMessages(db *sqliteDB, tx *sql.Tx, sessionID string, messages []models.InterviewMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var last int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM session_messages WHERE session_id = ?`, sessionID).Scan(&last); err != nil {
+		return err
+	}
+	for i, m := range messages {
+		m.Seq = last + i + 1
+		data, err := db.encode(m)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO session_messages (session_id, seq, data, created_at) VALUES (?, ?, ?, ?)`,
+			sessionID, m.Seq, data, formatTime(m.Timestamp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *sqliteSessions) Messages(id string, offset, limit int) ([]models.InterviewMessage, int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT (SELECT COUNT(*) FROM session_messages WHERE session_id = ?)
+		FROM sessions WHERE id = ?`, id, id).Scan(&total)
+	if err != nil {
+		return nil, 0, notFound(err)
+	}
+
+	// A negative LIMIT means no limit in SQLite
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.db.Query(`SELECT data FROM session_messages WHERE session_id = ?
+		ORDER BY seq LIMIT ? OFFSET ?`, id, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	messages := []models.InterviewMessage{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, err
+		}
+		var m models.InterviewMessage
+		if err := r.db.decode(data, &m); err != nil {
+			return nil, 0, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, total, rows.Err()
+}
+
+func (r *sqliteSessions) Sweep(now time.Time) error {
+	if r.opts.SessionTTL > 0 {
+		ids, err := queryIDs(r.db, `SELECT id FROM sessions WHERE is_active = 1 AND last_activity < ?`,
+			formatTime(now.Add(-r.opts.SessionTTL)))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := r.end(id, now); err != nil {
+				return err
+			}
+			atomic.AddUint64(&r.expired, 1)
+		}
+	}
+
+	if r.opts.MaxSessions > 0 {
+		ids, err := queryIDs(r.db, `SELECT id FROM sessions WHERE is_active = 1
+			ORDER BY last_activity DESC LIMIT -1 OFFSET ?`, r.opts.MaxSessions)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := r.end(id, now); err != nil {
+				return err
+			}
+			atomic.AddUint64(&r.evicted, 1)
+		}
+	}
+	return nil
+}
+
+// end marks an active session as ended, leaving its last activity alone
+func (r *sqliteSessions) end(id string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var data string
+	if err := tx.QueryRow(`SELECT data FROM sessions WHERE id = ?`, id).Scan(&data); err != nil {
+		return notFound(err)
+	}
+	var session models.InterviewSession
+	if err := r.db.decode(data, &session); err != nil {
+		return err
+	}
+	markEnded(&session, now)
+	if data, err = r.db.encode(&session); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE sessions SET is_active = 0, data = ? WHERE id = ?`, data, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteSessions) Stats() (LiveStats, error) {
+	var active, total int
+	if err := r.db.QueryRow(`SELECT COALESCE(SUM(is_active), 0), COUNT(*) FROM sessions`).Scan(&active, &total); err != nil {
+		return LiveStats{}, err
+	}
+	return LiveStats{
+		Live:     active,
+		Archived: total - active,
+		Expired:  atomic.LoadUint64(&r.expired),
+		Evicted:  atomic.LoadUint64(&r.evicted),
+	}, nil
+}
+
+type sqliteMemory struct {
+	expired uint64 // first for 64-bit alignment of atomics
+	evicted uint64
+	db      *sqliteDB
+	opts    Options
+}
+
+func (r *sqliteMemory) Get(sessionID string) (*models.SessionMemory, error) {
+	var data string
+	if err := r.db.QueryRow(`SELECT data FROM session_memory WHERE session_id = ?`, sessionID).Scan(&data); err != nil {
+		return nil, notFound(err)
+	}
+	var mem models.SessionMemory
+	if err := r.db.decode(data, &mem); err != nil {
+		return nil, err
+	}
+	return &mem, nil
+}
+
+func (r *sqliteMemory) Append(sessionID string, pair models.QAPair, limit int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	mem := models.SessionMemory{QA: []models.QAPair{}}
+	var data string
+	err = tx.QueryRow(`SELECT data FROM session_memory WHERE session_id = ?`, sessionID).Scan(&data)
+	switch {
+	case err == nil:
+		if err := r.db.decode(data, &mem); err != nil {
+			return err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	mem.QA = append(mem.QA, pair)
+	if limit > 0 && len(mem.QA) > limit {
+		mem.QA = mem.QA[len(mem.QA)-limit:]
+	}
+
+	if data, err = r.db.encode(mem); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO session_memory (session_id, data, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		sessionID, data, formatTime(time.Now())); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteMemory) Delete(sessionID string) error {
+	_, err := r.db.Exec(`DELETE FROM session_memory WHERE session_id = ?`, sessionID)
+	return err
+}
+
+func (r *sqliteMemory) Sweep(now time.Time) error {
+	if r.opts.MemoryTTL > 0 {
+		res, err := r.db.Exec(`DELETE FROM session_memory WHERE updated_at < ?`, formatTime(now.Add(-r.opts.MemoryTTL)))
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		atomic.AddUint64(&r.expired, uint64(n))
+	}
+
+	if r.opts.MaxMemory > 0 {
+		res, err := r.db.Exec(`DELETE FROM session_memory WHERE session_id IN (
+			SELECT session_id FROM session_memory ORDER BY updated_at DESC LIMIT -1 OFFSET ?)`, r.opts.MaxMemory)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		atomic.AddUint64(&r.evicted, uint64(n))
+	}
+	return nil
+}
+
+func (r *sqliteMemory) Stats() (LiveStats, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM session_memory`).Scan(&count); err != nil {
+		return LiveStats{}, err
+	}
+	return LiveStats{
+		Live:    count,
+		Expired: atomic.LoadUint64(&r.expired),
+		Evicted: atomic.LoadUint64(&r.evicted),
+	}, nil
+}
+
+type sqliteJobDescriptions struct {
+	db *sqliteDB
+}
+
+func (r *sqliteJobDescriptions) Get(id string) (*models.JobDescription, error) {
+	var data string
+	if err := r.db.QueryRow(`SELECT data FROM job_descriptions WHERE id = ?`, id).Scan(&data); err != nil {
+		return nil, notFound(err)
+	}
+	var jd models.JobDescription
+	if err := r.db.decode(data, &jd); err != nil {
+		return nil, err
+	}
+	return &jd, nil
+}
+
+func (r *sqliteJobDescriptions) Save(jd *models.JobDescription) error {
+	data, err := r.db.encode(jd)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO job_descriptions (id, role, company, data, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET role = excluded.role, company = excluded.company, data = excluded.data`,
+		jd.ID, jd.Role, jd.Company, data, formatTime(jd.CreatedAt))
+	return err
+}
+
+func (r *sqliteJobDescriptions) List() ([]*models.JobDescription, error) {
+	rows, err := r.db.Query(`SELECT data FROM job_descriptions ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.JobDescription{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var jd models.JobDescription
+		if err := r.db.decode(data, &jd); err != nil {
+			return nil, err
+		}
+		list = append(list, &jd)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteJobDescriptions) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM job_descriptions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type sqliteRecordings struct {
+	db *sqliteDB
+}
+
+func (r *sqliteRecordings) Get(id string) (*models.Recording, error) {
+	var data string
+	if err := r.db.QueryRow(`SELECT data FROM recordings WHERE id = ?`, id).Scan(&data); err != nil {
+		return nil, notFound(err)
+	}
+	var rec models.Recording
+	if err := r.db.decode(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *sqliteRecordings) Save(rec *models.Recording) error {
+	data, err := r.db.encode(rec)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO recordings (id, status, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET status = excluded.status, data = excluded.data, updated_at = excluded.updated_at`,
+		rec.ID, rec.Status, data, formatTime(rec.CreatedAt), formatTime(rec.UpdatedAt))
+	return err
+}
+
+func (r *sqliteRecordings) List() ([]*models.Recording, error) {
+	rows, err := r.db.Query(`SELECT data FROM recordings ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.Recording{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var rec models.Recording
+		if err := r.db.decode(data, &rec); err != nil {
+			return nil, err
+		}
+		list = append(list, &rec)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqliteRecordings) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM recordings WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
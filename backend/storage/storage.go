@@ -0,0 +1,182 @@
+// Package storage persists profiles, interview sessions, session memory, job
+// descriptions and uploaded recordings behind one repository interface per
+// entity. Two drivers are provided: an embedded SQLite database (pure Go, no
+// cgo) for normal use and plain in-memory maps for tests and throwaway
+// instances.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nexus-ai/models"
+)
+
+// Storage drivers accepted by Open
+const (
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// Options bounds the live state a store keeps: interview sessions and the
+// recent Q&A of live sessions. Zero values mean no limit. The memory driver
+// enforces caps on every write; SQLite enforces everything when swept.
+// Keys, if set, encrypts what is written to disk.
+type Options struct {
+	// SessionTTL ends active sessions with no activity for this long
+	SessionTTL time.Duration
+	// MemoryTTL drops the Q&A memory of sessions idle for this long
+	MemoryTTL time.Duration
+	// MaxSessions caps active sessions; the least recently used are ended
+	// to make room
+	MaxSessions int
+	// MaxMemory caps the sessions with Q&A memory, dropping the least
+	// recently used
+	MaxMemory int
+	// MaxArchived caps ended sessions kept by the memory driver. SQLite
+	// keeps every ended session.
+	MaxArchived int
+	// Keys seals profile, session, transcript and memory records before
+	// they are written, and uploaded recordings as they are spooled. The
+	// memory driver only writes recordings to disk.
+	Keys *Keyring
+}
+
+// LiveStats counts the entries a repository holds and what it has expired
+// or evicted since start-up
+type LiveStats struct {
+	Live     int    `json:"live"`
+	Archived int    `json:"archived,omitempty"`
+	Expired  uint64 `json:"expired"`
+	Evicted  uint64 `json:"evicted"`
+}
+
+// ProfileSummary is the listing form of a profile
+type ProfileSummary struct {
+	ID        string    `json:"profile_id"`
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProfileRepository stores profiles with their version history and tailored
+// variants
+type ProfileRepository interface {
+	Get(id string) (*models.UserProfile, error)
+	// GetWithVersion returns a profile together with the version it is at,
+	// read in one step
+	GetWithVersion(id string) (*models.UserProfile, int, error)
+	List() ([]ProfileSummary, error)
+	// Save makes version.Profile the current profile and appends version to
+	// its history, assigning version.Version. With a non-zero expected
+	// version it fails with ErrVersionConflict unless the latest version is
+	// still expected.
+	Save(version *models.ProfileVersion, expected int) error
+	// Delete removes a profile with its versions and variants
+	Delete(id string) error
+	// LatestVersion returns the current version number of a profile
+	LatestVersion(id string) (int, error)
+	Versions(id string) ([]*models.ProfileVersion, error)
+	Version(id string, version int) (*models.ProfileVersion, error)
+	SaveVariant(profileID string, variant *models.ProfileVariant) error
+	Variants(profileID string) ([]*models.ProfileVariant, error)
+	Variant(profileID, name string) (*models.ProfileVariant, error)
+}
+
+// SessionRepository stores interview sessions. A session's transcript is
+// only grown through AppendMessages: Save leaves recorded messages alone, and
+// List returns sessions without them.
+type SessionRepository interface {
+	Get(id string) (*models.InterviewSession, error)
+	// GetMeta returns a session without its transcript
+	GetMeta(id string) (*models.InterviewSession, error)
+	Save(session *models.InterviewSession) error
+	List() ([]*models.InterviewSession, error)
+	Delete(id string) error
+	// AppendMessages adds messages to the end of a session's transcript in
+	// one step, assigning their Seq
+	AppendMessages(id string, messages ...models.InterviewMessage) error
+	// Messages returns up to limit messages starting at offset, along with
+	// the total number of messages
+	Messages(id string, offset, limit int) ([]models.InterviewMessage, int, error)
+	// Sweep ends active sessions that have been idle past the TTL or exceed
+	// the cap, archiving them
+	Sweep(now time.Time) error
+	// Stats counts active (live) and ended (archived) sessions
+	Stats() (LiveStats, error)
+}
+
+// MemoryRepository stores the recent Q&A of live interview sessions
+type MemoryRepository interface {
+	Get(sessionID string) (*models.SessionMemory, error)
+	// Append adds a Q&A pair, keeping only the last limit pairs
+	Append(sessionID string, pair models.QAPair, limit int) error
+	Delete(sessionID string) error
+	// Sweep drops memory idle past the TTL or beyond the cap
+	Sweep(now time.Time) error
+	Stats() (LiveStats, error)
+}
+
+// JobDescriptionRepository stores parsed job descriptions
+type JobDescriptionRepository interface {
+	Get(id string) (*models.JobDescription, error)
+	Save(jd *models.JobDescription) error
+	List() ([]*models.JobDescription, error)
+	Delete(id string) error
+}
+
+// RecordingRepository stores long recordings uploaded for transcription.
+// The audio itself is kept in the store's Spool until it is transcribed.
+type RecordingRepository interface {
+	Get(id string) (*models.Recording, error)
+	Save(recording *models.Recording) error
+	// List returns recordings newest first
+	List() ([]*models.Recording, error)
+	Delete(id string) error
+}
+
+// Store bundles the repositories of one storage backend
+type Store struct {
+	Driver          string
+	Options         Options
+	Profiles        ProfileRepository
+	Sessions        SessionRepository
+	Memory          MemoryRepository
+	JobDescriptions JobDescriptionRepository
+	Recordings      RecordingRepository
+	Spool           *Spool
+
+	close     func() error
+	janitor   *janitor
+	sweepLock sync.Mutex
+	lastSweep time.Time
+}
+
+// Close stops the janitor and releases the underlying database, if any
+func (s *Store) Close() error {
+	s.StopJanitor()
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// Open returns the store for a driver. SQLite keeps its database file in
+// dataDir, which is created if needed.
+func Open(driver, dataDir string, opts Options) (*Store, error) {
+	switch driver {
+	case DriverSQLite, "":
+		return OpenSQLite(dataDir, opts)
+	case DriverMemory:
+		return NewMemoryStoreWithOptions(opts), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (use %s or %s)", driver, DriverSQLite, DriverMemory)
+	}
+}